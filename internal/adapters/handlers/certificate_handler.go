@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"opc_ua_service/internal/domain/models"
+	"strings"
+)
+
+// ListServerCertificates возвращает сертификаты серверов из хранилища PKI
+// @Summary Список сертификатов серверов
+// @Description Возвращает сертификаты OPC UA серверов из хранилища: доверенные (trusted) и отклонённые (rejected). Отклонённые сертификаты попадают в карантин автоматически при неудачном подключении.
+// @Tags Certificates
+// @Produce json
+// @Param store query string false "Раздел хранилища: trusted, rejected, issuers"
+// @Success 200 {object} swagger.ServerCertificateListResponse "Список сертификатов"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/certificates/server [get]
+func (h *Handler) ListServerCertificates(c *gin.Context) {
+	store := models.CertificateStoreEnum(c.Query("store"))
+
+	resp, eerr := h.usecase.ListServerCertificates(store)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, "Successfully get server certificates", Object, resp)
+}
+
+// TrustServerCertificate добавляет сертификат сервера в доверенные
+// @Summary Доверять сертификату сервера
+// @Description Переносит сертификат сервера из карантина (rejected) в доверенные (trusted) по SHA-1 отпечатку
+// @Tags Certificates
+// @Produce json
+// @Param thumbprint path string true "SHA-1 отпечаток сертификата"
+// @Success 200 {object} swagger.ServerCertificateResponse "Сертификат доверен"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Сертификат не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/certificates/server/{thumbprint}/trust [post]
+func (h *Handler) TrustServerCertificate(c *gin.Context) {
+	info, eerr := h.usecase.TrustServerCertificate(c.Param("thumbprint"))
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Certificate %s trusted", info.Thumbprint), Object, info)
+}
+
+// RevokeServerCertificate отзывает доверие к сертификату сервера
+// @Summary Отозвать доверие к сертификату сервера
+// @Description Переносит сертификат сервера из доверенных (trusted) в карантин (rejected). Новые подключения к серверу с этим сертификатом будут отклоняться.
+// @Tags Certificates
+// @Produce json
+// @Param thumbprint path string true "SHA-1 отпечаток сертификата"
+// @Success 200 {object} swagger.ServerCertificateResponse "Доверие отозвано"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Сертификат не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/certificates/server/{thumbprint}/revoke [post]
+func (h *Handler) RevokeServerCertificate(c *gin.Context) {
+	info, eerr := h.usecase.RevokeServerCertificate(c.Param("thumbprint"))
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Certificate %s revoked", info.Thumbprint), Object, info)
+}
+
+// GetApplicationCertificate возвращает сведения о сертификате приложения сервиса
+// @Summary Сертификат приложения
+// @Description Возвращает сведения о сертификате экземпляра клиентского приложения, которым сервис подключается к станкам по умолчанию
+// @Tags Certificates
+// @Produce json
+// @Success 200 {object} swagger.ApplicationCertificateResponse "Сертификат приложения"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/certificates/client [get]
+func (h *Handler) GetApplicationCertificate(c *gin.Context) {
+	info, eerr := h.usecase.GetApplicationCertificate()
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, "Successfully get application certificate", Object, info)
+}
+
+// DownloadApplicationCertificate отдает сертификат приложения файлом
+// @Summary Скачать сертификат приложения
+// @Description Возвращает сертификат приложения файлом для добавления в список доверенных на ЧПУ
+// @Tags Certificates
+// @Produce octet-stream
+// @Param format query string false "Формат файла: der (по умолчанию) или pem"
+// @Success 200 {file} file "Сертификат"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/certificates/client/download [get]
+func (h *Handler) DownloadApplicationCertificate(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "der"))
+
+	data, eerr := h.usecase.DownloadApplicationCertificate(format)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	contentType := "application/pkix-cert"
+	if format == "pem" {
+		contentType = "application/x-pem-file"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=client.%s", format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// RenewApplicationCertificate перевыпускает сертификат приложения
+// @Summary Перевыпустить сертификат приложения
+// @Description Генерирует новый ключ и самоподписанный сертификат приложения. Открытые сессии продолжают работать, новые подключения используют новый сертификат — его нужно заново добавить в доверенные на ЧПУ.
+// @Tags Certificates
+// @Produce json
+// @Success 200 {object} swagger.ApplicationCertificateResponse "Новый сертификат приложения"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/certificates/client/renew [post]
+func (h *Handler) RenewApplicationCertificate(c *gin.Context) {
+	info, eerr := h.usecase.RenewApplicationCertificate()
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, "Application certificate renewed", Object, info)
+}
+
+// GetCertificateExpiry возвращает сроки действия клиентских сертификатов станков
+// @Summary Сроки действия сертификатов
+// @Description Возвращает для каждого станка с подключением по сертификату число дней до истечения клиентского сертификата и его состояние: ok, expiring, expired, invalid
+// @Tags Certificates
+// @Produce json
+// @Success 200 {object} swagger.CertificateExpiryResponse "Сроки действия сертификатов"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/certificates/expiry [get]
+func (h *Handler) GetCertificateExpiry(c *gin.Context) {
+	resp, eerr := h.usecase.CheckCertificateExpiry()
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, "Successfully get certificate expiry", Object, resp)
+}
@@ -89,7 +89,7 @@ func (h *Handler) CloseConnection(c *gin.Context) {
 
 // CheckConnection проверяет здоровье соединения по UUID
 // @Summary Проверка соединения
-// @Description Проверяет состояние соединения OPC UA по UUID
+// @Description Возвращает состояние соединения OPC UA по UUID: Connecting, Connected, Degraded, Reconnecting, AuthFailed или Closed, время переходов и последнюю ошибку. AuthFailed означает, что сервер отклонил учётные данные или сертификат, Reconnecting со статусом TIMEOUT - что станок недоступен.
 // @Tags Connection
 // @Accept json
 // @Produce json
@@ -108,6 +108,7 @@ func (h *Handler) CheckConnection(c *gin.Context) {
 	id, err := uuid.Parse(req.UUID)
 	if err != nil {
 		h.BadRequest(c, fmt.Errorf("incorrect UUID: %s", req.UUID))
+		return
 	}
 
 	connInfo, eerr := h.usecase.GetConnectionState(id)
@@ -130,3 +131,59 @@ func (h *Handler) GetConnectionPool(c *gin.Context) {
 	resp := h.usecase.GetActiveConnections()
 	h.ResultResponse(c, "Successfully get connection pool", Object, resp)
 }
+
+// GetEndpoints возвращает endpoint'ы OPC UA сервера до регистрации станка
+// @Summary Endpoint'ы сервера
+// @Description Выполняет GetEndpoints для указанного URL и возвращает политики безопасности, режимы, типы токенов пользователя с PolicyID и сведения о сертификате сервера. Используется для формирования ConnectionRequest.
+// @Tags Connection
+// @Accept json
+// @Produce json
+// @Param input body models.EndpointsRequest true "URL OPC UA сервера"
+// @Success 200 {object} swagger.EndpointsResponse "Список endpoint'ов"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/connect/endpoints [post]
+func (h *Handler) GetEndpoints(c *gin.Context) {
+	var req models.EndpointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.BadRequest(c, err)
+		return
+	}
+
+	resp, eerr := h.usecase.DiscoverEndpoints(req)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, "Successfully get endpoints", Object, resp)
+}
+
+// RotateCertificate заменяет клиентский сертификат станка без потери UUID и опроса
+// @Summary Замена сертификата станка
+// @Description Принимает новую пару сертификат/ключ (Base64), переустанавливает сессию с новыми данными и сохраняет их в БД. UUID станка и запущенный опрос сохраняются; при ошибке подключения остаётся прежняя сессия.
+// @Tags Connection
+// @Accept json
+// @Produce json
+// @Param input body models.RotateCertificateRequest true "UUID станка и новая пара сертификат/ключ"
+// @Success 200 {object} swagger.RotateCertificateResponse "Сертификат заменён"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса или некорректные данные"
+// @Failure 404 {object} swagger.NotFoundError "Станок не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/connect/certificate [post]
+func (h *Handler) RotateCertificate(c *gin.Context) {
+	var req models.RotateCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.BadRequest(c, err)
+		return
+	}
+
+	resp, eerr := h.usecase.RotateCertificate(req)
+	if eerr != nil {
+		h.logger.Error("Certificate rotation failed", "UUID", req.UUID, "error", eerr)
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Certificate rotated for machine %s", resp.UUID), Object, resp)
+}
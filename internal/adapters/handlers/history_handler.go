@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"opc_ua_service/internal/domain/models"
+)
+
+// ReadHistory возвращает исторические значения узлов станка
+// @Summary Исторические значения узлов
+// @Description Выполняет HistoryReadRaw для узлов станка (по NodeID или пути просмотра) за интервал времени и догружает значения по continuation point. Если значений у узла больше maxValuesPerNode, оставшиеся не читаются, а результат узла помечается как усечённый (truncated). Работает только для серверов, хранящих историю узлов.
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Param input body models.HistoryReadRequest true "Узлы и интервал времени"
+// @Success 200 {object} swagger.HistoryReadResponse "Исторические значения"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Соединение не найдено"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Failure 503 {object} swagger.InternalServerError "Соединение со станком недоступно"
+// @Router /api/v1/machines/{uuid}/history [post]
+func (h *Handler) ReadHistory(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.HistoryReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.BadRequest(c, err)
+		return
+	}
+
+	resp, eerr := h.usecase.ReadHistory(id, req)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Read history of %d nodes of machine %s", len(resp.Results), id), Object, resp)
+}
+
+// StartBackfill запускает дозаполнение данных станка из истории
+// @Summary Дозаполнение данных из истории
+// @Description Запускает в фоне задачу: история узлов модели станка за интервал собирается в снимки по меткам времени и отправляется в Kafka с полем backfilled=true. Узлы без истории заполняются текущими значениями. Для станка одновременно выполняется одна задача, её состояние возвращает GET того же адреса.
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Param input body models.BackfillRequest true "Интервал времени"
+// @Success 200 {object} swagger.BackfillJobResponse "Задача запущена"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Соединение не найдено"
+// @Failure 409 {object} swagger.IncorrectDataError "Задача для станка уже выполняется"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Failure 503 {object} swagger.InternalServerError "Соединение со станком недоступно"
+// @Router /api/v1/machines/{uuid}/history/backfill [post]
+func (h *Handler) StartBackfill(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.BackfillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.BadRequest(c, err)
+		return
+	}
+
+	job, eerr := h.usecase.StartBackfill(id, req)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Backfill started for machine %s", id), Object, job)
+}
+
+// GetBackfillJob возвращает состояние задачи дозаполнения
+// @Summary Состояние дозаполнения
+// @Description Возвращает последнюю задачу дозаполнения станка: состояние, число прочитанных значений и отправленных в Kafka снимков
+// @Tags History
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Success 200 {object} swagger.BackfillJobResponse "Задача дозаполнения"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Задача не найдена"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/machines/{uuid}/history/backfill [get]
+func (h *Handler) GetBackfillJob(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	job, eerr := h.usecase.GetBackfillJob(id)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, "Successfully get backfill job", Object, job)
+}
@@ -45,16 +45,56 @@ func ProvideRouter(h *Handler, cfg *config.Config, swagCfg *swagger.Config) http
 
 	// Подключение
 	connectGroup := baseRouter.Group("/connect")
-	connectGroup.POST("/", h.AddConnection)        // Добавить соединение
-	connectGroup.GET("/", h.GetConnectionPool)     // Получить пул открытых соединений
-	connectGroup.DELETE("/", h.CloseConnection)    // Закрыть соединение
-	connectGroup.POST("/check", h.CheckConnection) // Проверить соединение
+	connectGroup.POST("/", h.AddConnection)                // Добавить соединение
+	connectGroup.GET("/", h.GetConnectionPool)             // Получить пул открытых соединений
+	connectGroup.DELETE("/", h.CloseConnection)            // Закрыть соединение
+	connectGroup.POST("/check", h.CheckConnection)         // Проверить соединение
+	connectGroup.POST("/endpoints", h.GetEndpoints)        // Получить endpoint'ы сервера
+	connectGroup.POST("/certificate", h.RotateCertificate) // Заменить сертификат станка
 
 	// Мониторинг
 	pollingGroup := baseRouter.Group("/polling")
 	pollingGroup.GET("/start", h.StartPollingByUUID)
 	pollingGroup.GET("/stop", h.StopPollingByUUID)
 
+	// Хранилище сертификатов серверов
+	certGroup := baseRouter.Group("/certificates")
+	certGroup.GET("/server", h.ListServerCertificates)                      // Список доверенных и отклонённых сертификатов
+	certGroup.POST("/server/:thumbprint/trust", h.TrustServerCertificate)   // Доверять сертификату
+	certGroup.POST("/server/:thumbprint/revoke", h.RevokeServerCertificate) // Отозвать доверие
+	certGroup.GET("/client", h.GetApplicationCertificate)                   // Сертификат приложения сервиса
+	certGroup.GET("/client/download", h.DownloadApplicationCertificate)     // Скачать сертификат приложения
+	certGroup.POST("/client/renew", h.RenewApplicationCertificate)          // Перевыпустить сертификат приложения
+	certGroup.GET("/expiry", h.GetCertificateExpiry)                        // Сроки действия сертификатов станков
+
+	// Адресное пространство станков
+	machinesGroup := baseRouter.Group("/machines")
+	machinesGroup.POST("/:uuid/browse", h.BrowseNodes)                 // Обход адресного пространства
+	machinesGroup.POST("/:uuid/read", h.ReadNodes)                     // Чтение узлов по NodeID или пути просмотра
+	machinesGroup.POST("/:uuid/write", h.WriteNodes)                   // Запись в разрешённые узлы
+	machinesGroup.GET("/:uuid/write/audit", h.GetWriteAudit)           // Журнал аудита записи
+	machinesGroup.GET("/:uuid/writable-nodes", h.GetWritableNodes)     // Узлы, разрешённые для записи
+	machinesGroup.PUT("/:uuid/writable-nodes", h.SetWritableNodes)     // Задать узлы, разрешённые для записи
+	machinesGroup.POST("/:uuid/call", h.CallMethod)                    // Вызов метода OPC UA
+	machinesGroup.GET("/:uuid/callable-methods", h.GetCallableMethods) // Методы, разрешённые для вызова
+	machinesGroup.PUT("/:uuid/callable-methods", h.SetCallableMethods) // Задать методы, разрешённые для вызова
+	machinesGroup.POST("/:uuid/history", h.ReadHistory)                // Исторические значения узлов
+	machinesGroup.POST("/:uuid/history/backfill", h.StartBackfill)     // Дозаполнение данных из истории в Kafka
+	machinesGroup.GET("/:uuid/history/backfill", h.GetBackfillJob)     // Состояние дозаполнения
+	machinesGroup.GET("/:uuid/profile", h.GetMachineProfile)           // Профиль, по которому собираются данные станка
+	machinesGroup.PUT("/:uuid/profile", h.PinMachineProfile)           // Закрепить версию профиля
+	machinesGroup.DELETE("/:uuid/profile", h.UnpinMachineProfile)      // Снять закрепление версии профиля
+
+	// Реестр профилей моделей станков
+	profilesGroup := baseRouter.Group("/profiles")
+	profilesGroup.GET("/", h.ListProfiles)                                   // Список профилей
+	profilesGroup.POST("/", h.CreateProfileVersion)                          // Загрузить новую версию профиля
+	profilesGroup.POST("/validate", h.ValidateProfile)                       // Проверить профиль без сохранения
+	profilesGroup.GET("/:name", h.GetProfile)                                // Последняя версия профиля
+	profilesGroup.DELETE("/:name", h.DeleteProfile)                          // Удалить все версии профиля
+	profilesGroup.GET("/:name/versions/:version", h.GetProfileVersion)       // Версия профиля
+	profilesGroup.DELETE("/:name/versions/:version", h.DeleteProfileVersion) // Удалить версию профиля
+
 	//baseRouter.GET("/control", h.GetControlProgram) // Получить управляющую программу
 
 	return r
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io"
+	"opc_ua_service/internal/domain/models"
+	"strconv"
+)
+
+// BrowseNodes обходит адресное пространство станка
+// @Summary Обход адресного пространства станка
+// @Description Возвращает дерево узлов OPC UA сервера станка от начального узла (по умолчанию папка Objects) на заданную глубину. Для переменных дополнительно читаются DataType и текущее значение. Большие папки догружаются через BrowseNext; при достижении maxNodes ответ помечается как усечённый (truncated). Тело запроса необязательно.
+// @Tags Nodes
+// @Accept json
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Param input body models.BrowseRequest false "Параметры обхода"
+// @Success 200 {object} swagger.BrowseResponse "Дерево узлов"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Соединение не найдено"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Failure 503 {object} swagger.InternalServerError "Соединение со станком недоступно"
+// @Router /api/v1/machines/{uuid}/browse [post]
+func (h *Handler) BrowseNodes(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.BrowseRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		h.BadRequest(c, err)
+		return
+	}
+
+	resp, eerr := h.usecase.BrowseNodes(id, req)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Browsed %d nodes of machine %s", resp.NodeCount, id), Object, resp)
+}
+
+// ReadNodes читает произвольные узлы станка
+// @Summary Чтение узлов станка
+// @Description Читает значения узлов по NodeID в строковой форме (i=, s=, g=, b=) или по пути просмотра из BrowseName (например 2:DeviceSet/4:Machine/4:Spindle) от startNodeID, по умолчанию от папки Objects. Для каждого узла возвращаются значение, качество (Good, Uncertain, Bad), StatusCode и метки времени. Узлы, которые не удалось найти или прочитать, возвращаются с ошибкой, не прерывая остальные.
+// @Tags Nodes
+// @Accept json
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Param input body models.NodeReadRequest true "Узлы для чтения"
+// @Success 200 {object} swagger.NodeReadResponse "Значения узлов"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Соединение не найдено"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Failure 503 {object} swagger.InternalServerError "Соединение со станком недоступно"
+// @Router /api/v1/machines/{uuid}/read [post]
+func (h *Handler) ReadNodes(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.NodeReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.BadRequest(c, err)
+		return
+	}
+
+	resp, eerr := h.usecase.ReadNodes(id, req)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Read %d nodes of machine %s", len(resp.Results), id), Object, resp)
+}
+
+// WriteNodes записывает значения в узлы станка
+// @Summary Запись в узлы станка
+// @Description Записывает значения в узлы станка, указанные по NodeID или пути просмотра. Запись выполняется только в узлы из списка разрешённых для станка (/machines/{uuid}/writable-nodes), значение приводится к типу данных узла. Каждая попытка записи, в том числе отклонённая, сохраняется в журнал аудита вместе с прежним значением, заголовком X-User и IP клиента.
+// @Tags Nodes
+// @Accept json
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Param X-User header string false "Инициатор записи для журнала аудита"
+// @Param input body models.NodeWriteRequest true "Узлы и новые значения"
+// @Success 200 {object} swagger.NodeWriteResponse "Результаты записи"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Станок не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Failure 503 {object} swagger.InternalServerError "Соединение со станком недоступно"
+// @Router /api/v1/machines/{uuid}/write [post]
+func (h *Handler) WriteNodes(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.NodeWriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.BadRequest(c, err)
+		return
+	}
+
+	actor := models.WriteActor{User: c.GetHeader("X-User"), ClientIP: c.ClientIP()}
+	resp, eerr := h.usecase.WriteNodes(id, req, actor)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Write processed for machine %s", id), Object, resp)
+}
+
+// GetWritableNodes возвращает список узлов станка, разрешённых для записи
+// @Summary Узлы, разрешённые для записи
+// @Description Возвращает список NodeID станка, в которые разрешена запись через /machines/{uuid}/write
+// @Tags Nodes
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Success 200 {object} swagger.WritableNodesResponse "Список узлов"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Станок не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/machines/{uuid}/writable-nodes [get]
+func (h *Handler) GetWritableNodes(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	resp, eerr := h.usecase.GetWritableNodes(id)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, "Successfully get writable nodes", Object, resp)
+}
+
+// SetWritableNodes заменяет список узлов станка, разрешённых для записи
+// @Summary Задать узлы, разрешённые для записи
+// @Description Заменяет список NodeID станка, в которые разрешена запись. Пустой список запрещает запись в любые узлы. Пути просмотра не принимаются: при записи по пути сравнивается найденный NodeID.
+// @Tags Nodes
+// @Accept json
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Param input body models.WritableNodesRequest true "Список NodeID"
+// @Success 200 {object} swagger.WritableNodesResponse "Список обновлён"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Станок не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/machines/{uuid}/writable-nodes [put]
+func (h *Handler) SetWritableNodes(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.WritableNodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.BadRequest(c, err)
+		return
+	}
+
+	resp, eerr := h.usecase.SetWritableNodes(id, req)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Writable nodes updated for machine %s", id), Object, resp)
+}
+
+// GetWriteAudit возвращает журнал аудита записи в узлы станка
+// @Summary Журнал аудита записи
+// @Description Возвращает последние попытки записи в узлы станка, новые первыми
+// @Tags Nodes
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Param limit query int false "Число записей, по умолчанию 100, не больше 1000"
+// @Success 200 {object} swagger.NodeWriteAuditResponse "Журнал аудита"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/machines/{uuid}/write/audit [get]
+func (h *Handler) GetWriteAudit(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			h.BadRequest(c, fmt.Errorf("incorrect limit: %s", s))
+			return
+		}
+		limit = n
+	}
+
+	resp, eerr := h.usecase.GetWriteAudit(id, limit)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, "Successfully get write audit", Object, resp)
+}
+
+// CallMethod вызывает метод OPC UA на станке
+// @Summary Вызов метода OPC UA
+// @Description Вызывает метод на объекте станка. Пара объект/метод должна входить в список разрешённых для станка (/machines/{uuid}/callable-methods). Входные аргументы передаются в порядке свойства InputArguments метода: их число и размерность проверяются, значения приводятся к типам аргументов. Выходные аргументы возвращаются с именами и типами из OutputArguments.
+// @Tags Nodes
+// @Accept json
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Param input body models.MethodCallRequest true "Объект, метод и входные аргументы"
+// @Success 200 {object} swagger.MethodCallResponse "Результат вызова"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса или аргументы"
+// @Failure 403 {object} swagger.IncorrectDataError "Метод не разрешён для станка"
+// @Failure 404 {object} swagger.NotFoundError "Станок не найден"
+// @Failure 422 {object} swagger.IncorrectDataError "Сервер вернул ошибку вызова метода"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Failure 503 {object} swagger.InternalServerError "Соединение со станком недоступно"
+// @Router /api/v1/machines/{uuid}/call [post]
+func (h *Handler) CallMethod(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.MethodCallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.BadRequest(c, err)
+		return
+	}
+
+	resp, eerr := h.usecase.CallMethod(id, req)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Method %s called on machine %s", resp.MethodID, id), Object, resp)
+}
+
+// GetCallableMethods возвращает список методов станка, которые разрешено вызывать
+// @Summary Методы, разрешённые для вызова
+// @Description Возвращает список NodeID методов станка, которые можно вызывать через /machines/{uuid}/call
+// @Tags Nodes
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Success 200 {object} swagger.CallableMethodsResponse "Список методов"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Станок не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/machines/{uuid}/callable-methods [get]
+func (h *Handler) GetCallableMethods(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	resp, eerr := h.usecase.GetCallableMethods(id)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, "Successfully get callable methods", Object, resp)
+}
+
+// SetCallableMethods заменяет список методов станка, которые разрешено вызывать
+// @Summary Задать методы, разрешённые для вызова
+// @Description Заменяет список пар NodeID объекта и метода, которые можно вызывать. Метод разрешён только на указанном объекте. Пустой список запрещает вызов любых методов.
+// @Tags Nodes
+// @Accept json
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Param input body models.CallableMethodsRequest true "Список пар объект/метод"
+// @Success 200 {object} swagger.CallableMethodsResponse "Список обновлён"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Станок не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/machines/{uuid}/callable-methods [put]
+func (h *Handler) SetCallableMethods(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CallableMethodsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.BadRequest(c, err)
+		return
+	}
+
+	resp, eerr := h.usecase.SetCallableMethods(id, req)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Callable methods updated for machine %s", id), Object, resp)
+}
+
+// machineUUID разбирает UUID станка из пути запроса. При ошибке ответ 400 уже отправлен
+func (h *Handler) machineUUID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("uuid"))
+	if err != nil {
+		h.BadRequest(c, fmt.Errorf("incorrect UUID: %s", c.Param("uuid")))
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -5,21 +5,22 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"opc_ua_service/internal/domain/models"
+	connection_models "opc_ua_service/internal/domain/models/connection_models"
 )
 
 // StartPollingByUUID запускает мониторинг для одного станка
 // @Summary Запустить мониторинг станка
-// @Description Запускает опрос OPC UA для конкретного станка по UUID
+// @Description Запускает получение данных OPC UA для конкретного станка по UUID. mode=polling (по умолчанию) периодически читает все узлы, mode=subscription создаёт подписку с MonitoredItems: сервер сам присылает изменения с заданными интервалом выборки, размером очереди и зоной нечувствительности (для всех узлов или отдельно для каждого).
 // @Tags Polling
 // @Produce json
-// @Param input body models.UUIDRequest true "UUID станка"
+// @Param input body models.StartPollingRequest true "UUID станка и способ получения данных"
 // @Success 200 {object} swagger.PollingResponse "Мониторинг запущен"
 // @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
 // @Failure 404 {object} swagger.NotFoundError "Данные не найдены"
 // @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
 // @Router /api/v1/polling/start [get]
 func (h *Handler) StartPollingByUUID(c *gin.Context) {
-	var req models.UUIDRequest
+	var req models.StartPollingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.BadRequest(c, err)
 		return
@@ -30,13 +31,17 @@ func (h *Handler) StartPollingByUUID(c *gin.Context) {
 		return
 	}
 
-	eerr := h.usecase.StartPollingMachine(id)
+	eerr := h.usecase.StartPollingMachine(req)
 	if eerr != nil {
-		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, false)
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
 		return
 	}
 
-	h.ResultResponse(c, fmt.Sprintf("Polling started for machine %s", id), Object, models.PollingResponse{Polled: true})
+	mode := req.Mode
+	if mode == "" {
+		mode = connection_models.AcquisitionPolling
+	}
+	h.ResultResponse(c, fmt.Sprintf("Polling started for machine %s", id), Object, models.PollingResponse{Polled: true, Mode: mode})
 }
 
 // StopPollingByUUID останавливает мониторинг для одного станка
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"opc_ua_service/internal/domain/models"
+	"strconv"
+)
+
+// maxProfileSize - максимальный размер загружаемого профиля
+const maxProfileSize = 1 << 20
+
+// ListProfiles возвращает профили реестра
+// @Summary Профили моделей станков
+// @Description Возвращает профили моделей станков из реестра с последней версией и числом версий. Профили из каталога MACHINE_PROFILES_DIR в список не входят.
+// @Tags Profiles
+// @Produce json
+// @Success 200 {object} swagger.ProfileListResponse "Профили реестра"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/profiles [get]
+func (h *Handler) ListProfiles(c *gin.Context) {
+	resp, eerr := h.usecase.ListProfiles()
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Successfully get %d machine profiles", len(resp.Profiles)), Object, resp)
+}
+
+// CreateProfileVersion загружает новую версию профиля
+// @Summary Загрузить версию профиля
+// @Description Проверяет профиль модели станка в формате JSON или YAML (Content-Type application/yaml) и сохраняет его следующей версией профиля с именем из поля name. Новые опросы и подписки станков моделей профиля используют новую версию без перезапуска сервиса; станки с закреплённой версией продолжают использовать её. Модель станка может описывать только один профиль реестра.
+// @Tags Profiles
+// @Accept json
+// @Accept application/yaml
+// @Produce json
+// @Param X-User header string false "Автор версии"
+// @Param comment query string false "Комментарий к версии"
+// @Param input body object true "Профиль модели станка"
+// @Success 200 {object} swagger.ProfileResponse "Сохранённая версия профиля"
+// @Failure 400 {object} swagger.IncorrectDataError "Профиль не прошёл проверку"
+// @Failure 409 {object} swagger.IncorrectDataError "Модель станка описывает другой профиль"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/profiles [post]
+func (h *Handler) CreateProfileVersion(c *gin.Context) {
+	req, ok := h.profileUpload(c)
+	if !ok {
+		return
+	}
+
+	resp, eerr := h.usecase.CreateProfileVersion(req)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Profile %s version %d saved", resp.Name, resp.Version), Object, resp)
+}
+
+// ValidateProfile проверяет профиль без сохранения
+// @Summary Проверить профиль
+// @Description Проверяет профиль модели станка в формате JSON или YAML без сохранения и возвращает все найденные ошибки
+// @Tags Profiles
+// @Accept json
+// @Accept application/yaml
+// @Produce json
+// @Param input body object true "Профиль модели станка"
+// @Success 200 {object} swagger.ProfileValidationResponse "Результат проверки"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Router /api/v1/profiles/validate [post]
+func (h *Handler) ValidateProfile(c *gin.Context) {
+	req, ok := h.profileUpload(c)
+	if !ok {
+		return
+	}
+
+	resp := h.usecase.ValidateProfile(req)
+	message := "Profile is valid"
+	if !resp.Valid {
+		message = "Profile is invalid"
+	}
+	h.ResultResponse(c, message, Object, resp)
+}
+
+// GetProfile возвращает последнюю версию профиля
+// @Summary Профиль модели станка
+// @Description Возвращает последнюю версию профиля с определением, список всех версий и станки, за которыми закреплена последняя версия
+// @Tags Profiles
+// @Produce json
+// @Param name path string true "Имя профиля"
+// @Success 200 {object} swagger.ProfileResponse "Профиль"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Профиль не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/profiles/{name} [get]
+func (h *Handler) GetProfile(c *gin.Context) {
+	name, ok := h.profileName(c)
+	if !ok {
+		return
+	}
+
+	resp, eerr := h.usecase.GetProfile(name)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Successfully get profile %s", name), Object, resp)
+}
+
+// GetProfileVersion возвращает версию профиля
+// @Summary Версия профиля
+// @Description Возвращает версию профиля с определением и станки, за которыми она закреплена
+// @Tags Profiles
+// @Produce json
+// @Param name path string true "Имя профиля"
+// @Param version path int true "Версия профиля"
+// @Success 200 {object} swagger.ProfileResponse "Версия профиля"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Версия не найдена"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/profiles/{name}/versions/{version} [get]
+func (h *Handler) GetProfileVersion(c *gin.Context) {
+	name, version, ok := h.profileVersion(c)
+	if !ok {
+		return
+	}
+
+	resp, eerr := h.usecase.GetProfileVersion(name, version)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Successfully get profile %s version %d", name, version), Object, resp)
+}
+
+// DeleteProfile удаляет все версии профиля
+// @Summary Удалить профиль
+// @Description Удаляет все версии профиля. Профиль, версия которого закреплена за станком, не удаляется. Уже запущенные опросы продолжают работать с прежней версией
+// @Tags Profiles
+// @Produce json
+// @Param name path string true "Имя профиля"
+// @Success 200 {object} swagger.ProfileDeleteResponse "Профиль удалён"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Профиль не найден"
+// @Failure 409 {object} swagger.IncorrectDataError "Версия профиля закреплена за станками"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/profiles/{name} [delete]
+func (h *Handler) DeleteProfile(c *gin.Context) {
+	name, ok := h.profileName(c)
+	if !ok {
+		return
+	}
+
+	if eerr := h.usecase.DeleteProfile(name, 0); eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Profile %s deleted", name), Empty, nil)
+}
+
+// DeleteProfileVersion удаляет версию профиля
+// @Summary Удалить версию профиля
+// @Description Удаляет версию профиля. После удаления последней версии станки используют предыдущую. Закреплённая за станком версия не удаляется
+// @Tags Profiles
+// @Produce json
+// @Param name path string true "Имя профиля"
+// @Param version path int true "Версия профиля"
+// @Success 200 {object} swagger.ProfileDeleteResponse "Версия удалена"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Версия не найдена"
+// @Failure 409 {object} swagger.IncorrectDataError "Версия профиля закреплена за станками"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/profiles/{name}/versions/{version} [delete]
+func (h *Handler) DeleteProfileVersion(c *gin.Context) {
+	name, version, ok := h.profileVersion(c)
+	if !ok {
+		return
+	}
+
+	if eerr := h.usecase.DeleteProfile(name, version); eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Profile %s version %d deleted", name, version), Empty, nil)
+}
+
+// GetMachineProfile возвращает модель, по которой собираются данные станка
+// @Summary Профиль станка
+// @Description Возвращает источник модели станка: закреплённая версия профиля (pinned), последняя версия профиля из реестра (registry), профиль из каталога (file), встроенная модель (builtin) или none
+// @Tags Profiles
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Success 200 {object} swagger.MachineProfileResponse "Профиль станка"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Станок не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/machines/{uuid}/profile [get]
+func (h *Handler) GetMachineProfile(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	resp, eerr := h.usecase.GetMachineProfile(id)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Successfully get profile of machine %s", id), Object, resp)
+}
+
+// PinMachineProfile закрепляет за станком версию профиля
+// @Summary Закрепить версию профиля
+// @Description Закрепляет за станком версию профиля из реестра: новые версии профиля на станок не влияют, пока закрепление не снято. Профиль должен описывать производителя и модель станка. Действует со следующего запуска опроса или подписки
+// @Tags Profiles
+// @Accept json
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Param input body models.ProfilePinRequest true "Профиль и версия"
+// @Success 200 {object} swagger.MachineProfileResponse "Профиль станка"
+// @Failure 400 {object} swagger.IncorrectDataError "Профиль не описывает модель станка"
+// @Failure 404 {object} swagger.NotFoundError "Станок или версия профиля не найдены"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/machines/{uuid}/profile [put]
+func (h *Handler) PinMachineProfile(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ProfilePinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.BadRequest(c, err)
+		return
+	}
+
+	resp, eerr := h.usecase.PinMachineProfile(id, req)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Profile %s version %d pinned to machine %s", req.Name, req.Version, id), Object, resp)
+}
+
+// UnpinMachineProfile снимает закрепление версии профиля
+// @Summary Снять закрепление профиля
+// @Description Снимает закрепление версии профиля: станок снова использует последнюю версию профиля своей модели. Действует со следующего запуска опроса или подписки
+// @Tags Profiles
+// @Produce json
+// @Param uuid path string true "UUID станка"
+// @Success 200 {object} swagger.MachineProfileResponse "Профиль станка"
+// @Failure 400 {object} swagger.IncorrectFormatError "Неверный формат запроса"
+// @Failure 404 {object} swagger.NotFoundError "Станок не найден"
+// @Failure 500 {object} swagger.InternalServerError "Внутренняя ошибка сервера"
+// @Router /api/v1/machines/{uuid}/profile [delete]
+func (h *Handler) UnpinMachineProfile(c *gin.Context) {
+	id, ok := h.machineUUID(c)
+	if !ok {
+		return
+	}
+
+	resp, eerr := h.usecase.UnpinMachineProfile(id)
+	if eerr != nil {
+		h.ErrorResponse(c, eerr, eerr.Code, eerr.Message, eerr.IsUserFacing)
+		return
+	}
+
+	h.ResultResponse(c, fmt.Sprintf("Profile unpinned from machine %s", id), Object, resp)
+}
+
+// profileUpload читает загружаемый профиль из тела запроса
+func (h *Handler) profileUpload(c *gin.Context) (models.ProfileUploadRequest, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxProfileSize))
+	if err != nil {
+		h.BadRequest(c, fmt.Errorf("read profile: %w", err))
+		return models.ProfileUploadRequest{}, false
+	}
+	if len(data) == 0 {
+		h.BadRequest(c, fmt.Errorf("profile is empty"))
+		return models.ProfileUploadRequest{}, false
+	}
+	return models.ProfileUploadRequest{
+		Definition:  data,
+		ContentType: c.ContentType(),
+		Comment:     c.Query("comment"),
+		CreatedBy:   c.GetHeader("X-User"),
+	}, true
+}
+
+// profileName разбирает имя профиля из пути запроса
+func (h *Handler) profileName(c *gin.Context) (string, bool) {
+	name := c.Param("name")
+	if err := models.ValidateProfileName(name); err != nil {
+		h.BadRequest(c, err)
+		return "", false
+	}
+	return name, true
+}
+
+// profileVersion разбирает имя и версию профиля из пути запроса
+func (h *Handler) profileVersion(c *gin.Context) (string, int, bool) {
+	name, ok := h.profileName(c)
+	if !ok {
+		return "", 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		h.BadRequest(c, fmt.Errorf("incorrect profile version: %s", c.Param("version")))
+		return "", 0, false
+	}
+	return name, version, true
+}
@@ -9,6 +9,8 @@ import (
 	"opc_ua_service/internal/adapters/repositories/anonymous_connection"
 	"opc_ua_service/internal/adapters/repositories/certificate_connection"
 	"opc_ua_service/internal/adapters/repositories/cnc_machine"
+	"opc_ua_service/internal/adapters/repositories/machine_profile"
+	"opc_ua_service/internal/adapters/repositories/node_write_audit"
 	"opc_ua_service/internal/adapters/repositories/password_connection"
 	"opc_ua_service/internal/config"
 	"opc_ua_service/internal/domain/entities"
@@ -25,6 +27,8 @@ type Repository struct {
 	interfaces.CertificateConnectionRepository
 	interfaces.PasswordConnectionRepository
 	interfaces.AnonymousConnectionRepository
+	interfaces.NodeWriteAuditRepository
+	interfaces.MachineProfileRepository
 }
 
 func NewRepository(cfg *config.Config, appLogger *logging.Logger) (interfaces.Repository, error) {
@@ -50,6 +54,8 @@ func NewRepository(cfg *config.Config, appLogger *logging.Logger) (interfaces.Re
 		CertificateConnectionRepository: certificate_connection.NewCertificateConnectionRepository(appDb),
 		PasswordConnectionRepository:    password_connection.NewPasswordConnectionRepository(appDb),
 		AnonymousConnectionRepository:   anonymous_connection.NewAnonymousConnectionRepository(appDb),
+		NodeWriteAuditRepository:        node_write_audit.NewNodeWriteAuditRepository(appDb),
+		MachineProfileRepository:        machine_profile.NewMachineProfileRepository(appDb),
 	}, nil
 }
 
@@ -98,27 +104,16 @@ func connectToAppDatabase(cfg *config.Config) (*gorm.DB, error) {
 	})
 }
 
-// autoMigrate выполняет миграцию и очистку зависимых таблиц
+// autoMigrate выполняет миграцию таблиц.
+// Таблицы не пересоздаются, чтобы сохранённые станки и их UUID переживали перезапуск сервиса
 func autoMigrate(db *gorm.DB) error {
-	// Удаление таблиц в порядке зависимостей
-	tablesToDrop := []string{
-		"certificate_connection",
-		"anonymous_connection",
-		"password_connection",
-		"cnc_machine",
-	}
-
-	for _, table := range tablesToDrop {
-		if err := db.Migrator().DropTable(table); err != nil {
-			return fmt.Errorf("failed to drop table %s: %w", table, err)
-		}
-	}
-
 	models := []interface{}{
 		&entities.CncMachine{},
 		&entities.CertificateConnection{},
 		&entities.PasswordConnection{},
 		&entities.AnonymousConnection{},
+		&entities.NodeWriteAudit{},
+		&entities.MachineProfile{},
 	}
 
 	if err := db.AutoMigrate(models...); err != nil {
@@ -0,0 +1,92 @@
+package machine_profile
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"opc_ua_service/internal/domain/entities"
+	"opc_ua_service/pkg/errors"
+)
+
+// CreateMachineProfileVersion сохраняет профиль следующей версией: 1 для нового профиля, иначе последняя + 1
+func (r *MachineProfileRepositoryImpl) CreateMachineProfileVersion(profile entities.MachineProfile) (entities.MachineProfile, error) {
+	op := "repo.MachineProfile.CreateMachineProfileVersion"
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Блокируем версии профиля, чтобы параллельная загрузка не получила тот же номер
+		var versions []int
+		if err := tx.Model(&entities.MachineProfile{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("name = ?", profile.Name).Pluck("version", &versions).Error; err != nil {
+			return err
+		}
+		profile.Version = 1
+		for _, version := range versions {
+			profile.Version = max(profile.Version, version+1)
+		}
+		return tx.Create(&profile).Error
+	})
+	if err != nil {
+		return entities.MachineProfile{}, errors.NewDBError(op, err)
+	}
+
+	return profile, nil
+}
+
+// GetMachineProfileVersion возвращает версию профиля
+func (r *MachineProfileRepositoryImpl) GetMachineProfileVersion(name string, version int) (entities.MachineProfile, error) {
+	op := "repo.MachineProfile.GetMachineProfileVersion"
+
+	var profile entities.MachineProfile
+	err := r.db.First(&profile, "name = ? AND version = ?", name, version).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.MachineProfile{}, errors.NewDBError(op, fmt.Errorf("%s: %w", op, errors.ErrNotFound))
+		}
+		return entities.MachineProfile{}, errors.NewDBError(op, err)
+	}
+
+	return profile, nil
+}
+
+// GetMachineProfileVersions возвращает все версии профиля, последняя первой
+func (r *MachineProfileRepositoryImpl) GetMachineProfileVersions(name string) ([]entities.MachineProfile, error) {
+	op := "repo.MachineProfile.GetMachineProfileVersions"
+
+	var versions []entities.MachineProfile
+	if err := r.db.Where("name = ?", name).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, errors.NewDBError(op, err)
+	}
+
+	return versions, nil
+}
+
+// GetAllMachineProfiles возвращает все версии всех профилей
+func (r *MachineProfileRepositoryImpl) GetAllMachineProfiles() ([]entities.MachineProfile, error) {
+	op := "repo.MachineProfile.GetAllMachineProfiles"
+
+	var list []entities.MachineProfile
+	if err := r.db.Order("name, version").Find(&list).Error; err != nil {
+		return nil, errors.NewDBError(op, err)
+	}
+
+	return list, nil
+}
+
+// DeleteMachineProfile удаляет версию профиля, version 0 - все версии
+func (r *MachineProfileRepositoryImpl) DeleteMachineProfile(name string, version int) error {
+	op := "repo.MachineProfile.DeleteMachineProfile"
+
+	query := r.db.Where("name = ?", name)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	result := query.Delete(&entities.MachineProfile{})
+	if result.Error != nil {
+		return errors.NewDBError(op, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewDBError(op, fmt.Errorf("%s: %w", op, errors.ErrNotFound))
+	}
+
+	return nil
+}
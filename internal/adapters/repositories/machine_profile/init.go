@@ -0,0 +1,14 @@
+package machine_profile
+
+import (
+	"gorm.io/gorm"
+	"opc_ua_service/internal/interfaces"
+)
+
+type MachineProfileRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewMachineProfileRepository(db *gorm.DB) interfaces.MachineProfileRepository {
+	return &MachineProfileRepositoryImpl{db: db}
+}
@@ -0,0 +1,30 @@
+package node_write_audit
+
+import (
+	"opc_ua_service/internal/domain/entities"
+	"opc_ua_service/pkg/errors"
+)
+
+// CreateNodeWriteAudit сохраняет запись журнала аудита записи в узел
+func (r *NodeWriteAuditRepositoryImpl) CreateNodeWriteAudit(record entities.NodeWriteAudit) (uint, error) {
+	op := "repo.NodeWriteAudit.CreateNodeWriteAudit"
+
+	if err := r.db.Create(&record).Error; err != nil {
+		return 0, errors.NewDBError(op, err)
+	}
+
+	return record.ID, nil
+}
+
+// GetNodeWriteAudits возвращает последние limit записей журнала аудита станка, новые первыми
+func (r *NodeWriteAuditRepositoryImpl) GetNodeWriteAudits(machineUUID string, limit int) ([]entities.NodeWriteAudit, error) {
+	op := "repo.NodeWriteAudit.GetNodeWriteAudits"
+
+	var records []entities.NodeWriteAudit
+	err := r.db.Where("machine_uuid = ?", machineUUID).Order("created_at DESC, id DESC").Limit(limit).Find(&records).Error
+	if err != nil {
+		return nil, errors.NewDBError(op, err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,14 @@
+package node_write_audit
+
+import (
+	"gorm.io/gorm"
+	"opc_ua_service/internal/interfaces"
+)
+
+type NodeWriteAuditRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewNodeWriteAuditRepository(db *gorm.DB) interfaces.NodeWriteAuditRepository {
+	return &NodeWriteAuditRepositoryImpl{db: db}
+}
@@ -11,11 +11,14 @@ import (
 	"opc_ua_service/internal/adapters/producers"
 	"opc_ua_service/internal/adapters/repositories"
 	"opc_ua_service/internal/config"
+	"opc_ua_service/internal/domain/models"
 	"opc_ua_service/internal/interfaces"
 	"opc_ua_service/internal/middleware/logging"
 	"opc_ua_service/internal/middleware/swagger"
 	"opc_ua_service/internal/services/opc_service"
 	"opc_ua_service/internal/usecases"
+	"opc_ua_service/pkg/machine_models"
+	"time"
 )
 
 func New() *fx.App {
@@ -29,7 +32,10 @@ func New() *fx.App {
 		ServiceModule,
 		UsecaseModule,
 		HttpServerModule,
+		fx.Invoke(InvokeLoadMachineProfiles),
+		fx.Invoke(InvokeLoadStoredProfiles),
 		fx.Invoke(InvokeRestoreConnections),
+		fx.Invoke(InvokeCertificateExpiryMonitor),
 	)
 }
 
@@ -135,6 +141,37 @@ func intToUint(c int) uint {
 	return uint(c)
 }
 
+// InvokeLoadMachineProfiles загружает профили моделей станков до восстановления подключений.
+// Файл с ошибкой пропускается, остальные профили регистрируются.
+func InvokeLoadMachineProfiles(cfg *config.Config, logger *logging.Logger) {
+	profiles, err := machine_models.LoadMachineProfiles(cfg.Profiles.Dir)
+	if err != nil {
+		logger.Error("Some machine profiles were not loaded", "dir", cfg.Profiles.Dir, "error", err)
+	}
+	for _, profile := range profiles {
+		for _, model := range profile.Models {
+			if interfaces.IsBuiltinMachineModel(profile.Manufacturer, model) {
+				logger.Warn("Machine profile overrides built-in model", "manufacturer", profile.Manufacturer, "model", model, "file", profile.Source)
+			}
+		}
+		machine_models.RegisterMachineProfile(profile)
+		logger.Info("Machine profile loaded", "manufacturer", profile.Manufacturer, "models", profile.Models, "nodes", len(profile.Nodes), "file", profile.Source)
+	}
+	if len(profiles) == 0 {
+		logger.Info("No machine profiles found", "dir", cfg.Profiles.Dir)
+	}
+}
+
+// InvokeLoadStoredProfiles загружает версии профилей из реестра в БД и закрепления версий за станками.
+// Профили реестра имеют приоритет над профилями каталога.
+func InvokeLoadStoredProfiles(uc interfaces.Usecases, logger *logging.Logger) {
+	loaded, eerr := uc.LoadStoredProfiles()
+	if eerr != nil {
+		logger.Error("Some stored machine profiles were not loaded", "error", eerr)
+	}
+	logger.Info("Stored machine profiles loaded", "versions", loaded)
+}
+
 // InvokeRestoreConnections восстанавливает подключения и опросы при старте приложения.
 func InvokeRestoreConnections(lc fx.Lifecycle, uc interfaces.Usecases, dbRepo interfaces.Repository, logger *logging.Logger) {
 	lc.Append(fx.Hook{
@@ -155,17 +192,81 @@ func InvokeRestoreConnections(lc fx.Lifecycle, uc interfaces.Usecases, dbRepo in
 					logger.Info("Attempting to restore connection", "UUID", machine.UUID, "model", machine.Model, "endpoint", machine.EndpointURL)
 
 					connInfo, _ := uc.RestoreConnection(machine)
+					if connInfo == nil {
+						logger.Warn("Connection could not be restored", "UUID", machine.UUID)
+						continue
+					}
+
+					connInfo.Mu.RLock()
+					state := connInfo.StateSnapshot()
+					connInfo.Mu.RUnlock()
 
-					if connInfo != nil && connInfo.IsHealthy {
+					switch state.State {
+					case models.StateConnected:
 						logger.Info("Connection restored successfully in pool", "UUID", machine.UUID)
-					} else if connInfo != nil && !connInfo.IsHealthy {
-						logger.Warn("Connection restored in pool but is unhealthy. Will retry in background.", "UUID", machine.UUID)
-					} else {
-						logger.Warn("Connection could not be restored", "UUID", machine.UUID)
+					case models.StateAuthFailed:
+						logger.Error("Connection restored in pool but server rejected credentials. Will retry in background.", "UUID", machine.UUID, "error", state.LastError)
+					default:
+						logger.Warn("Connection restored in pool but is unhealthy. Will retry in background.", "UUID", machine.UUID, "state", state.State, "error", state.LastError)
+					}
+				}
+			}()
+			return nil
+		},
+	})
+}
+
+// InvokeCertificateExpiryMonitor периодически проверяет сроки действия клиентских сертификатов станков
+// и пишет в лог предупреждения об истекающих и истёкших сертификатах.
+func InvokeCertificateExpiryMonitor(lc fx.Lifecycle, uc interfaces.Usecases, cfg *config.Config, logger *logging.Logger) {
+	monitorLogger := logger.WithPrefix("CERT_EXPIRY")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	check := func() {
+		resp, eerr := uc.CheckCertificateExpiry()
+		if eerr != nil {
+			monitorLogger.Error("Certificate expiry check failed", "error", eerr)
+			return
+		}
+		for _, cert := range resp.Certificates {
+			fields := []interface{}{"UUID", cert.UUID, "model", cert.Model, "source", cert.Source, "daysToExpiry", cert.DaysToExpiry, "notAfter", cert.NotAfter}
+			switch cert.Status {
+			case models.ExpiryExpired:
+				monitorLogger.Error("Client certificate expired", fields...)
+			case models.ExpiryExpiring:
+				monitorLogger.Warn("Client certificate expires soon", fields...)
+			case models.ExpiryInvalid:
+				monitorLogger.Error("Client certificate cannot be parsed", "UUID", cert.UUID, "error", cert.Error)
+			default:
+				monitorLogger.Info("Client certificate is valid", fields...)
+			}
+		}
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			interval := cfg.PKI.ExpiryCheckInterval
+			if interval <= 0 {
+				interval = 24 * time.Hour
+			}
+			go func() {
+				check()
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						check()
 					}
 				}
 			}()
 			return nil
 		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
 	})
 }
@@ -17,6 +17,7 @@ type ServerConfig struct {
 type KafkaConfig struct {
 	KafkaBrokers []string `json:"kafka_brokers"`
 	KafkaTopic   string   `json:"kafka_topic"`
+	AlarmTopic   string   `json:"alarm_topic"` // Топик событий появления и снятия тревог станков
 }
 
 type Config struct {
@@ -26,6 +27,8 @@ type Config struct {
 	Logging    LoggerConfig
 	Services   Services
 	Server     ServerConfig
+	PKI        PKIConfig
+	Profiles   ProfilesConfig
 }
 
 func DefaultServerConfig() ServerConfig {
@@ -64,6 +67,22 @@ type LoggerConfig struct {
 	SavingDays int
 }
 
+// PKIConfig - расположение хранилища сертификатов OPC UA (own/, trusted/, rejected/, issuers/)
+// и параметры сертификата экземпляра клиентского приложения
+type PKIConfig struct {
+	Dir                     string
+	ApplicationName         string
+	ApplicationURI          string // Пусто - urn:<hostname>:<ApplicationName>
+	CertificateValidityDays int
+	ExpiryCheckInterval     time.Duration // Период проверки сроков действия сертификатов
+	ExpiryWarnDays          int           // За сколько дней до истечения предупреждать
+}
+
+// ProfilesConfig - каталог декларативных профилей моделей станков (YAML или JSON)
+type ProfilesConfig struct {
+	Dir string
+}
+
 type DatabaseConfig struct {
 	Host     string
 	Port     string
@@ -95,6 +114,7 @@ func LoadConfig() (*Config, error) {
 					getEnv("KAFKA_BROKER", "localhost:9092"),
 				},
 				KafkaTopic: getEnv("KAFKA_TOPIC", "opc-data"),
+				AlarmTopic: getEnv("KAFKA_ALARM_TOPIC", "opc-alarms"),
 			},
 			GinMode: getEnv("GIN_MODE", "release"),
 		},
@@ -117,6 +137,17 @@ func LoadConfig() (*Config, error) {
 			Level:      getEnv("LOGGER_LOG_LEVEL", "DEBUG"),
 			SavingDays: getEnvAsInt("LOGGER_SAVING_DAYS", 5),
 		},
+		PKI: PKIConfig{
+			Dir:                     getEnv("PKI_DIR", "./pki"),
+			ApplicationName:         getEnv("OPC_APPLICATION_NAME", "OpcUaService"),
+			ApplicationURI:          getEnv("OPC_APPLICATION_URI", ""),
+			CertificateValidityDays: getEnvAsInt("OPC_CERT_VALIDITY_DAYS", 730),
+			ExpiryCheckInterval:     time.Duration(getEnvAsInt("CERT_EXPIRY_CHECK_HOURS", 24)) * time.Hour,
+			ExpiryWarnDays:          getEnvAsInt("CERT_EXPIRY_WARN_DAYS", 30),
+		},
+		Profiles: ProfilesConfig{
+			Dir: getEnv("MACHINE_PROFILES_DIR", "./profiles"),
+		},
 		Services: Services{
 			MobileApp: Service{
 				Host: getEnv("API_URL", "http://localhost:8080"),
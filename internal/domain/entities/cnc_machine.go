@@ -17,6 +17,25 @@ type CncMachine struct {
 	Interval       int                                    `json:"interval"`                        // Интервал опроса в сек
 	ConnectionType connection_models.ConnectionTypeEnum   `gorm:"not null" json:"connection_type"` // "certificate", "anonymous", "password"
 
+	TrustOnFirstUse bool `gorm:"not null;default:false" json:"trust_on_first_use"` // Доверять сертификату сервера при первом подключении
+
+	AcquisitionMode      connection_models.AcquisitionModeEnum   `gorm:"not null;default:polling" json:"acquisition_mode"` // polling / subscription
+	SubscriptionSettings *connection_models.SubscriptionSettings `gorm:"type:jsonb" json:"subscription_settings"`          // Параметры подписки для режима subscription
+
+	WritableNodes   connection_models.NodeList   `gorm:"type:jsonb" json:"writable_nodes"`   // NodeID, в которые разрешена запись через REST API
+	CallableMethods connection_models.MethodList `gorm:"type:jsonb" json:"callable_methods"` // Пары объект/метод, которые разрешено вызывать через REST API
+
+	ProfileName    string `gorm:"not null;default:''" json:"profile_name"`   // Закреплённый профиль модели, пусто - последняя версия
+	ProfileVersion int    `gorm:"not null;default:0" json:"profile_version"` // Закреплённая версия профиля
+
+	// Модель станка, определённая при подключении по BuildInfo, пространствам имён и Identification сервера
+	DetectedManufacturer  string     `gorm:"not null;default:''" json:"detected_manufacturer"`
+	DetectedModel         string     `gorm:"not null;default:''" json:"detected_model"`
+	DetectionSource       string     `gorm:"not null;default:''" json:"detection_source"` // profile / namespace / machine_tool
+	ServerProductName     string     `gorm:"not null;default:''" json:"server_product_name"`
+	ServerSoftwareVersion string     `gorm:"not null;default:''" json:"server_software_version"`
+	DetectedAt            *time.Time `json:"detected_at"`
+
 	CertificateConnectionID *uint
 	AnonymousConnectionID   *uint
 	PasswordConnectionID    *uint
@@ -20,4 +20,6 @@ type PasswordConnection struct {
 	Password string `gorm:"column:password;type:varchar(255);not null"`
 	Policy   string `gorm:"column:policy;type:varchar(255)"`
 	Mode     string `gorm:"column:mode;type:varchar(255)"`
+	// AllowInsecure - пароль разрешено передавать по каналу без шифрования
+	AllowInsecure bool `gorm:"column:allow_insecure;not null;default:false"`
 }
@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+// MachineProfile - версия профиля модели станка. Версии неизменяемы: изменённый профиль сохраняется новой версией
+type MachineProfile struct {
+	ID           uint   `gorm:"primaryKey"`
+	Name         string `gorm:"uniqueIndex:idx_machine_profile_version;not null"`
+	Version      int    `gorm:"uniqueIndex:idx_machine_profile_version;not null"`
+	Manufacturer string `gorm:"index;not null"`
+	Definition   string `gorm:"type:jsonb;not null"` // Профиль в JSON
+	Comment      string
+	CreatedBy    string // Значение заголовка X-User
+	CreatedAt    time.Time
+}
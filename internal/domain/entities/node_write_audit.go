@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// NodeWriteAudit - запись журнала аудита записи значения в узел станка через REST API
+type NodeWriteAudit struct {
+	ID            uint   `gorm:"primaryKey"`
+	MachineUUID   string `gorm:"index;not null"`
+	NodeID        string `gorm:"not null"`
+	BrowsePath    string // Путь просмотра, если узел был указан через него
+	PreviousValue string // Значение до записи в JSON
+	Value         string `gorm:"not null"` // Записываемое значение в JSON
+	Allowed       bool   `gorm:"not null"` // Узел входил в список разрешённых для записи
+	Written       bool   `gorm:"not null"`
+	StatusCode    string // StatusCode ответа сервера на запись
+	Error         string
+	User          string // Значение заголовка X-User
+	ClientIP      string
+	CreatedAt     time.Time `gorm:"index"`
+}
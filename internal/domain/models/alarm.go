@@ -0,0 +1,20 @@
+package models
+
+import "github.com/google/uuid"
+
+// AlarmEventTypeEnum - изменение состояния тревоги, отправляемое в топик тревог
+type AlarmEventTypeEnum string
+
+const (
+	AlarmRaised  AlarmEventTypeEnum = "raised"
+	AlarmCleared AlarmEventTypeEnum = "cleared"
+)
+
+// AlarmEvent - сообщение о появлении или снятии тревоги станка
+type AlarmEvent struct {
+	MachineUUID uuid.UUID          `json:"machine_uuid"`
+	MachineId   string             `json:"machine_id,omitempty"` // серийный номер, если данные станка уже читались
+	Event       AlarmEventTypeEnum `json:"event"`
+	Timestamp   int64              `json:"timestamp"` // время события, Unix мс
+	Alarm       AlarmsResponse     `json:"alarm"`
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+)
+
+// Ограничения обхода адресного пространства
+const (
+	DefaultBrowseDepth    = 1
+	MaxBrowseDepth        = 10
+	DefaultBrowseMaxNodes = 1000
+	MaxBrowseMaxNodes     = 10000
+)
+
+// BrowseDirectionEnum - направление ссылок при обходе
+type BrowseDirectionEnum string
+
+const (
+	BrowseForward BrowseDirectionEnum = "forward"
+	BrowseInverse BrowseDirectionEnum = "inverse"
+	BrowseBoth    BrowseDirectionEnum = "both"
+)
+
+// ToUA преобразует направление в ua.BrowseDirection
+func (d BrowseDirectionEnum) ToUA() (ua.BrowseDirection, error) {
+	switch d {
+	case "", BrowseForward:
+		return ua.BrowseDirectionForward, nil
+	case BrowseInverse:
+		return ua.BrowseDirectionInverse, nil
+	case BrowseBoth:
+		return ua.BrowseDirectionBoth, nil
+	default:
+		return ua.BrowseDirectionInvalid, fmt.Errorf("invalid browse direction: %s", d)
+	}
+}
+
+// referenceTypesByName - типы ссылок, которые можно указать по имени
+var referenceTypesByName = map[string]ua.NodeID{
+	"References":                ua.ReferenceTypeIDReferences,
+	"HierarchicalReferences":    ua.ReferenceTypeIDHierarchicalReferences,
+	"NonHierarchicalReferences": ua.ReferenceTypeIDNonHierarchicalReferences,
+	"HasChild":                  ua.ReferenceTypeIDHasChild,
+	"Aggregates":                ua.ReferenceTypeIDAggregates,
+	"Organizes":                 ua.ReferenceTypeIDOrganizes,
+	"HasComponent":              ua.ReferenceTypeIDHasComponent,
+	"HasProperty":               ua.ReferenceTypeIDHasProperty,
+	"HasSubtype":                ua.ReferenceTypeIDHasSubtype,
+	"HasTypeDefinition":         ua.ReferenceTypeIDHasTypeDefinition,
+	"HasNotifier":               ua.ReferenceTypeIDHasNotifier,
+	"HasEventSource":            ua.ReferenceTypeIDHasEventSource,
+}
+
+// BrowseRequest - запрос обхода адресного пространства станка
+type BrowseRequest struct {
+	// NodeID - начальный узел, по умолчанию папка Objects (i=85)
+	NodeID string `json:"nodeID,omitempty" example:"ns=1;i=50000"`
+	// ReferenceType - тип ссылок по имени (HierarchicalReferences, Organizes, HasComponent, ...) или NodeID, по умолчанию HierarchicalReferences
+	ReferenceType string `json:"referenceType,omitempty" example:"HierarchicalReferences"`
+	// IncludeSubtypes - учитывать подтипы ReferenceType, по умолчанию true
+	IncludeSubtypes *bool `json:"includeSubtypes,omitempty" example:"true"`
+	// Direction - forward (по умолчанию), inverse или both
+	Direction BrowseDirectionEnum `json:"direction,omitempty" example:"forward"`
+	// Depth - глубина обхода, по умолчанию 1 (только дочерние узлы), не больше 10
+	Depth int `json:"depth,omitempty" example:"2"`
+	// MaxNodes - максимальное число узлов в ответе, по умолчанию 1000, не больше 10000
+	MaxNodes int `json:"maxNodes,omitempty" example:"1000"`
+	// ReadValues - читать DataType и текущее значение переменных, по умолчанию true
+	ReadValues *bool `json:"readValues,omitempty" example:"true"`
+}
+
+// Normalize проверяет запрос и подставляет значения по умолчанию
+func (r *BrowseRequest) Normalize() error {
+	if _, err := r.StartNodeID(); err != nil {
+		return err
+	}
+	if _, err := r.ReferenceTypeID(); err != nil {
+		return err
+	}
+	if _, err := r.Direction.ToUA(); err != nil {
+		return err
+	}
+
+	if r.Depth == 0 {
+		r.Depth = DefaultBrowseDepth
+	}
+	if r.Depth < 0 || r.Depth > MaxBrowseDepth {
+		return fmt.Errorf("depth must be in range 1..%d", MaxBrowseDepth)
+	}
+	if r.MaxNodes == 0 {
+		r.MaxNodes = DefaultBrowseMaxNodes
+	}
+	if r.MaxNodes < 0 || r.MaxNodes > MaxBrowseMaxNodes {
+		return fmt.Errorf("maxNodes must be in range 1..%d", MaxBrowseMaxNodes)
+	}
+	if r.IncludeSubtypes == nil {
+		includeSubtypes := true
+		r.IncludeSubtypes = &includeSubtypes
+	}
+	if r.ReadValues == nil {
+		readValues := true
+		r.ReadValues = &readValues
+	}
+	return nil
+}
+
+// StartNodeID возвращает начальный узел обхода
+func (r *BrowseRequest) StartNodeID() (ua.NodeID, error) {
+	if r.NodeID == "" {
+		return ua.ObjectIDObjectsFolder, nil
+	}
+	nodeID := ua.ParseNodeID(r.NodeID)
+	if nodeID == nil {
+		return nil, fmt.Errorf("invalid nodeID: %s", r.NodeID)
+	}
+	return nodeID, nil
+}
+
+// ReferenceTypeID возвращает NodeID типа ссылок для обхода
+func (r *BrowseRequest) ReferenceTypeID() (ua.NodeID, error) {
+	if r.ReferenceType == "" {
+		return ua.ReferenceTypeIDHierarchicalReferences, nil
+	}
+	if nodeID, ok := referenceTypesByName[r.ReferenceType]; ok {
+		return nodeID, nil
+	}
+	nodeID := ua.ParseNodeID(r.ReferenceType)
+	if nodeID == nil {
+		return nil, fmt.Errorf("invalid referenceType: %s", r.ReferenceType)
+	}
+	return nodeID, nil
+}
+
+// BrowseNode - узел адресного пространства
+type BrowseNode struct {
+	NodeID        string        `json:"nodeID" example:"ns=1;i=100039"`
+	BrowseName    string        `json:"browseName" example:"1:CurrentToolName"`
+	DisplayName   string        `json:"displayName" example:"CurrentToolName"`
+	NodeClass     string        `json:"nodeClass" example:"Variable"`
+	ReferenceType string        `json:"referenceType,omitempty" example:"i=47"`
+	DataType      string        `json:"dataType,omitempty" example:"String"`
+	Value         interface{}   `json:"value,omitempty"`
+	StatusCode    string        `json:"statusCode,omitempty" example:"Good"`
+	Children      []*BrowseNode `json:"children,omitempty"`
+}
+
+// BrowseResponse - результат обхода адресного пространства
+type BrowseResponse struct {
+	StartNodeID string `json:"startNodeID" example:"i=85"`
+	Depth       int    `json:"depth" example:"1"`
+	NodeCount   int    `json:"nodeCount" example:"12"`
+	// Truncated - обход остановлен по лимиту MaxNodes
+	Truncated bool          `json:"truncated" example:"false"`
+	Nodes     []*BrowseNode `json:"nodes"`
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// CertificateStoreEnum - разделы хранилища сертификатов серверов
+type CertificateStoreEnum string
+
+const (
+	StoreTrusted  CertificateStoreEnum = "trusted"
+	StoreRejected CertificateStoreEnum = "rejected"
+	StoreIssuers  CertificateStoreEnum = "issuers"
+)
+
+// Validate проверяет корректность CertificateStoreEnum
+func (s CertificateStoreEnum) Validate() error {
+	switch s {
+	case StoreTrusted, StoreRejected, StoreIssuers:
+		return nil
+	default:
+		return fmt.Errorf("invalid certificate store: %s", s)
+	}
+}
+
+// ServerCertificateInfo - сведения о сертификате сервера в хранилище
+type ServerCertificateInfo struct {
+	Thumbprint     string               `json:"thumbprint" example:"5f0e3c1b9a7d2e4f6a8b0c1d2e3f4a5b6c7d8e9f"` // SHA-1 отпечаток
+	Store          CertificateStoreEnum `json:"store,omitempty" example:"rejected"`                            // Пусто - сертификата нет в хранилище
+	Subject        string               `json:"subject" example:"CN=TNC640,O=HEIDENHAIN"`
+	Issuer         string               `json:"issuer" example:"CN=TNC640,O=HEIDENHAIN"`
+	ApplicationURI string               `json:"applicationURI,omitempty" example:"urn:TNC640:HEIDENHAIN:OpcUaServer"`
+	NotBefore      time.Time            `json:"notBefore"`
+	NotAfter       time.Time            `json:"notAfter"`
+}
+
+// ServerCertificateListResponse - список сертификатов серверов
+type ServerCertificateListResponse struct {
+	Certificates []ServerCertificateInfo `json:"certificates"`
+}
+
+// ApplicationCertificateInfo - сведения о сертификате экземпляра клиентского приложения сервиса
+type ApplicationCertificateInfo struct {
+	Thumbprint     string    `json:"thumbprint" example:"0a1b2c3d4e5f60718293a4b5c6d7e8f901234567"`
+	Subject        string    `json:"subject" example:"CN=OpcUaService,O=OpcUaService"`
+	ApplicationURI string    `json:"applicationURI" example:"urn:opc-host:OpcUaService"`
+	NotBefore      time.Time `json:"notBefore"`
+	NotAfter       time.Time `json:"notAfter"`
+}
+
+// CertificateExpiryStatusEnum - состояние срока действия сертификата
+type CertificateExpiryStatusEnum string
+
+const (
+	ExpiryOK       CertificateExpiryStatusEnum = "ok"
+	ExpiryExpiring CertificateExpiryStatusEnum = "expiring"
+	ExpiryExpired  CertificateExpiryStatusEnum = "expired"
+	ExpiryInvalid  CertificateExpiryStatusEnum = "invalid" // Сертификат не удалось разобрать
+)
+
+// CertificateExpiryStatus возвращает число полных дней до истечения сертификата и его состояние
+func CertificateExpiryStatus(notAfter time.Time, warnDays int) (int, CertificateExpiryStatusEnum) {
+	days := int(math.Floor(time.Until(notAfter).Hours() / 24))
+	switch {
+	case time.Now().After(notAfter):
+		return days, ExpiryExpired
+	case days < warnDays:
+		return days, ExpiryExpiring
+	default:
+		return days, ExpiryOK
+	}
+}
+
+// CertificateExpiryInfo - срок действия клиентского сертификата станка
+type CertificateExpiryInfo struct {
+	UUID         string                      `json:"UUID" example:"848f855f-f4c2-45f1-a216-c9fa64a6369f"`
+	Manufacturer string                      `json:"manufacturer" example:"Heidenhain"`
+	Model        string                      `json:"model" example:"TNC640"`
+	EndpointURL  string                      `json:"endpointURL" example:"opc.tcp://KHRLLW_-340595:4840/HEIDENHAIN/NC"`
+	Source       string                      `json:"source" example:"machine"` // machine - сертификат станка, application - сертификат сервиса
+	Thumbprint   string                      `json:"thumbprint,omitempty" example:"5f0e3c1b9a7d2e4f6a8b0c1d2e3f4a5b6c7d8e9f"`
+	Subject      string                      `json:"subject,omitempty" example:"CN=client1"`
+	NotAfter     time.Time                   `json:"notAfter"`
+	DaysToExpiry int                         `json:"daysToExpiry" example:"42"`
+	Status       CertificateExpiryStatusEnum `json:"status" example:"ok"`
+	Error        string                      `json:"error,omitempty"`
+}
+
+// CertificateExpiryResponse - сроки действия сертификатов всех станков
+type CertificateExpiryResponse struct {
+	WarnDays     int                     `json:"warnDays" example:"30"`
+	Certificates []CertificateExpiryInfo `json:"certificates"`
+}
+
+// RotateCertificateRequest - замена клиентского сертификата станка
+type RotateCertificateRequest struct {
+	UUID        string `json:"UUID" binding:"required" example:"848f855f-f4c2-45f1-a216-c9fa64a6369f"`
+	Certificate string `json:"certificate" binding:"required" example:"<Base64_encoded_bytes_of_certificate>"`
+	Key         string `json:"key" binding:"required" example:"<Base64_encoded_bytes_of_key>"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type AxisInfosResponse struct {
 	Name             string  `json:"name"`
 	Position         float64 `json:"position"`
@@ -9,18 +11,66 @@ type AxisInfosResponse struct {
 	PowerConsumption float64 `json:"power_consumption"`
 }
 
-type AlarmsResponse struct{}
+// AlarmsResponse - активная тревога станка по событиям AlarmConditionType сервера
+type AlarmsResponse struct {
+	ConditionID   string    `json:"condition_id"`
+	ConditionName string    `json:"condition_name,omitempty"`
+	EventType     string    `json:"event_type,omitempty"`
+	SourceNode    string    `json:"source_node,omitempty"`
+	SourceName    string    `json:"source_name,omitempty"`
+	Severity      uint16    `json:"severity"` // 1..1000
+	Message       string    `json:"message"`
+	Active        bool      `json:"active"`
+	Acked         bool      `json:"acked"`
+	Time          time.Time `json:"time"` // время последнего события тревоги на сервере
+}
+
 type ProgramResponse struct {
 	ProgramName   string `json:"program_name"`
 	ProgramNumber int    `json:"program_number"`
 	GCodeLine     string `json:"g_code_line"`
 }
 
-type SpindleInfosResponse struct{}
+// SpindleInfosResponse - состояние шпинделя
+type SpindleInfosResponse struct {
+	Name         string  `json:"name"`
+	IsRotating   bool    `json:"is_rotating"`
+	Speed        float64 `json:"speed"`        // фактическая скорость, об/мин
+	LoadPercent  float64 `json:"load_percent"` // нагрузка привода, %
+	Override     float64 `json:"override"`     // коррекция скорости, %
+	IsUsedAsAxis bool    `json:"is_used_as_axis"`
+}
+
+// ChannelInfosResponse - состояние канала ЧПУ
+type ChannelInfosResponse struct {
+	Name          string  `json:"name"`
+	State         string  `json:"state"` // ACTIVE, INTERRUPTED, RESET
+	Mode          string  `json:"mode"`  // AUTOMATIC, MDA, MANUAL, ...
+	FeedOverride  float64 `json:"feed_override"`
+	RapidOverride float64 `json:"rapid_override"`
+}
+
+// ValueQualityEnum - качество значения узла по StatusCode OPC UA
+type ValueQualityEnum string
+
+const (
+	QualityGood      ValueQualityEnum = "Good"
+	QualityUncertain ValueQualityEnum = "Uncertain"
+	QualityBad       ValueQualityEnum = "Bad"
+)
+
+// ValueQuality - качество и метки времени значения, из которого получено поле
+type ValueQuality struct {
+	NodeID          string           `json:"node_id"`
+	Quality         ValueQualityEnum `json:"quality"`
+	StatusCode      string           `json:"status_code"`
+	SourceTimestamp *time.Time       `json:"source_timestamp,omitempty"`
+	ServerTimestamp *time.Time       `json:"server_timestamp,omitempty"`
+}
 
 type MachineDataResponse struct {
 	MachineId string `json:"machine_id"`
-	Timestamp int64  `json:"timestamp"`
+	Timestamp int64  `json:"timestamp"` // Время снимка, Unix мс: самая поздняя метка времени из прочитанных значений
 
 	IsEnabled bool `json:"is_enabled"`
 
@@ -49,7 +99,14 @@ type MachineDataResponse struct {
 
 	CurrentProgram ProgramResponse        `json:"current_program"`
 	SpindleInfos   []SpindleInfosResponse `json:"spindle_infos"`
+	ChannelInfos   []ChannelInfosResponse `json:"channel_infos,omitempty"`
 
 	CountourFeedRate float64 `json:"countour_feed_rate"`
 	JogOverride      float64 `json:"jog_override"`
+
+	// Quality - качество и метки времени по каждому прочитанному значению, ключ - имя значения
+	Quality map[string]ValueQuality `json:"quality,omitempty"`
+
+	// Backfilled - снимок восстановлен из истории сервера задачей дозаполнения, а не получен в реальном времени
+	Backfilled bool `json:"backfilled,omitempty"`
 }
@@ -14,11 +14,18 @@ type ConnectionRequest struct {
 	Certificate    string                    `json:"certificate,omitempty" example:"cert-abc-123"` // для certificate
 	Key            string                    `json:"key,omitempty" example:"secret"`
 	EndpointURL    string                    `json:"endpointURL" example:"opc.tcp://KHRLLW_-340595:4840/HEIDENHAIN/NC"`
-	Policy         SecurityPolicyEnum        `json:"policy,omitempty" example:"Basic256Sha256"` // OPC UA SecurityPolicy
+	Policy         SecurityPolicyEnum        `json:"policy,omitempty" example:"Basic256Sha256"` // OPC UA SecurityPolicy: None, Basic128Rsa15, Basic256, Basic256Sha256, Aes128_Sha256_RsaOaep, Aes256_Sha256_RsaPss (ECC политики отклоняются)
 	Mode           MessageSecurityModeEnum   `json:"mode,omitempty" example:"SignAndEncrypt"`   // OPC UA MessageSecurityMode
 	Timeout        int                       `json:"timeout,omitempty" example:"30"`
-	Manufacturer   string                    `json:"manufacturer" binding:"required" example:"Heidenhain"`
-	Model          string                    `json:"model" binding:"required" example:"TNC640"`
+	// TrustOnFirstUse - автоматически доверять сертификату сервера при первом подключении
+	TrustOnFirstUse bool `json:"trustOnFirstUse,omitempty" example:"false"`
+	// AllowInsecurePassword - разрешить подключение по паролю через endpoint с политикой None, где пароль
+	// передаётся без шифрования. По умолчанию такое подключение отклоняется
+	AllowInsecurePassword bool `json:"allowInsecurePassword,omitempty" example:"false"`
+	// Manufacturer и Model необязательны: незаданные определяются по BuildInfo, пространствам имён и Identification
+	// сервера. Заявленная модель, которая противоречит определённой, отклоняется
+	Manufacturer string `json:"manufacturer,omitempty" example:"Heidenhain"`
+	Model        string `json:"model,omitempty" example:"TNC640"`
 }
 
 // DisconnectRequest - отключение станка
@@ -74,6 +81,8 @@ type ConnectionInfoResponse struct {
 	CreatedAt   time.Time               `json:"createdAt" example:"2025-08-22T12:00:00Z"`
 	LastUsed    time.Time               `json:"lastUsed" example:"2025-08-22T12:05:00Z"`
 	UseCount    int64                   `json:"useCount" example:"1"`
+	// State - состояние жизненного цикла сессии, время переходов и последняя ошибка
+	State ConnectionStateInfo `json:"state"`
 }
 
 // CheckConnectionWithInfoResponse - ответ проверки соединения
@@ -90,5 +99,15 @@ type ConnectionPoolResponse struct {
 }
 
 type PollingResponse struct {
-	Polled bool `json:"polled" binding:"required"`
+	Polled bool                       `json:"polled" binding:"required"`
+	Mode   models.AcquisitionModeEnum `json:"mode,omitempty" example:"subscription"`
+}
+
+// StartPollingRequest - запуск получения данных станка опросом или подпиской
+type StartPollingRequest struct {
+	UUID string `json:"UUID" binding:"required"`
+	// Mode - polling (по умолчанию) или subscription
+	Mode models.AcquisitionModeEnum `json:"mode,omitempty" example:"subscription"`
+	// Subscription - параметры подписки для режима subscription
+	Subscription *models.SubscriptionSettings `json:"subscription,omitempty"`
 }
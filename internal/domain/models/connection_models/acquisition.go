@@ -0,0 +1,192 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultPollInterval - интервал опроса и публикации подписки, если timeout в запросе на подключение не задан
+const DefaultPollInterval = 5 * time.Second
+
+// PollInterval возвращает интервал опроса станка: timeout подключения или DefaultPollInterval
+func PollInterval(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return DefaultPollInterval
+	}
+	return timeout
+}
+
+// AcquisitionModeEnum - способ получения данных станка
+type AcquisitionModeEnum string
+
+const (
+	AcquisitionPolling      AcquisitionModeEnum = "polling"      // Периодическое чтение всех узлов
+	AcquisitionSubscription AcquisitionModeEnum = "subscription" // Подписка с MonitoredItems, сервер сам присылает изменения
+)
+
+// Validate для проверки enum AcquisitionModeEnum
+func (m AcquisitionModeEnum) Validate() error {
+	switch m {
+	case AcquisitionPolling, AcquisitionSubscription:
+		return nil
+	default:
+		return fmt.Errorf("invalid acquisition mode: %s", m)
+	}
+}
+
+// ---------------------------------------------------------------------------------------------------------------
+
+// DeadbandTypeEnum - тип зоны нечувствительности для MonitoredItem
+type DeadbandTypeEnum string
+
+const (
+	DeadbandNone     DeadbandTypeEnum = "none"     // Уведомление о любом изменении значения
+	DeadbandAbsolute DeadbandTypeEnum = "absolute" // Уведомление, если значение изменилось больше чем на DeadbandValue
+	DeadbandPercent  DeadbandTypeEnum = "percent"  // DeadbandValue в процентах от EURange узла
+)
+
+// Validate для проверки enum DeadbandTypeEnum
+func (d DeadbandTypeEnum) Validate() error {
+	switch d {
+	case DeadbandNone, DeadbandAbsolute, DeadbandPercent:
+		return nil
+	default:
+		return fmt.Errorf("invalid deadband type: %s", d)
+	}
+}
+
+// ---------------------------------------------------------------------------------------------------------------
+
+// MonitoredItemSettings - параметры отдельного узла подписки. Нулевые значения наследуются от SubscriptionSettings
+type MonitoredItemSettings struct {
+	NodeID           string           `json:"nodeID" binding:"required" example:"nsu=http://heidenhain.de/NC/;i=100025"`
+	SamplingInterval int              `json:"samplingInterval,omitempty" example:"100"` // мс
+	QueueSize        uint32           `json:"queueSize,omitempty" example:"10"`
+	DeadbandType     DeadbandTypeEnum `json:"deadbandType,omitempty" example:"absolute"`
+	DeadbandValue    float64          `json:"deadbandValue,omitempty" example:"0.5"`
+}
+
+// SubscriptionSettings - параметры подписки станка и значения по умолчанию для всех его узлов
+type SubscriptionSettings struct {
+	PublishingInterval int                     `json:"publishingInterval,omitempty" example:"1000"` // мс, по умолчанию - интервал опроса станка
+	SamplingInterval   int                     `json:"samplingInterval,omitempty" example:"500"`    // мс, по умолчанию - PublishingInterval
+	QueueSize          uint32                  `json:"queueSize,omitempty" example:"1"`             // по умолчанию 1
+	DeadbandType       DeadbandTypeEnum        `json:"deadbandType,omitempty" example:"none"`
+	DeadbandValue      float64                 `json:"deadbandValue,omitempty" example:"0"`
+	Items              []MonitoredItemSettings `json:"items,omitempty"`
+}
+
+// Validate проверяет параметры подписки
+func (s SubscriptionSettings) Validate() error {
+	if s.PublishingInterval < 0 || s.SamplingInterval < 0 {
+		return fmt.Errorf("intervals must not be negative")
+	}
+	if err := validateDeadband(s.DeadbandType, s.DeadbandValue); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(s.Items))
+	for _, item := range s.Items {
+		if item.NodeID == "" {
+			return fmt.Errorf("item nodeID is required")
+		}
+		if seen[item.NodeID] {
+			return fmt.Errorf("duplicate settings for node %s", item.NodeID)
+		}
+		seen[item.NodeID] = true
+
+		if item.SamplingInterval < 0 {
+			return fmt.Errorf("node %s: sampling interval must not be negative", item.NodeID)
+		}
+		if err := validateDeadband(item.DeadbandType, item.DeadbandValue); err != nil {
+			return fmt.Errorf("node %s: %w", item.NodeID, err)
+		}
+	}
+	return nil
+}
+
+// HasItem проверяет, заданы ли отдельные параметры для узла
+func (s SubscriptionSettings) HasItem(nodeID string) bool {
+	for _, item := range s.Items {
+		if item.NodeID == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// ItemSettings возвращает итоговые параметры узла с учётом значений по умолчанию подписки
+func (s SubscriptionSettings) ItemSettings(nodeID string) MonitoredItemSettings {
+	result := MonitoredItemSettings{
+		NodeID:           nodeID,
+		SamplingInterval: s.SamplingInterval,
+		QueueSize:        s.QueueSize,
+		DeadbandType:     s.DeadbandType,
+		DeadbandValue:    s.DeadbandValue,
+	}
+	if result.SamplingInterval == 0 {
+		result.SamplingInterval = s.PublishingInterval
+	}
+	if result.QueueSize == 0 {
+		result.QueueSize = 1
+	}
+	if result.DeadbandType == "" {
+		result.DeadbandType = DeadbandNone
+	}
+
+	for _, item := range s.Items {
+		if item.NodeID != nodeID {
+			continue
+		}
+		if item.SamplingInterval != 0 {
+			result.SamplingInterval = item.SamplingInterval
+		}
+		if item.QueueSize != 0 {
+			result.QueueSize = item.QueueSize
+		}
+		if item.DeadbandType != "" {
+			result.DeadbandType = item.DeadbandType
+			result.DeadbandValue = item.DeadbandValue
+		}
+	}
+	return result
+}
+
+// Value сохраняет настройки подписки в jsonb колонку
+func (s SubscriptionSettings) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan читает настройки подписки из jsonb колонки
+func (s *SubscriptionSettings) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = SubscriptionSettings{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("unsupported subscription settings type: %T", value)
+	}
+}
+
+// validateDeadband проверяет тип и значение зоны нечувствительности
+func validateDeadband(t DeadbandTypeEnum, value float64) error {
+	if t == "" {
+		return nil
+	}
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	if value < 0 {
+		return fmt.Errorf("deadband value must not be negative")
+	}
+	if t == DeadbandPercent && value > 100 {
+		return fmt.Errorf("percent deadband must be in range 0..100")
+	}
+	return nil
+}
@@ -6,10 +6,14 @@ import (
 
 // AnonymousConnection конфигурация подключения OPC UA анонимно
 type AnonymousConnection struct {
-	EndpointURL  string
-	Timeout      time.Duration
-	Manufacturer string
-	Model        string
+	EndpointURL string
+	Policy      string
+	Mode        string
+	// TrustOnFirstUse - доверять сертификату сервера при первом подключении
+	TrustOnFirstUse bool
+	Timeout         time.Duration
+	Manufacturer    string
+	Model           string
 }
 
 func (*AnonymousConnection) GetType() ConnectionTypeEnum {
@@ -17,7 +21,7 @@ func (*AnonymousConnection) GetType() ConnectionTypeEnum {
 }
 
 func (ac *AnonymousConnection) GetManufacturer() string {
-	return ac.Model
+	return ac.Manufacturer
 }
 
 func (ac *AnonymousConnection) GetModel() string {
@@ -1,19 +1,31 @@
 package models
 
 import (
+	"crypto"
 	"time"
 )
 
+// ClientCredentials - разобранные сертификат клиента (DER) и его приватный ключ
+type ClientCredentials struct {
+	Certificate []byte
+	Key         crypto.Signer
+}
+
 // CertificateConnection конфигурация подключения OPC UA по сертификату
 type CertificateConnection struct {
-	EndpointURL  string
-	Certificate  []byte
-	Key          []byte
-	Policy       string
-	Mode         string
-	Timeout      time.Duration
-	Manufacturer string
-	Model        string
+	EndpointURL string
+	Certificate []byte
+	Key         []byte
+	Policy      string
+	Mode        string
+	// TrustOnFirstUse - доверять сертификату сервера при первом подключении
+	TrustOnFirstUse bool
+	Timeout         time.Duration
+	Manufacturer    string
+	Model           string
+	// Credentials - Certificate и Key, разобранные коннектором при создании или замене конфигурации.
+	// Переподключения используют их, не разбирая сертификат заново
+	Credentials *ClientCredentials `json:"-"`
 }
 
 func (*CertificateConnection) GetType() ConnectionTypeEnum {
@@ -35,3 +47,8 @@ func (cc *CertificateConnection) GetEndpointURL() string {
 func (ac *CertificateConnection) GetTimeout() time.Duration {
 	return ac.Timeout
 }
+
+// UsesApplicationCertificate - подключение использует сертификат приложения сервиса вместо переданного клиентом
+func (cc *CertificateConnection) UsesApplicationCertificate() bool {
+	return len(cc.Certificate) == 0 && len(cc.Key) == 0
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// NodeList - список NodeID станка, хранится в jsonb колонке
+type NodeList []string
+
+// Contains проверяет, входит ли NodeID в список
+func (l NodeList) Contains(nodeID string) bool {
+	for _, n := range l {
+		if n == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// Value сохраняет список в jsonb колонку
+func (l NodeList) Value() (driver.Value, error) {
+	if l == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(l))
+}
+
+// Scan читает список из jsonb колонки
+func (l *NodeList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]string)(l))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]string)(l))
+	default:
+		return fmt.Errorf("unsupported node list type: %T", value)
+	}
+}
+
+// CallableMethod - метод, который разрешено вызывать на конкретном объекте станка
+type CallableMethod struct {
+	ObjectID string `json:"objectID" example:"ns=2;s=/Methods"`
+	MethodID string `json:"methodID" example:"ns=2;s=/Methods/Select"`
+}
+
+// MethodList - список пар объект/метод станка, которые разрешено вызывать, хранится в jsonb колонке
+type MethodList []CallableMethod
+
+// Contains проверяет, разрешён ли метод methodID на объекте objectID
+func (l MethodList) Contains(objectID, methodID string) bool {
+	for _, m := range l {
+		if m.ObjectID == objectID && m.MethodID == methodID {
+			return true
+		}
+	}
+	return false
+}
+
+// Value сохраняет список в jsonb колонку
+func (l MethodList) Value() (driver.Value, error) {
+	if l == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]CallableMethod(l))
+}
+
+// Scan читает список из jsonb колонки. Записи старого формата - NodeID метода без объекта - пропускаются:
+// по ним нельзя понять, на каком объекте метод разрешён
+func (l *MethodList) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported method list type: %T", value)
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	methods := make(MethodList, 0, len(items))
+	for _, item := range items {
+		var m CallableMethod
+		if err := json.Unmarshal(item, &m); err != nil {
+			continue
+		}
+		methods = append(methods, m)
+	}
+	*l = methods
+	return nil
+}
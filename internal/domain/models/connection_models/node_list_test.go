@@ -0,0 +1,35 @@
+package models
+
+import "testing"
+
+func TestMethodListScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  MethodList
+	}{
+		{name: "пары объект/метод", value: []byte(`[{"objectID":"ns=2;s=/Methods","methodID":"ns=2;s=/Methods/Select"}]`),
+			want: MethodList{{ObjectID: "ns=2;s=/Methods", MethodID: "ns=2;s=/Methods/Select"}}},
+		{name: "записи прежнего формата пропускаются", value: `["ns=2;s=/Methods/Select"]`, want: MethodList{}},
+		{name: "NULL", value: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got MethodList
+			if err := got.Scan(tt.value); err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if len(got) != len(tt.want) || (tt.want == nil) != (got == nil) {
+				t.Fatalf("Scan() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Scan()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+			if got.Contains("ns=2;s=/Other", "ns=2;s=/Methods/Select") {
+				t.Fatal("метод разрешён на объекте вне списка")
+			}
+		})
+	}
+}
@@ -6,14 +6,18 @@ import (
 
 // PasswordConnection конфигурация подключения OPC UA по паролю
 type PasswordConnection struct {
-	EndpointURL  string
-	Username     string
-	Password     string
-	Policy       string
-	Mode         string
-	Timeout      time.Duration
-	Manufacturer string
-	Model        string
+	EndpointURL string
+	Username    string
+	Password    string
+	Policy      string
+	Mode        string
+	// TrustOnFirstUse - доверять сертификату сервера при первом подключении
+	TrustOnFirstUse bool
+	// AllowInsecurePassword - разрешить передачу пароля по каналу без шифрования (политика None)
+	AllowInsecurePassword bool
+	Timeout               time.Duration
+	Manufacturer          string
+	Model                 string
 }
 
 func (*PasswordConnection) GetType() ConnectionTypeEnum {
@@ -21,7 +25,7 @@ func (*PasswordConnection) GetType() ConnectionTypeEnum {
 }
 
 func (pc *PasswordConnection) GetManufacturer() string {
-	return pc.Model
+	return pc.Manufacturer
 }
 
 func (pc *PasswordConnection) GetModel() string {
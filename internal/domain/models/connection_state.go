@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConnectionStateEnum - состояния жизненного цикла соединения с OPC UA сервером
+type ConnectionStateEnum string
+
+const (
+	StateConnecting   ConnectionStateEnum = "Connecting"   // Сессия устанавливается впервые
+	StateConnected    ConnectionStateEnum = "Connected"    // Сессия установлена и отвечает
+	StateDegraded     ConnectionStateEnum = "Degraded"     // Сессия жива, но чтение узлов завершается ошибками
+	StateReconnecting ConnectionStateEnum = "Reconnecting" // Сессия потеряна, идёт переподключение в фоне
+	StateAuthFailed   ConnectionStateEnum = "AuthFailed"   // Сервер отклонил учётные данные или сертификат
+	StateClosed       ConnectionStateEnum = "Closed"       // Соединение закрыто и удалено из пула
+)
+
+// maxStateTransitions - сколько последних переходов хранится в истории соединения
+const maxStateTransitions = 20
+
+// Validate проверяет корректность ConnectionStateEnum
+func (s ConnectionStateEnum) Validate() error {
+	switch s {
+	case StateConnecting, StateConnected, StateDegraded, StateReconnecting, StateAuthFailed, StateClosed:
+		return nil
+	default:
+		return fmt.Errorf("invalid connection state: %s", s)
+	}
+}
+
+// IsUsable проверяет, можно ли выполнять запросы через сессию в этом состоянии
+func (s ConnectionStateEnum) IsUsable() bool {
+	return s == StateConnected || s == StateDegraded
+}
+
+// ToStatus сводит состояние к детальному статусу соединения.
+// Для потерянной сессии статус уточняется по последней ошибке: таймаут означает, что станок недоступен.
+func (s ConnectionStateEnum) ToStatus(lastErrorIsTimeout bool) ConnectionStatusEnum {
+	switch s {
+	case StateConnected:
+		return StatusHealthy
+	case StateDegraded:
+		return StatusUnhealthy
+	case StateAuthFailed:
+		return StatusAuthFailed
+	case StateConnecting, StateReconnecting:
+		if lastErrorIsTimeout {
+			return StatusTimeout
+		}
+		return StatusDisconnected
+	case StateClosed:
+		return StatusDisconnected
+	default:
+		return StatusError
+	}
+}
+
+// ConnectionStateTransition - переход соединения из одного состояния в другое
+type ConnectionStateTransition struct {
+	From  ConnectionStateEnum `json:"from,omitempty" example:"Connected"`
+	To    ConnectionStateEnum `json:"to" example:"Reconnecting"`
+	At    time.Time           `json:"at" example:"2025-08-22T12:05:00Z"`
+	Error string              `json:"error,omitempty" example:"failed to connect: i/o timeout"`
+}
+
+// ConnectionStateInfo - текущее состояние соединения, последняя ошибка и история переходов
+type ConnectionStateInfo struct {
+	State          ConnectionStateEnum         `json:"state" example:"Connected"`
+	StateChangedAt time.Time                   `json:"stateChangedAt" example:"2025-08-22T12:00:00Z"`
+	LastError      string                      `json:"lastError,omitempty" example:"failed to connect: i/o timeout"`
+	LastErrorAt    *time.Time                  `json:"lastErrorAt,omitempty" example:"2025-08-22T12:05:00Z"`
+	Transitions    []ConnectionStateTransition `json:"transitions"`
+
+	lastErrorIsTimeout bool
+}
+
+// setState переводит соединение в новое состояние и запоминает переход.
+// err - причина перехода; nil не сбрасывает последнюю ошибку, чтобы её было видно после восстановления.
+// Повторная установка того же состояния фиксирует только новую ошибку.
+func (si *ConnectionStateInfo) setState(state ConnectionStateEnum, err error, isTimeout bool) {
+	now := time.Now()
+	if err != nil {
+		si.LastError = err.Error()
+		si.LastErrorAt = &now
+		si.lastErrorIsTimeout = isTimeout
+	}
+	if si.State == state {
+		return
+	}
+
+	transition := ConnectionStateTransition{From: si.State, To: state, At: now}
+	if err != nil {
+		transition.Error = err.Error()
+	}
+	si.Transitions = append(si.Transitions, transition)
+	if len(si.Transitions) > maxStateTransitions {
+		si.Transitions = si.Transitions[len(si.Transitions)-maxStateTransitions:]
+	}
+	si.State = state
+	si.StateChangedAt = now
+}
+
+// Status возвращает детальный статус соединения для текущего состояния
+func (si *ConnectionStateInfo) Status() ConnectionStatusEnum {
+	return si.State.ToStatus(si.lastErrorIsTimeout)
+}
+
+// copy возвращает независимую копию состояния
+func (si *ConnectionStateInfo) copy() ConnectionStateInfo {
+	c := *si
+	c.Transitions = append([]ConnectionStateTransition(nil), si.Transitions...)
+	if si.LastErrorAt != nil {
+		at := *si.LastErrorAt
+		c.LastErrorAt = &at
+	}
+	return c
+}
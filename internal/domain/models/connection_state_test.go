@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestConnectionStateToStatus(t *testing.T) {
+	tests := []struct {
+		state     ConnectionStateEnum
+		isTimeout bool
+		want      ConnectionStatusEnum
+	}{
+		{StateConnected, false, StatusHealthy},
+		{StateDegraded, false, StatusUnhealthy},
+		{StateAuthFailed, false, StatusAuthFailed},
+		{StateConnecting, false, StatusDisconnected},
+		{StateConnecting, true, StatusTimeout},
+		{StateReconnecting, false, StatusDisconnected},
+		{StateReconnecting, true, StatusTimeout},
+		{StateClosed, true, StatusDisconnected},
+		{"Unknown", false, StatusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/timeout=%v", tt.state, tt.isTimeout), func(t *testing.T) {
+			if got := tt.state.ToStatus(tt.isTimeout); got != tt.want {
+				t.Fatalf("ToStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionInfoStateTransitions(t *testing.T) {
+	errTimeout := fmt.Errorf("read: %w", context.DeadlineExceeded)
+	errRead := fmt.Errorf("read: bad node")
+
+	steps := []struct {
+		state          ConnectionStateEnum
+		err            error
+		wantStatus     ConnectionStatusEnum
+		wantUsable     bool
+		wantReconnect  bool
+		wantLastError  string
+		wantTransition int
+	}{
+		{StateConnecting, nil, StatusDisconnected, false, true, "", 1},
+		{StateConnected, nil, StatusHealthy, true, false, "", 2},
+		{StateDegraded, errRead, StatusUnhealthy, true, false, errRead.Error(), 3},
+		// Повтор того же состояния запоминает только новую ошибку
+		{StateDegraded, errTimeout, StatusUnhealthy, true, false, errTimeout.Error(), 3},
+		{StateReconnecting, errTimeout, StatusTimeout, false, true, errTimeout.Error(), 4},
+		// Восстановление не стирает последнюю ошибку
+		{StateConnected, nil, StatusHealthy, true, false, errTimeout.Error(), 5},
+		{StateClosed, nil, StatusDisconnected, false, false, errTimeout.Error(), 6},
+	}
+
+	info := &ConnectionInfo{}
+	for i, step := range steps {
+		info.SetState(step.state, step.err)
+		snapshot := info.StateSnapshot()
+		if snapshot.State != step.state {
+			t.Fatalf("шаг %d: состояние %s, ожидалось %s", i, snapshot.State, step.state)
+		}
+		if got := snapshot.Status(); got != step.wantStatus {
+			t.Fatalf("шаг %d: статус %s, ожидался %s", i, got, step.wantStatus)
+		}
+		if got := info.StateInfo.State.IsUsable(); got != step.wantUsable {
+			t.Fatalf("шаг %d: IsUsable() = %v, ожидалось %v", i, got, step.wantUsable)
+		}
+		if got := info.IsReconnecting(); got != step.wantReconnect {
+			t.Fatalf("шаг %d: IsReconnecting() = %v, ожидалось %v", i, got, step.wantReconnect)
+		}
+		if snapshot.LastError != step.wantLastError {
+			t.Fatalf("шаг %d: последняя ошибка %q, ожидалась %q", i, snapshot.LastError, step.wantLastError)
+		}
+		if len(snapshot.Transitions) != step.wantTransition {
+			t.Fatalf("шаг %d: переходов %d, ожидалось %d", i, len(snapshot.Transitions), step.wantTransition)
+		}
+	}
+
+	first := info.StateSnapshot().Transitions[0]
+	if first.From != "" || first.To != StateConnecting {
+		t.Fatalf("первый переход %s -> %s, ожидался -> %s", first.From, first.To, StateConnecting)
+	}
+}
+
+func TestConnectionStateTransitionsLimit(t *testing.T) {
+	info := &ConnectionInfo{}
+	for i := 0; i < maxStateTransitions+5; i++ {
+		if i%2 == 0 {
+			info.SetState(StateConnected, nil)
+		} else {
+			info.SetState(StateDegraded, fmt.Errorf("read error %d", i))
+		}
+	}
+
+	snapshot := info.StateSnapshot()
+	if len(snapshot.Transitions) != maxStateTransitions {
+		t.Fatalf("переходов %d, ожидалось %d", len(snapshot.Transitions), maxStateTransitions)
+	}
+	// Снимок не должен зависеть от дальнейших переходов
+	info.SetState(StateClosed, nil)
+	if last := snapshot.Transitions[len(snapshot.Transitions)-1]; last.To == StateClosed {
+		t.Fatal("снимок изменился после нового перехода")
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import "github.com/awcullen/opcua/ua"
+
+// EndpointRequirements - требования к endpoint сервера при выборе политики безопасности
+type EndpointRequirements struct {
+	Policy               SecurityPolicyEnum      // Пусто - выбрать самую сильную из доступных
+	Mode                 MessageSecurityModeEnum // Пусто - любой режим для выбранной политики
+	TokenType            ua.UserTokenType        // Тип токена пользователя, который должен поддерживать endpoint
+	HasClientCertificate bool                    // Без сертификата клиента доступна только политика None
+}
+
+// EndpointsRequest - запрос списка endpoint'ов OPC UA сервера
+type EndpointsRequest struct {
+	EndpointURL string `json:"endpointURL" binding:"required" example:"opc.tcp://KHRLLW_-340595:4840/HEIDENHAIN/NC"`
+}
+
+// UserTokenPolicyResponse - способ аутентификации пользователя, предлагаемый endpoint'ом
+type UserTokenPolicyResponse struct {
+	TokenType         string `json:"tokenType" example:"UserName"` // Anonymous, UserName, Certificate, IssuedToken
+	PolicyID          string `json:"policyID" example:"username_basic256sha256"`
+	SecurityPolicyURI string `json:"securityPolicyURI,omitempty" example:"http://opcfoundation.org/UA/SecurityPolicy#Basic256Sha256"`
+}
+
+// EndpointDescriptionResponse - описание endpoint'а OPC UA сервера
+type EndpointDescriptionResponse struct {
+	EndpointURL       string                    `json:"endpointURL" example:"opc.tcp://KHRLLW_-340595:4840/HEIDENHAIN/NC"`
+	ApplicationURI    string                    `json:"applicationURI" example:"urn:TNC640:HEIDENHAIN:OpcUaServer"`
+	ApplicationName   string                    `json:"applicationName" example:"HEIDENHAIN OPC UA NC Server"`
+	Policy            SecurityPolicyEnum        `json:"policy" example:"Basic256Sha256"`
+	SecurityPolicyURI string                    `json:"securityPolicyURI" example:"http://opcfoundation.org/UA/SecurityPolicy#Basic256Sha256"`
+	Mode              MessageSecurityModeEnum   `json:"mode" example:"SignAndEncrypt"`
+	SecurityLevel     uint8                     `json:"securityLevel" example:"3"`
+	Supported         bool                      `json:"supported" example:"true"` // Поддерживается ли политика сервисом
+	UserTokens        []UserTokenPolicyResponse `json:"userTokens"`
+	ServerCertificate *ServerCertificateInfo    `json:"serverCertificate,omitempty"`
+}
+
+// EndpointsResponse - результат GetEndpoints
+type EndpointsResponse struct {
+	Endpoints []EndpointDescriptionResponse `json:"endpoints"`
+}
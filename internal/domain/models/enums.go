@@ -2,29 +2,55 @@ package models
 
 import (
 	"fmt"
+	"github.com/awcullen/opcua/ua"
 	"net/http"
+	"strings"
 )
 
 // SecurityPolicyEnum - допустимые политики безопасности OPC UA
 type SecurityPolicyEnum string
 
 const (
-	PolicyNone           SecurityPolicyEnum = "None"
-	PolicyBasic128Rsa15  SecurityPolicyEnum = "Basic128Rsa15"
-	PolicyBasic256       SecurityPolicyEnum = "Basic256"
-	PolicyBasic256Sha256 SecurityPolicyEnum = "Basic256Sha256"
+	PolicyNone                SecurityPolicyEnum = "None"
+	PolicyBasic128Rsa15       SecurityPolicyEnum = "Basic128Rsa15"
+	PolicyBasic256            SecurityPolicyEnum = "Basic256"
+	PolicyBasic256Sha256      SecurityPolicyEnum = "Basic256Sha256"
+	PolicyAes128Sha256RsaOaep SecurityPolicyEnum = "Aes128_Sha256_RsaOaep"
+	PolicyAes256Sha256RsaPss  SecurityPolicyEnum = "Aes256_Sha256_RsaPss"
+	PolicyEccNistP256         SecurityPolicyEnum = "ECC_nistP256"
+	PolicyEccNistP384         SecurityPolicyEnum = "ECC_nistP384"
 )
 
+// securityPolicyURIPrefix - общий префикс URI политик безопасности OPC UA
+const securityPolicyURIPrefix = "http://opcfoundation.org/UA/SecurityPolicy#"
+
 // Validate проверяет корректность SecurityPolicyEnum
 func (p SecurityPolicyEnum) Validate() error {
 	switch p {
-	case PolicyNone, PolicyBasic128Rsa15, PolicyBasic256, PolicyBasic256Sha256:
+	case PolicyNone, PolicyBasic128Rsa15, PolicyBasic256, PolicyBasic256Sha256,
+		PolicyAes128Sha256RsaOaep, PolicyAes256Sha256RsaPss,
+		PolicyEccNistP256, PolicyEccNistP384:
 		return nil
 	default:
 		return fmt.Errorf("invalid security policy: %s", p)
 	}
 }
 
+// URI возвращает URI политики безопасности OPC UA
+func (p SecurityPolicyEnum) URI() string {
+	return securityPolicyURIPrefix + string(p)
+}
+
+// IsECC проверяет, что политика использует ключи на эллиптических кривых
+func (p SecurityPolicyEnum) IsECC() bool {
+	return strings.HasPrefix(string(p), "ECC_")
+}
+
+// SecurityPolicyFromURI возвращает короткое имя политики по её URI
+func SecurityPolicyFromURI(uri string) SecurityPolicyEnum {
+	return SecurityPolicyEnum(strings.TrimPrefix(uri, securityPolicyURIPrefix))
+}
+
 // ---------------------------------------------------------------------------------------------------------------
 
 // MessageSecurityModeEnum - допустимые режимы шифрования сообщений OPC UA
@@ -46,6 +72,25 @@ func (m MessageSecurityModeEnum) Validate() error {
 	}
 }
 
+// ToUA преобразует режим в ua.MessageSecurityMode
+func (m MessageSecurityModeEnum) ToUA() ua.MessageSecurityMode {
+	switch m {
+	case ModeNone:
+		return ua.MessageSecurityModeNone
+	case ModeSign:
+		return ua.MessageSecurityModeSign
+	case ModeSignAndEncrypt:
+		return ua.MessageSecurityModeSignAndEncrypt
+	default:
+		return ua.MessageSecurityModeInvalid
+	}
+}
+
+// MessageSecurityModeFromUA возвращает MessageSecurityModeEnum по ua.MessageSecurityMode
+func MessageSecurityModeFromUA(m ua.MessageSecurityMode) MessageSecurityModeEnum {
+	return MessageSecurityModeEnum(m.String())
+}
+
 // ---------------------------------------------------------------------------------------------------------------
 
 // ConnectionStatusEnum - детальные статусы соединения OPC UA
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Ограничения чтения истории
+const (
+	DefaultHistoryPageSize         = 1000   // NumValuesPerNode одного HistoryRead запроса
+	DefaultHistoryMaxValuesPerNode = 10000  // значений на узел в ответе REST API по умолчанию
+	MaxHistoryMaxValuesPerNode     = 100000 // значений на узел в ответе REST API и в задаче дозаполнения
+	MaxHistoryNodes                = 100
+)
+
+// HistoryReadRequest - запрос сырых исторических значений узлов за интервал времени
+type HistoryReadRequest struct {
+	Nodes     []NodeRef `json:"nodes" binding:"required"`
+	StartTime time.Time `json:"startTime" binding:"required" example:"2025-09-03T00:00:00Z"`
+	EndTime   time.Time `json:"endTime" binding:"required" example:"2025-09-03T12:00:00Z"`
+	// MaxValuesPerNode - максимум значений на узел, по умолчанию 10000, не больше 100000
+	MaxValuesPerNode int `json:"maxValuesPerNode,omitempty" example:"10000"`
+	// ReturnBounds - вернуть граничные значения на начало и конец интервала
+	ReturnBounds bool `json:"returnBounds,omitempty" example:"false"`
+}
+
+// Normalize проверяет запрос и подставляет значения по умолчанию
+func (r *HistoryReadRequest) Normalize() error {
+	if len(r.Nodes) == 0 {
+		return fmt.Errorf("nodes must not be empty")
+	}
+	if len(r.Nodes) > MaxHistoryNodes {
+		return fmt.Errorf("too many nodes: %d, maximum %d", len(r.Nodes), MaxHistoryNodes)
+	}
+	for i, ref := range r.Nodes {
+		if err := ref.Validate(); err != nil {
+			return fmt.Errorf("nodes[%d]: %w", i, err)
+		}
+	}
+	if err := validateHistoryRange(r.StartTime, r.EndTime); err != nil {
+		return err
+	}
+	if r.MaxValuesPerNode == 0 {
+		r.MaxValuesPerNode = DefaultHistoryMaxValuesPerNode
+	}
+	if r.MaxValuesPerNode < 0 || r.MaxValuesPerNode > MaxHistoryMaxValuesPerNode {
+		return fmt.Errorf("maxValuesPerNode must be in range 1..%d", MaxHistoryMaxValuesPerNode)
+	}
+	return nil
+}
+
+// HistorySample - историческое значение узла
+type HistorySample struct {
+	Value           interface{}      `json:"value,omitempty"`
+	Quality         ValueQualityEnum `json:"quality" example:"Good"`
+	StatusCode      string           `json:"statusCode" example:"Good"`
+	SourceTimestamp *time.Time       `json:"sourceTimestamp,omitempty" example:"2025-09-03T10:00:00Z"`
+	ServerTimestamp *time.Time       `json:"serverTimestamp,omitempty" example:"2025-09-03T10:00:00Z"`
+}
+
+// NodeHistory - исторические значения одного узла в порядке времени
+type NodeHistory struct {
+	NodeID     string          `json:"nodeID" example:"ns=1;i=100025"`
+	BrowsePath string          `json:"browsePath,omitempty" example:""`
+	Samples    []HistorySample `json:"samples"`
+	// Truncated - значений больше, чем MaxValuesPerNode
+	Truncated bool   `json:"truncated" example:"false"`
+	Error     string `json:"error,omitempty" example:""`
+}
+
+// HistoryReadResponse - исторические значения узлов в порядке запроса
+type HistoryReadResponse struct {
+	Results []NodeHistory `json:"results"`
+}
+
+// ---------------------------------------------------------------------------------------------------------------
+
+// BackfillStatusEnum - состояние задачи дозаполнения данных из истории
+type BackfillStatusEnum string
+
+const (
+	BackfillRunning   BackfillStatusEnum = "running"
+	BackfillCompleted BackfillStatusEnum = "completed"
+	BackfillFailed    BackfillStatusEnum = "failed"
+)
+
+// BackfillRequest - интервал, за который история узлов модели станка переотправляется в Kafka
+type BackfillRequest struct {
+	StartTime time.Time `json:"startTime" binding:"required" example:"2025-09-03T00:00:00Z"`
+	EndTime   time.Time `json:"endTime" binding:"required" example:"2025-09-03T12:00:00Z"`
+}
+
+// Validate проверяет интервал дозаполнения
+func (r BackfillRequest) Validate() error {
+	return validateHistoryRange(r.StartTime, r.EndTime)
+}
+
+// BackfillJob - задача дозаполнения данных станка из истории сервера
+type BackfillJob struct {
+	ID          uuid.UUID          `json:"id" example:"5b0d7c1e-1f44-4f55-9a57-2f3a1b6c8d90"`
+	MachineUUID uuid.UUID          `json:"machineUUID" example:"12840be9-36b2-4ecb-8243-b9d9e0952a03"`
+	Status      BackfillStatusEnum `json:"status" example:"completed"`
+	StartTime   time.Time          `json:"startTime" example:"2025-09-03T00:00:00Z"`
+	EndTime     time.Time          `json:"endTime" example:"2025-09-03T12:00:00Z"`
+	StartedAt   time.Time          `json:"startedAt" example:"2025-09-03T12:05:00Z"`
+	FinishedAt  *time.Time         `json:"finishedAt,omitempty" example:"2025-09-03T12:05:04Z"`
+	// SampleCount - прочитано исторических значений по всем узлам
+	SampleCount int `json:"sampleCount" example:"5321"`
+	// PublishedCount - отправлено снимков в Kafka
+	PublishedCount int `json:"publishedCount" example:"1200"`
+	// Truncated - у части узлов значений больше лимита задачи, поздние значения не отправлены
+	Truncated bool   `json:"truncated" example:"false"`
+	Error     string `json:"error,omitempty" example:""`
+}
+
+// validateHistoryRange проверяет интервал времени истории
+func validateHistoryRange(start, end time.Time) error {
+	if start.IsZero() || end.IsZero() {
+		return fmt.Errorf("startTime and endTime are required")
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("startTime must be before endTime")
+	}
+	return nil
+}
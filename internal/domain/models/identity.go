@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// ServerIdentity - сведения сервера ЧПУ о себе, по которым определяются производитель и модель станка
+type ServerIdentity struct {
+	// BuildInfo из ServerStatus
+	ProductName      string `json:"productName,omitempty" example:"SINUMERIK 840D sl"`
+	ProductURI       string `json:"productUri,omitempty" example:"urn:Siemens.Automation.OpcUaServer"`
+	ManufacturerName string `json:"manufacturerName,omitempty" example:"Siemens AG"`
+	SoftwareVersion  string `json:"softwareVersion,omitempty" example:"V4.95"`
+	// Namespaces - NamespaceArray сервера
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Identification экземпляра MachineTool (OPC 40501), если сервер его публикует
+	MachineManufacturer string `json:"machineManufacturer,omitempty" example:"DMG MORI"`
+	MachineModel        string `json:"machineModel,omitempty" example:"DMU 50"`
+	SerialNumber        string `json:"serialNumber,omitempty" example:"SN-1024"`
+}
+
+// DetectionSourceEnum - по каким сведениям сервера определена модель станка
+type DetectionSourceEnum string
+
+const (
+	DetectionSourceProfile     DetectionSourceEnum = "profile"      // производитель и модель профиля названы в BuildInfo или Identification
+	DetectionSourceNamespace   DetectionSourceEnum = "namespace"    // на сервере есть пространство имён ЧПУ производителя
+	DetectionSourceMachineTool DetectionSourceEnum = "machine_tool" // сервер публикует экземпляр MachineTool OPC 40501
+)
+
+// DetectedMachine - производитель и модель станка, определённые по сведениям сервера
+type DetectedMachine struct {
+	Manufacturer string
+	Model        string
+	Source       DetectionSourceEnum
+}
+
+// MachineDetectionResponse - модель станка, определённая при подключении
+type MachineDetectionResponse struct {
+	Manufacturer    string              `json:"manufacturer" example:"Siemens"`
+	Model           string              `json:"model" example:"840D sl"`
+	Source          DetectionSourceEnum `json:"source,omitempty" example:"namespace"`
+	ProductName     string              `json:"productName,omitempty" example:"SINUMERIK 840D sl"`
+	SoftwareVersion string              `json:"softwareVersion,omitempty" example:"V4.95"`
+	DetectedAt      time.Time           `json:"detectedAt" example:"2025-08-22T12:00:00Z"`
+}
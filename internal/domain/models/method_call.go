@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	models "opc_ua_service/internal/domain/models/connection_models"
+)
+
+// MethodCallRequest - вызов метода OPC UA на объекте станка
+type MethodCallRequest struct {
+	// ObjectID - NodeID объекта, на котором вызывается метод
+	ObjectID string `json:"objectID" binding:"required" example:"ns=2;s=/Methods"`
+	// MethodID - NodeID метода, пара ObjectID и MethodID должна входить в список разрешённых для станка
+	MethodID string `json:"methodID" binding:"required" example:"ns=2;s=/Methods/Select"`
+	// Arguments - входные аргументы в порядке InputArguments метода. Значения приводятся к типам аргументов
+	Arguments []interface{} `json:"arguments" swaggertype:"array,string" example:"/_N_WKS_DIR/_N_PART1_WPD/_N_MAIN_MPF,1"`
+}
+
+// Validate проверяет NodeID объекта и метода
+func (r MethodCallRequest) Validate() error {
+	if _, err := NormalizeNodeID(r.ObjectID); err != nil {
+		return fmt.Errorf("objectID: %w", err)
+	}
+	if _, err := NormalizeNodeID(r.MethodID); err != nil {
+		return fmt.Errorf("methodID: %w", err)
+	}
+	return nil
+}
+
+// MethodArgument - выходной аргумент метода с именем и типом из OutputArguments
+type MethodArgument struct {
+	Name     string      `json:"name,omitempty" example:"Result"`
+	DataType string      `json:"dataType,omitempty" example:"Int32"`
+	Value    interface{} `json:"value"`
+}
+
+// MethodCallResponse - результат вызова метода
+type MethodCallResponse struct {
+	ObjectID   string           `json:"objectID" example:"ns=2;s=/Methods"`
+	MethodID   string           `json:"methodID" example:"ns=2;s=/Methods/Select"`
+	StatusCode string           `json:"statusCode" example:"Good"`
+	Outputs    []MethodArgument `json:"outputs"`
+}
+
+// CallableMethodsRequest - список пар объект/метод станка, которые разрешено вызывать
+type CallableMethodsRequest struct {
+	Methods []models.CallableMethod `json:"methods"`
+}
+
+// CallableMethodsResponse - текущий список разрешённых пар объект/метод
+type CallableMethodsResponse struct {
+	Methods []models.CallableMethod `json:"methods"`
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"strings"
+	"time"
+)
+
+// Ограничения разового чтения и записи узлов
+const (
+	MaxNodesPerAccessRequest = 1000
+	MaxNodesPerWriteRequest  = 100
+)
+
+// NodeRef - ссылка на узел: NodeID в строковой форме или путь просмотра от начального узла
+type NodeRef struct {
+	// NodeID - идентификатор узла любого типа: i=, s=, g=, b=
+	NodeID string `json:"nodeID,omitempty" example:"ns=2;s=/Channel/Parameter/R[1]"`
+	// BrowsePath - путь из BrowseName через "/", например 2:DeviceSet/4:Machine/4:Spindle
+	BrowsePath string `json:"browsePath,omitempty" example:"2:DeviceSet/4:Machine/4:Spindle"`
+	// StartNodeID - начальный узел пути просмотра, по умолчанию папка Objects (i=85)
+	StartNodeID string `json:"startNodeID,omitempty" example:"i=85"`
+}
+
+// Validate проверяет, что указан ровно один способ адресации узла
+func (r NodeRef) Validate() error {
+	switch {
+	case r.NodeID == "" && r.BrowsePath == "":
+		return fmt.Errorf("nodeID or browsePath is required")
+	case r.NodeID != "" && r.BrowsePath != "":
+		return fmt.Errorf("only one of nodeID and browsePath may be set")
+	case r.NodeID != "":
+		if r.StartNodeID != "" {
+			return fmt.Errorf("startNodeID is only allowed with browsePath")
+		}
+		if ua.ParseNodeID(r.NodeID) == nil {
+			return fmt.Errorf("invalid nodeID: %s", r.NodeID)
+		}
+	default:
+		if _, err := r.StartNode(); err != nil {
+			return err
+		}
+		for _, name := range r.PathElements() {
+			if name.Name == "" {
+				return fmt.Errorf("invalid browsePath: %s", r.BrowsePath)
+			}
+		}
+	}
+	return nil
+}
+
+// StartNode возвращает начальный узел пути просмотра
+func (r NodeRef) StartNode() (ua.NodeID, error) {
+	if r.StartNodeID == "" {
+		return ua.ObjectIDObjectsFolder, nil
+	}
+	nodeID := ua.ParseNodeID(r.StartNodeID)
+	if nodeID == nil {
+		return nil, fmt.Errorf("invalid startNodeID: %s", r.StartNodeID)
+	}
+	return nodeID, nil
+}
+
+// PathElements возвращает BrowseName элементов пути просмотра
+func (r NodeRef) PathElements() []ua.QualifiedName {
+	return ua.ParseBrowsePath(strings.Trim(r.BrowsePath, "/"))
+}
+
+// NormalizeNodeID приводит NodeID к канонической строковой форме для сравнения со списком разрешённых узлов
+func NormalizeNodeID(s string) (string, error) {
+	nodeID := ua.ParseNodeID(strings.TrimSpace(s))
+	if nodeID == nil {
+		return "", fmt.Errorf("invalid nodeID: %s", s)
+	}
+	return fmt.Sprint(nodeID), nil
+}
+
+// ---------------------------------------------------------------------------------------------------------------
+
+// NodeReadRequest - запрос чтения произвольных узлов станка
+type NodeReadRequest struct {
+	Nodes []NodeRef `json:"nodes" binding:"required"`
+}
+
+// Validate проверяет запрос чтения
+func (r NodeReadRequest) Validate() error {
+	if len(r.Nodes) == 0 {
+		return fmt.Errorf("nodes must not be empty")
+	}
+	if len(r.Nodes) > MaxNodesPerAccessRequest {
+		return fmt.Errorf("too many nodes: %d, maximum %d", len(r.Nodes), MaxNodesPerAccessRequest)
+	}
+	for i, ref := range r.Nodes {
+		if err := ref.Validate(); err != nil {
+			return fmt.Errorf("nodes[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// NodeValue - прочитанное значение узла с качеством
+type NodeValue struct {
+	NodeID     string      `json:"nodeID" example:"ns=2;s=/Channel/Parameter/R[1]"`
+	BrowsePath string      `json:"browsePath,omitempty" example:"2:DeviceSet/4:Machine/4:Spindle"`
+	Value      interface{} `json:"value,omitempty"`
+	// Quality - Good, Uncertain или Bad по StatusCode
+	Quality         ValueQualityEnum `json:"quality" example:"Good"`
+	StatusCode      string           `json:"statusCode" example:"Good"`
+	SourceTimestamp *time.Time       `json:"sourceTimestamp,omitempty" example:"2025-09-03T16:00:47.748Z"`
+	ServerTimestamp *time.Time       `json:"serverTimestamp,omitempty" example:"2025-09-03T16:00:47.750Z"`
+	// Error - узел не удалось найти по пути просмотра или прочитать
+	Error string `json:"error,omitempty" example:""`
+}
+
+// NodeReadResponse - результат чтения узлов в порядке запроса
+type NodeReadResponse struct {
+	Results []NodeValue `json:"results"`
+}
+
+// ---------------------------------------------------------------------------------------------------------------
+
+// NodeWriteItem - узел и новое значение. Значение приводится к типу данных узла
+type NodeWriteItem struct {
+	NodeRef
+	Value interface{} `json:"value" swaggertype:"string" example:"12.5"`
+}
+
+// NodeWriteRequest - запрос записи значений в узлы станка
+type NodeWriteRequest struct {
+	Items []NodeWriteItem `json:"items" binding:"required"`
+}
+
+// Validate проверяет запрос записи
+func (r NodeWriteRequest) Validate() error {
+	if len(r.Items) == 0 {
+		return fmt.Errorf("items must not be empty")
+	}
+	if len(r.Items) > MaxNodesPerWriteRequest {
+		return fmt.Errorf("too many items: %d, maximum %d", len(r.Items), MaxNodesPerWriteRequest)
+	}
+	for i, item := range r.Items {
+		if err := item.NodeRef.Validate(); err != nil {
+			return fmt.Errorf("items[%d]: %w", i, err)
+		}
+		if item.Value == nil {
+			return fmt.Errorf("items[%d]: value is required", i)
+		}
+	}
+	return nil
+}
+
+// NodeWriteResult - результат записи в узел
+type NodeWriteResult struct {
+	NodeID        string      `json:"nodeID" example:"ns=2;s=/Channel/Parameter/R[1]"`
+	BrowsePath    string      `json:"browsePath,omitempty" example:""`
+	PreviousValue interface{} `json:"previousValue,omitempty"`
+	Value         interface{} `json:"value,omitempty"`
+	// Allowed - узел входит в список разрешённых для записи
+	Allowed    bool   `json:"allowed" example:"true"`
+	Written    bool   `json:"written" example:"true"`
+	StatusCode string `json:"statusCode,omitempty" example:"Good"`
+	Error      string `json:"error,omitempty" example:""`
+}
+
+// NodeWriteResponse - результаты записи в порядке запроса
+type NodeWriteResponse struct {
+	Results []NodeWriteResult `json:"results"`
+}
+
+// WriteActor - инициатор записи для журнала аудита
+type WriteActor struct {
+	User     string
+	ClientIP string
+}
+
+// ---------------------------------------------------------------------------------------------------------------
+
+// WritableNodesRequest - список узлов станка, в которые разрешена запись
+type WritableNodesRequest struct {
+	Nodes []string `json:"nodes" example:"ns=2;s=/Channel/Parameter/R[1]"`
+}
+
+// WritableNodesResponse - текущий список разрешённых для записи узлов
+type WritableNodesResponse struct {
+	Nodes []string `json:"nodes" example:"ns=2;s=/Channel/Parameter/R[1]"`
+}
+
+// NodeWriteAuditRecord - запись журнала аудита записи в узел
+type NodeWriteAuditRecord struct {
+	ID            uint      `json:"id" example:"1"`
+	MachineUUID   string    `json:"machineUUID" example:"12840be9-36b2-4ecb-8243-b9d9e0952a03"`
+	NodeID        string    `json:"nodeID" example:"ns=2;s=/Channel/Parameter/R[1]"`
+	BrowsePath    string    `json:"browsePath,omitempty" example:""`
+	PreviousValue string    `json:"previousValue,omitempty" example:"10"`
+	Value         string    `json:"value" example:"12.5"`
+	Allowed       bool      `json:"allowed" example:"true"`
+	Written       bool      `json:"written" example:"true"`
+	StatusCode    string    `json:"statusCode,omitempty" example:"Good"`
+	Error         string    `json:"error,omitempty" example:""`
+	User          string    `json:"user,omitempty" example:"engineer1"`
+	ClientIP      string    `json:"clientIP" example:"10.0.0.15"`
+	CreatedAt     time.Time `json:"createdAt" example:"2025-09-03T16:00:47Z"`
+}
+
+// NodeWriteAuditResponse - журнал аудита записи, последние записи первыми
+type NodeWriteAuditResponse struct {
+	Records []NodeWriteAuditRecord `json:"records"`
+}
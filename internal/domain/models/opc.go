@@ -2,8 +2,10 @@ package models
 
 import (
 	"context"
+	"errors"
 	"github.com/awcullen/opcua/client"
 	"github.com/awcullen/opcua/ua"
+	"net"
 	models "opc_ua_service/internal/domain/models/connection_models"
 	"opc_ua_service/pkg/opc_custom"
 	"sync"
@@ -32,82 +34,53 @@ type ConnectionInfo struct {
 	CreatedAt time.Time
 	LastUsed  time.Time
 	UseCount  int64
-	IsHealthy bool
 	IsPolled  bool
+	// StateInfo - состояние жизненного цикла сессии. Меняется только через SetState под Mu
+	StateInfo ConnectionStateInfo
 	Mu        sync.RWMutex
 
 	Manufacturer string
 	Model        string
 }
 
-func (ci *ConnectionInfo) GetRelevantNodeIDs() []ua.NodeIDNumeric {
-	switch ci.Manufacturer {
-	case "ACME", "Heidenhain":
-		switch ci.Model {
-		case "TNC640":
-			// Здесь перечисляем NodeID, которые нам нужны для Heidenhain TNC640
-			return []ua.NodeIDNumeric{
-				ua.NewNodeIDNumeric(1, 56004), // SerialNumber
+// SetState переводит соединение в новое состояние. Вызывается под Mu
+func (ci *ConnectionInfo) SetState(state ConnectionStateEnum, err error) {
+	ci.StateInfo.setState(state, err, isTimeoutError(err))
+}
+
+// State возвращает текущее состояние соединения. Вызывается под Mu
+func (ci *ConnectionInfo) State() ConnectionStateEnum {
+	return ci.StateInfo.State
+}
+
+// IsHealthy проверяет, что через сессию можно выполнять запросы. Вызывается под Mu
+func (ci *ConnectionInfo) IsHealthy() bool {
+	return ci.Conn != nil && ci.StateInfo.State.IsUsable()
+}
 
-				ua.NewNodeIDNumeric(1, 100024), // OperatingMode
-				// ------------------------ TOOL ------------------------
-				ua.NewNodeIDNumeric(1, 100039), // CurrentToolName
-				ua.NewNodeIDNumeric(1, 100003), // CutterLocation
-				// ------------------------ FEED ------------------------
-				ua.NewNodeIDNumeric(1, 100025), // FeedOverride
-				ua.NewNodeIDNumeric(1, 100026), // FeedOverrideEURange
-				ua.NewNodeIDNumeric(1, 300002), // FeedOverrideEngineeringUnits
-				// ------------------------ RAPID ------------------------
-				ua.NewNodeIDNumeric(1, 100029), // RapidOverride
-				ua.NewNodeIDNumeric(1, 100030), // RapidOverrideEURange
-				ua.NewNodeIDNumeric(1, 300004), // RapidOverrideEngineeringUnits
-				ua.NewNodeIDNumeric(1, 100031), // RapidTraverseActive
-				// ------------------------ SPEED ------------------------
-				ua.NewNodeIDNumeric(1, 100027), // SpeedOverride
-				ua.NewNodeIDNumeric(1, 100028), // SpeedOverrideEURange
-				ua.NewNodeIDNumeric(1, 300003), // SpeedOverrideEngineeringUnits
-				// ------------------------ TIME ------------------------
-				ua.NewNodeIDNumeric(1, 56031), // ControlUpTime
-				ua.NewNodeIDNumeric(1, 56033), // MachineUpTime
-				ua.NewNodeIDNumeric(1, 56032), // ProgramExecutionTime
-				// ---------------  PROGRAM  -------------------
-				ua.NewNodeIDNumeric(1, 51002),  // CurrentState
-				ua.NewNodeIDNumeric(1, 100005), // CurrentCall
-				ua.NewNodeIDNumeric(1, 100006), // ExecutionStack
-				ua.NewNodeIDNumeric(1, 100022), // ActiveProgramName
-				// ----------------- EXECUTION STATE -------------------------
-				ua.NewNodeIDNumeric(1, 100010), // ExecutionStateCurrentState
-				ua.NewNodeIDNumeric(1, 100008), // ExecutionStateLastTransition
-			}
-		default:
-			return nil
-		}
+// IsReconnecting проверяет, что сессия восстанавливается в фоне. Вызывается под Mu
+func (ci *ConnectionInfo) IsReconnecting() bool {
+	switch ci.StateInfo.State {
+	case StateConnecting, StateReconnecting, StateAuthFailed:
+		return true
 	default:
-		return nil
+		return false
 	}
 }
 
-func (ci *ConnectionInfo) GetControlProgramNodes() []ua.NodeIDNumeric {
-	switch ci.Manufacturer {
-	case "ACME", "Heidenhain":
-		switch ci.Model {
-		case "TNC640":
-			// Здесь перечисляем NodeID, которые нам нужны для Heidenhain TNC640
-			return []ua.NodeIDNumeric{
-				ua.NewNodeIDNumeric(1, 56032), // ProgramExecutionTime
-				// ---------------  PROGRAM  -------------------
-				ua.NewNodeIDNumeric(1, 51002),  // CurrentState
-				ua.NewNodeIDNumeric(1, 100005), // CurrentCall
-				ua.NewNodeIDNumeric(1, 100006), // ExecutionStack
-				ua.NewNodeIDNumeric(1, 100022), // ActiveProgramName
-				// ----------------- EXECUTION STATE -------------------------
-				ua.NewNodeIDNumeric(1, 100010), // ExecutionStateCurrentState
-				ua.NewNodeIDNumeric(1, 100008), // ExecutionStateLastTransition
-			}
-		default:
-			return nil
-		}
-	default:
-		return nil
+// StateSnapshot возвращает копию состояния соединения. Вызывается под Mu
+func (ci *ConnectionInfo) StateSnapshot() ConnectionStateInfo {
+	return ci.StateInfo.copy()
+}
+
+// isTimeoutError проверяет, что ошибка вызвана таймаутом (станок выключен или недоступен по сети)
+func isTimeoutError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ua.BadTimeout) {
+		return true
 	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ProfileSourceEnum - откуда взята модель станка
+type ProfileSourceEnum string
+
+const (
+	ProfileSourcePinned   ProfileSourceEnum = "pinned"   // Закреплённая за станком версия профиля из реестра
+	ProfileSourceRegistry ProfileSourceEnum = "registry" // Последняя версия профиля из реестра
+	ProfileSourceFile     ProfileSourceEnum = "file"     // Профиль из каталога MACHINE_PROFILES_DIR
+	ProfileSourceBuiltin  ProfileSourceEnum = "builtin"  // Встроенная Go-модель
+	ProfileSourceNone     ProfileSourceEnum = "none"     // Модель станка не поддерживается
+)
+
+// profileNamePattern - допустимое имя профиля: используется в пути REST API
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// ValidateProfileName проверяет имя профиля
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use up to 64 letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+// ProfileVersionInfo - версия профиля без определения
+type ProfileVersionInfo struct {
+	Version   int       `json:"version" example:"2"`
+	Models    []string  `json:"models" example:"EX-500"`
+	Comment   string    `json:"comment,omitempty" example:"Added spindle load"`
+	CreatedBy string    `json:"createdBy,omitempty" example:"engineer1"`
+	CreatedAt time.Time `json:"createdAt" example:"2025-09-03T16:00:47Z"`
+}
+
+// ProfileSummary - профиль в списке реестра
+type ProfileSummary struct {
+	Name          string    `json:"name" example:"example-ex500"`
+	Manufacturer  string    `json:"manufacturer" example:"Example"`
+	Models        []string  `json:"models" example:"EX-500"`
+	LatestVersion int       `json:"latestVersion" example:"2"`
+	Versions      int       `json:"versions" example:"2"`
+	UpdatedAt     time.Time `json:"updatedAt" example:"2025-09-03T16:00:47Z"`
+}
+
+// ProfileListResponse - профили реестра
+type ProfileListResponse struct {
+	Profiles []ProfileSummary `json:"profiles"`
+}
+
+// ProfileResponse - версия профиля с определением
+type ProfileResponse struct {
+	Name         string `json:"name" example:"example-ex500"`
+	Manufacturer string `json:"manufacturer" example:"Example"`
+	ProfileVersionInfo
+	Definition json.RawMessage `json:"definition" swaggertype:"object"`
+	// Versions - все версии профиля, последняя первой. Заполняется при запросе профиля без версии
+	Versions []ProfileVersionInfo `json:"versions,omitempty"`
+	// PinnedMachines - станки, за которыми закреплена версия профиля
+	PinnedMachines []string `json:"pinnedMachines"`
+}
+
+// ProfileValidationResponse - результат проверки профиля без сохранения
+type ProfileValidationResponse struct {
+	Valid  bool     `json:"valid" example:"false"`
+	Errors []string `json:"errors,omitempty" example:"node 0 (SerialNumber): unknown field machine"`
+}
+
+// ProfileUploadRequest - метаданные новой версии профиля
+type ProfileUploadRequest struct {
+	Definition  []byte
+	ContentType string // application/json или application/yaml
+	Comment     string
+	CreatedBy   string
+}
+
+// ProfilePinRequest - закрепление версии профиля за станком
+type ProfilePinRequest struct {
+	Name    string `json:"name" binding:"required" example:"example-ex500"`
+	Version int    `json:"version" binding:"required,min=1" example:"2"`
+}
+
+// MachineProfileResponse - модель, по которой собираются данные станка
+type MachineProfileResponse struct {
+	MachineUUID    string            `json:"machineUUID" example:"12840be9-36b2-4ecb-8243-b9d9e0952a03"`
+	Manufacturer   string            `json:"manufacturer" example:"Example"`
+	Model          string            `json:"model" example:"EX-500"`
+	Source         ProfileSourceEnum `json:"source" example:"pinned"`
+	ProfileName    string            `json:"profileName,omitempty" example:"example-ex500"`
+	ProfileVersion int               `json:"profileVersion,omitempty" example:"2"`
+	ProfileFile    string            `json:"profileFile,omitempty" example:""`
+	// Detection - модель, определённая по сведениям сервера при подключении
+	Detection *MachineDetectionResponse `json:"detection,omitempty"`
+}
@@ -7,5 +7,6 @@ import (
 // KafkaService определяет контракт для отправки данных во внешние системы
 type KafkaService interface {
 	Produce(ctx context.Context, key, value []byte) error
+	ProduceAlarms(ctx context.Context, key []byte, values [][]byte) error
 	Close() error
 }
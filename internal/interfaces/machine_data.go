@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
 	"opc_ua_service/internal/domain/models"
 	"opc_ua_service/pkg/machine_models"
 	"opc_ua_service/pkg/opc_custom"
@@ -10,16 +11,62 @@ import (
 // MachineData — общий интерфейс для всех моделей станков
 type MachineData interface {
 	ConvertNodeToMachineData(nodeID string, v any) error
+	RecordSample(nodeID string, value ua.DataValue)
 	GetExecutionStack() ([]opc_custom.ProgramPositionDataType, error)
 	ToJSON() string
 	ToResponse() models.MachineDataResponse
-	GetRelevantNodeIDs() []ua.NodeIDNumeric
+	// GetRelevantNodeIDs возвращает узлы модели по URI пространства имён. Ключ узла в ConvertNodeToMachineData
+	// и RecordSample - строка ExpandedNodeID (nsu=<URI>;<идентификатор>), не зависящая от индексов сервера
+	GetRelevantNodeIDs() []ua.ExpandedNodeID
 	GetMachineID() (*string, error)
 }
 
-func MachineDataFactory(manufacturer, model string) MachineData {
-	switch manufacturer {
-	case "ACME", "Heidenhain":
+// DiscoverableMachineData - модель станка, узлы которой находятся обходом адресного пространства сервера,
+// а не задаются заранее. Найденные узлы кэшируются на сессию и передаются каждому новому объекту модели
+type DiscoverableMachineData interface {
+	MachineData
+	DiscoverNodes(browser machine_models.NodeBrowser) (*machine_models.DiscoveredNodes, error)
+	UseDiscoveredNodes(nodes *machine_models.DiscoveredNodes)
+}
+
+// ExecutionStackMachineData - модель станка, публикующая стек выполнения управляющей программы отдельным узлом
+type ExecutionStackMachineData interface {
+	MachineData
+	ExecutionStackNode() ua.ExpandedNodeID
+}
+
+// MachineDataConstructor создаёт пустую модель станка выбранного типа
+type MachineDataConstructor func() MachineData
+
+// ResolveMachineData выбирает модель станка: закреплённая за станком версия профиля, последняя версия профиля
+// из реестра, профиль из каталога, встроенная Go-модель. Профили имеют приоритет над встроенными моделями,
+// поэтому их поведение можно переопределить без пересборки. Возвращает nil для неизвестного станка
+func ResolveMachineData(id uuid.UUID, manufacturer, model string) MachineDataConstructor {
+	if profile, ok := machine_models.FindMachineProfileFor(id.String(), manufacturer, model); ok {
+		return func() MachineData { return machine_models.NewProfileMachineData(profile) }
+	}
+	if builtinMachineData(manufacturer, model) == nil {
+		return nil
+	}
+	return func() MachineData { return builtinMachineData(manufacturer, model) }
+}
+
+// MachineDataFactory возвращает модель станка по текущему состоянию реестра профилей
+func MachineDataFactory(id uuid.UUID, manufacturer, model string) MachineData {
+	if newMachine := ResolveMachineData(id, manufacturer, model); newMachine != nil {
+		return newMachine()
+	}
+	return nil
+}
+
+// builtinMachineData возвращает встроенную Go-модель станка
+func builtinMachineData(manufacturer, model string) MachineData {
+	// Станки OPC 40501 описываются стандартной моделью независимо от производителя
+	if model == machine_models.MachineToolModel {
+		return machine_models.NewMachineToolData()
+	}
+	switch machine_models.CanonicalManufacturer(manufacturer) {
+	case "Heidenhain":
 		switch model {
 		case "TNC640":
 			return &machine_models.HeidenhainTNC640Data{}
@@ -28,7 +75,19 @@ func MachineDataFactory(manufacturer, model string) MachineData {
 		default:
 			return nil // неизвестная модель для этого производителя
 		}
+	case "Siemens":
+		switch model {
+		case "840D sl", "828D":
+			return machine_models.NewSinumerikData()
+		default:
+			return nil
+		}
 	default:
 		return nil // неизвестный производитель
 	}
 }
+
+// IsBuiltinMachineModel сообщает, есть ли для станка встроенная Go-модель
+func IsBuiltinMachineModel(manufacturer, model string) bool {
+	return builtinMachineData(manufacturer, model) != nil
+}
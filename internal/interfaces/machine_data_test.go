@@ -0,0 +1,42 @@
+package interfaces
+
+import (
+	"opc_ua_service/pkg/machine_models"
+	"testing"
+)
+
+func TestExecutionStackMachineData(t *testing.T) {
+	tests := []struct {
+		name         string
+		manufacturer string
+		model        string
+		supported    bool
+	}{
+		{name: "Heidenhain TNC640", manufacturer: "Heidenhain", model: "TNC640", supported: true},
+		{name: "псевдоним ACME", manufacturer: "ACME", model: "TNC620", supported: true},
+		{name: "SINUMERIK без стека выполнения", manufacturer: "Siemens", model: "840D sl"},
+		{name: "станок OPC 40501", manufacturer: "DMG MORI", model: machine_models.MachineToolModel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := builtinMachineData(tt.manufacturer, tt.model)
+			if machine == nil {
+				t.Fatalf("нет модели для %s %s", tt.manufacturer, tt.model)
+			}
+			stackMachine, ok := machine.(ExecutionStackMachineData)
+			if ok != tt.supported {
+				t.Fatalf("поддержка стека выполнения = %v, ожидалось %v", ok, tt.supported)
+			}
+			if !ok {
+				return
+			}
+			if got, want := stackMachine.ExecutionStackNode(), machine_models.HeidenhainNode(100006); got != want {
+				t.Fatalf("ExecutionStackNode() = %s, ожидалось %s", got, want)
+			}
+			// Пустой стек без прочитанного значения не должен приводить к панике
+			if stack, err := machine.GetExecutionStack(); err != nil || stack != nil {
+				t.Fatalf("GetExecutionStack() = %v, %v", stack, err)
+			}
+		})
+	}
+}
@@ -9,6 +9,8 @@ type Repository interface {
 	PasswordConnectionRepository
 	AnonymousConnectionRepository
 	CertificateConnectionRepository
+	NodeWriteAuditRepository
+	MachineProfileRepository
 }
 
 type CncMachineRepository interface {
@@ -41,3 +43,16 @@ type CertificateConnectionRepository interface {
 	UpdateCertificateConnection(id uint, updateMap map[string]interface{}) (uint, error)
 	DeleteCertificateConnection(id uint) error
 }
+
+type NodeWriteAuditRepository interface {
+	CreateNodeWriteAudit(record entities.NodeWriteAudit) (uint, error)
+	GetNodeWriteAudits(machineUUID string, limit int) ([]entities.NodeWriteAudit, error)
+}
+
+type MachineProfileRepository interface {
+	CreateMachineProfileVersion(profile entities.MachineProfile) (entities.MachineProfile, error)
+	GetMachineProfileVersion(name string, version int) (entities.MachineProfile, error)
+	GetMachineProfileVersions(name string) ([]entities.MachineProfile, error)
+	GetAllMachineProfiles() ([]entities.MachineProfile, error)
+	DeleteMachineProfile(name string, version int) error
+}
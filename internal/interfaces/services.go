@@ -2,7 +2,7 @@ package interfaces
 
 import (
 	"context"
-	"crypto/rsa"
+	"crypto"
 	"crypto/x509"
 	"github.com/awcullen/opcua/client"
 	"github.com/awcullen/opcua/ua"
@@ -20,15 +20,30 @@ type OpcService interface {
 }
 
 type CertificateManagerService interface {
-	SelectCertificateEndpoint(ctx context.Context, endpointURL string) (*ua.EndpointDescription, string, error)
+	DescribeEndpoints(ctx context.Context, endpointURL string) ([]models.EndpointDescriptionResponse, error)
+	SelectEndpoint(ctx context.Context, endpointURL string, req models.EndpointRequirements) (*ua.EndpointDescription, string, error)
+	SelectCertificateEndpoint(ctx context.Context, endpointURL string, policy models.SecurityPolicyEnum, mode models.MessageSecurityModeEnum) (*ua.EndpointDescription, string, error)
 	PrintCertInfo(label string, cert *x509.Certificate)
-	VerifyKeyMatchesCert(cert *x509.Certificate, key *rsa.PrivateKey) error
+	VerifyKeyMatchesCert(cert *x509.Certificate, key crypto.Signer) error
 
-	DecodeClientCredentials(certBytes, keyBytes []byte) ([]byte, *x509.Certificate, *rsa.PrivateKey)
-	DecodePrivateKey(data []byte) (*rsa.PrivateKey, error)
+	DecodeClientCredentials(certBytes, keyBytes []byte) ([]byte, *x509.Certificate, crypto.Signer)
+	DecodePrivateKey(data []byte) (crypto.Signer, error)
 	DecodeCertificate(data []byte) (*x509.Certificate, error)
 
-	BuildClientOptions(endpoint *ua.EndpointDescription, policyID string, certBytes []byte, key *rsa.PrivateKey) []client.Option
+	BuildClientOptions(endpoint *ua.EndpointDescription, policyID string, certBytes []byte, key crypto.Signer) ([]client.Option, error)
+	SecureChannelOptions(endpoint *ua.EndpointDescription) ([]client.Option, error)
+	HasApplicationCertificate() bool
+
+	ApplicationCertificate() ([]byte, crypto.Signer, error)
+	ApplicationCertificateInfo() (*models.ApplicationCertificateInfo, error)
+	RenewApplicationCertificate() (*models.ApplicationCertificateInfo, error)
+	ApplicationName() string
+
+	ServerTrustOptions() []client.Option
+	PrepareServerTrust(endpoint *ua.EndpointDescription, trustOnFirstUse bool) error
+	ListServerCertificates(store models.CertificateStoreEnum) ([]models.ServerCertificateInfo, error)
+	TrustServerCertificate(thumbprint string) (*models.ServerCertificateInfo, error)
+	RevokeServerCertificate(thumbprint string) (*models.ServerCertificateInfo, error)
 }
 
 type OpcConnectorService interface {
@@ -36,9 +51,14 @@ type OpcConnectorService interface {
 	CreateAnonymousConnection(config connection_models.AnonymousConnection) (*uuid.UUID, error)
 	CreatePasswordConnection(config connection_models.PasswordConnection) (*uuid.UUID, error)
 	CreateCertificateConnection(config connection_models.CertificateConnection) (*uuid.UUID, error)
+	RestoreConnection(id uuid.UUID, config connection_models.ConnectionConfigImpl) (*models.ConnectionInfo, error)
 	CloseAll()
 	GetConnectionByUUID(id uuid.UUID) (*client.Client, error)
 	GetConnectionInfoByUUID(id uuid.UUID) (*models.ConnectionInfo, error)
+	FindConnectionInfo(id uuid.UUID) (*models.ConnectionInfo, error)
+	UpdateConnectionConfig(id uuid.UUID, config connection_models.ConnectionConfigImpl) error
+	ReportReadResult(id uuid.UUID, err error)
+	SetMachineModel(id uuid.UUID, manufacturer, model string) error
 	//GetControlProgramInfo(sessionID string) ([]opc_custom.ProgramPositionDataType, error)
 
 	Cleanup(maxIdleTime time.Duration) int
@@ -54,7 +74,16 @@ type OpcConnectorService interface {
 type OpcCommunicatorService interface {
 	CallOPCMethod(ctx context.Context, c *client.Client, objectNodeID, methodNodeID ua.NodeID, inputArgs ...ua.Variant) ([]ua.Variant, error)
 	ReadMachineData(id uuid.UUID) (MachineData, error)
+	IdentifyServer(id uuid.UUID) (*models.ServerIdentity, error)
 	GetControlProgramInfo(id uuid.UUID) ([]opc_custom.ProgramPositionDataType, error)
 	StartPollingForMachine(id uuid.UUID) error
+	StartSubscriptionForMachine(id uuid.UUID, settings connection_models.SubscriptionSettings) error
+	Browse(id uuid.UUID, req models.BrowseRequest) (*models.BrowseResponse, error)
+	ReadNodes(id uuid.UUID, refs []models.NodeRef) ([]models.NodeValue, error)
+	WriteNodes(id uuid.UUID, items []models.NodeWriteItem, allowed func(nodeID string) bool) ([]models.NodeWriteResult, error)
+	CallMethod(id uuid.UUID, req models.MethodCallRequest) (*models.MethodCallResponse, error)
+	ReadHistory(id uuid.UUID, req models.HistoryReadRequest) (*models.HistoryReadResponse, error)
+	StartBackfill(id uuid.UUID, req models.BackfillRequest) (*models.BackfillJob, error)
+	GetBackfillJob(id uuid.UUID) (*models.BackfillJob, error)
 	StopPollingForMachine(id uuid.UUID) error
 }
@@ -11,6 +11,10 @@ import (
 type Usecases interface {
 	ConnectionUsecase
 	PollingUsecase
+	CertificateUsecase
+	NodeUsecase
+	HistoryUsecase
+	ProfileUsecase
 }
 
 type ConnectionUsecase interface {
@@ -28,11 +32,61 @@ type ConnectionUsecase interface {
 	GetConnectionState(id uuid.UUID) (*models.ConnectionInfoResponse, *errors.AppError)
 	CleanupIdleConnections(maxIdleMinutes int) int
 	RestoreConnection(machine entities.CncMachine) (*models.ConnectionInfo, *errors.AppError)
+
+	DiscoverEndpoints(request models.EndpointsRequest) (models.EndpointsResponse, *errors.AppError)
+	RotateCertificate(request models.RotateCertificateRequest) (*models.CertificateExpiryInfo, *errors.AppError)
 }
 
 type PollingUsecase interface {
 	GetControlProgram(req models.GetControlProgramRequest) (*models.ControlProgramInfoRequest, error)
 
-	StartPollingMachine(machineID uuid.UUID) *errors.AppError
+	StartPollingMachine(request models.StartPollingRequest) *errors.AppError
 	StopPollingMachine(machineID uuid.UUID) *errors.AppError
 }
+
+type CertificateUsecase interface {
+	ListServerCertificates(store models.CertificateStoreEnum) (models.ServerCertificateListResponse, *errors.AppError)
+	TrustServerCertificate(thumbprint string) (*models.ServerCertificateInfo, *errors.AppError)
+	RevokeServerCertificate(thumbprint string) (*models.ServerCertificateInfo, *errors.AppError)
+
+	GetApplicationCertificate() (*models.ApplicationCertificateInfo, *errors.AppError)
+	DownloadApplicationCertificate(format string) ([]byte, *errors.AppError)
+	RenewApplicationCertificate() (*models.ApplicationCertificateInfo, *errors.AppError)
+
+	CheckCertificateExpiry() (models.CertificateExpiryResponse, *errors.AppError)
+}
+
+type NodeUsecase interface {
+	BrowseNodes(id uuid.UUID, req models.BrowseRequest) (*models.BrowseResponse, *errors.AppError)
+	ReadNodes(id uuid.UUID, req models.NodeReadRequest) (*models.NodeReadResponse, *errors.AppError)
+	WriteNodes(id uuid.UUID, req models.NodeWriteRequest, actor models.WriteActor) (*models.NodeWriteResponse, *errors.AppError)
+
+	GetWritableNodes(id uuid.UUID) (*models.WritableNodesResponse, *errors.AppError)
+	SetWritableNodes(id uuid.UUID, req models.WritableNodesRequest) (*models.WritableNodesResponse, *errors.AppError)
+	GetWriteAudit(id uuid.UUID, limit int) (*models.NodeWriteAuditResponse, *errors.AppError)
+
+	CallMethod(id uuid.UUID, req models.MethodCallRequest) (*models.MethodCallResponse, *errors.AppError)
+	GetCallableMethods(id uuid.UUID) (*models.CallableMethodsResponse, *errors.AppError)
+	SetCallableMethods(id uuid.UUID, req models.CallableMethodsRequest) (*models.CallableMethodsResponse, *errors.AppError)
+}
+
+type HistoryUsecase interface {
+	ReadHistory(id uuid.UUID, req models.HistoryReadRequest) (*models.HistoryReadResponse, *errors.AppError)
+	StartBackfill(id uuid.UUID, req models.BackfillRequest) (*models.BackfillJob, *errors.AppError)
+	GetBackfillJob(id uuid.UUID) (*models.BackfillJob, *errors.AppError)
+}
+
+type ProfileUsecase interface {
+	ListProfiles() (models.ProfileListResponse, *errors.AppError)
+	GetProfile(name string) (*models.ProfileResponse, *errors.AppError)
+	GetProfileVersion(name string, version int) (*models.ProfileResponse, *errors.AppError)
+	ValidateProfile(req models.ProfileUploadRequest) models.ProfileValidationResponse
+	CreateProfileVersion(req models.ProfileUploadRequest) (*models.ProfileResponse, *errors.AppError)
+	DeleteProfile(name string, version int) *errors.AppError
+
+	GetMachineProfile(id uuid.UUID) (*models.MachineProfileResponse, *errors.AppError)
+	PinMachineProfile(id uuid.UUID, req models.ProfilePinRequest) (*models.MachineProfileResponse, *errors.AppError)
+	UnpinMachineProfile(id uuid.UUID) (*models.MachineProfileResponse, *errors.AppError)
+
+	LoadStoredProfiles() (int, *errors.AppError)
+}
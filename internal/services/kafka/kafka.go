@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"context"
+	"errors"
 
 	"opc_ua_service/internal/config"
 	"opc_ua_service/internal/interfaces"
@@ -10,7 +11,8 @@ import (
 )
 
 type KafkaProducer struct {
-	writer *kafka.Writer
+	writer      *kafka.Writer
+	alarmWriter *kafka.Writer
 }
 
 // NewKafkaProducer создает новый экземпляр продюсера Kafka
@@ -20,7 +22,12 @@ func NewKafkaProducer(cfg *config.AppConfig) (interfaces.KafkaService, error) {
 		Topic:    cfg.Kafka.KafkaTopic,
 		Balancer: &kafka.LeastBytes{},
 	}
-	return &KafkaProducer{writer: writer}, nil
+	alarmWriter := &kafka.Writer{
+		Addr:     kafka.TCP(cfg.Kafka.KafkaBrokers...),
+		Topic:    cfg.Kafka.AlarmTopic,
+		Balancer: &kafka.LeastBytes{},
+	}
+	return &KafkaProducer{writer: writer, alarmWriter: alarmWriter}, nil
 }
 
 // Produce отправляет сообщение в Kafka
@@ -33,7 +40,19 @@ func (p *KafkaProducer) Produce(ctx context.Context, key, value []byte) error {
 	)
 }
 
+// ProduceAlarms отправляет события тревог в топик тревог одним запросом
+func (p *KafkaProducer) ProduceAlarms(ctx context.Context, key []byte, values [][]byte) error {
+	messages := make([]kafka.Message, len(values))
+	for i, value := range values {
+		messages[i] = kafka.Message{
+			Key:   key,
+			Value: value,
+		}
+	}
+	return p.alarmWriter.WriteMessages(ctx, messages...)
+}
+
 // Close закрывает соединение с Kafka
 func (p *KafkaProducer) Close() error {
-	return p.writer.Close()
+	return errors.Join(p.writer.Close(), p.alarmWriter.Close())
 }
@@ -0,0 +1,265 @@
+package cert_manager
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"opc_ua_service/internal/config"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// appKeySize - размер RSA ключа сертификата приложения (требование Basic256Sha256: 2048..4096)
+const appKeySize = 2048
+
+// ApplicationIdentity - параметры сертификата экземпляра клиентского приложения
+type ApplicationIdentity struct {
+	ApplicationName string
+	ApplicationURI  string
+	ValidityDays    int
+}
+
+func newApplicationIdentity(cfg config.PKIConfig) ApplicationIdentity {
+	identity := ApplicationIdentity{
+		ApplicationName: cfg.ApplicationName,
+		ApplicationURI:  cfg.ApplicationURI,
+		ValidityDays:    cfg.CertificateValidityDays,
+	}
+	if identity.ApplicationName == "" {
+		identity.ApplicationName = "OpcUaService"
+	}
+	if identity.ApplicationURI == "" {
+		hostname, _ := os.Hostname()
+		identity.ApplicationURI = fmt.Sprintf("urn:%s:%s", hostname, identity.ApplicationName)
+	}
+	if identity.ValidityDays <= 0 {
+		identity.ValidityDays = 730
+	}
+	return identity
+}
+
+func (cm *CertificateManager) appCertPath() string {
+	return filepath.Join(cm.pkiDir, "own", "certs", "client.der")
+}
+
+func (cm *CertificateManager) appKeyPath() string {
+	return filepath.Join(cm.pkiDir, "own", "private", "client.pem")
+}
+
+// ensureApplicationCertificate создает сертификат приложения, если его ещё нет в хранилище
+func (cm *CertificateManager) ensureApplicationCertificate() error {
+	cm.appMu.Lock()
+	defer cm.appMu.Unlock()
+
+	cert, key, err := cm.loadApplicationCertificate()
+	switch {
+	case err == nil:
+		cm.appCert, cm.appKey = cert, key
+		return nil
+	case os.IsNotExist(err):
+		cm.logger.Info("Application certificate not found, generating a new one", "applicationURI", cm.identity.ApplicationURI)
+	case errors.Is(err, errors.ErrCertificateKeyMismatch):
+		// Выпуск сертификата был прерван между заменой ключа и сертификата
+		cm.logger.Warn("Application certificate does not match its private key, generating a new one", "applicationURI", cm.identity.ApplicationURI)
+	default:
+		return err
+	}
+	return cm.generateApplicationCertificate()
+}
+
+// ApplicationName возвращает имя клиентского приложения, указываемое при создании сессии
+func (cm *CertificateManager) ApplicationName() string {
+	return cm.identity.ApplicationName
+}
+
+// ApplicationCertificate возвращает сертификат приложения (DER) и его приватный ключ без чтения с диска
+func (cm *CertificateManager) ApplicationCertificate() ([]byte, crypto.Signer, error) {
+	cert, key, err := cm.applicationCertificate()
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert.Raw, key, nil
+}
+
+// ApplicationCertificateInfo возвращает сведения о сертификате приложения
+func (cm *CertificateManager) ApplicationCertificateInfo() (*models.ApplicationCertificateInfo, error) {
+	cert, _, err := cm.applicationCertificate()
+	if err != nil {
+		return nil, err
+	}
+	return applicationCertificateInfo(cert), nil
+}
+
+// applicationCertificate возвращает разобранный сертификат приложения. С диска он читается, только если
+// не был загружен при запуске
+func (cm *CertificateManager) applicationCertificate() (*x509.Certificate, crypto.Signer, error) {
+	cm.appMu.RLock()
+	cert, key := cm.appCert, cm.appKey
+	cm.appMu.RUnlock()
+	if cert != nil {
+		return cert, key, nil
+	}
+
+	cm.appMu.Lock()
+	defer cm.appMu.Unlock()
+	if cm.appCert == nil {
+		cert, key, err := cm.loadApplicationCertificate()
+		if err != nil {
+			return nil, nil, fmt.Errorf("application certificate is not available: %w", err)
+		}
+		cm.appCert, cm.appKey = cert, key
+	}
+	return cm.appCert, cm.appKey, nil
+}
+
+// RenewApplicationCertificate перевыпускает сертификат приложения с новым ключом.
+// Уже открытые сессии продолжают работать, новые подключения используют новый сертификат
+func (cm *CertificateManager) RenewApplicationCertificate() (*models.ApplicationCertificateInfo, error) {
+	cm.appMu.Lock()
+	defer cm.appMu.Unlock()
+
+	if err := cm.generateApplicationCertificate(); err != nil {
+		return nil, err
+	}
+	info := applicationCertificateInfo(cm.appCert)
+	cm.logger.Info("Application certificate renewed", "thumbprint", info.Thumbprint, "notAfter", info.NotAfter)
+	return info, nil
+}
+
+// loadApplicationCertificate читает сертификат и ключ приложения с диска. Вызывается под appMu
+func (cm *CertificateManager) loadApplicationCertificate() (*x509.Certificate, crypto.Signer, error) {
+	certData, err := os.ReadFile(cm.appCertPath())
+	if err != nil {
+		return nil, nil, err
+	}
+	keyData, err := os.ReadFile(cm.appKeyPath())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := cm.DecodeCertificate(certData)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := cm.DecodePrivateKey(keyData)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cm.VerifyKeyMatchesCert(cert, key); err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// generateApplicationCertificate выпускает самоподписанный сертификат экземпляра приложения
+// с ApplicationURI в SubjectAltName, как того требует OPC UA Part 6, и делает его текущим. Вызывается под appMu
+func (cm *CertificateManager) generateApplicationCertificate() error {
+	appURI, err := url.Parse(cm.identity.ApplicationURI)
+	if err != nil {
+		return fmt.Errorf("invalid application URI %q: %w", cm.identity.ApplicationURI, err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, appKeySize)
+	if err != nil {
+		return fmt.Errorf("failed to generate RSA key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	notBefore := time.Now().Add(-time.Hour)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   cm.identity.ApplicationName,
+			Organization: []string{cm.identity.ApplicationName},
+		},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.AddDate(0, 0, cm.identity.ValidityDays),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment | x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		URIs:                  []*url.URL{appURI},
+	}
+	if hostname != "" {
+		template.DNSNames = []string{hostname}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	for _, dir := range []string{filepath.Dir(cm.appCertPath()), filepath.Dir(cm.appKeyPath())} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+	// Оба файла сначала пишутся во временные, сертификат заменяется последним: прерванная запись оставляет
+	// прежнюю пару или новый ключ со старым сертификатом, который обнаружится при загрузке
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	keyTmp, err := writeTempFile(cm.appKeyPath(), keyPEM, 0o600)
+	if err != nil {
+		return err
+	}
+	certTmp, err := writeTempFile(cm.appCertPath(), der, 0o644)
+	if err != nil {
+		os.Remove(keyTmp)
+		return err
+	}
+	if err := replaceFile(keyTmp, cm.appKeyPath()); err != nil {
+		os.Remove(certTmp)
+		return err
+	}
+	if err := replaceFile(certTmp, cm.appCertPath()); err != nil {
+		return err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("failed to parse generated certificate: %w", err)
+	}
+	cm.appCert, cm.appKey = cert, key
+	return nil
+}
+
+// writeTempFile записывает данные во временный файл рядом с path и возвращает его путь
+func writeTempFile(path string, data []byte, perm os.FileMode) (string, error) {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	return tmp, nil
+}
+
+// replaceFile заменяет path временным файлом, чтобы читатели не увидели частичную запись
+func replaceFile(tmp, path string) error {
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+func applicationCertificateInfo(cert *x509.Certificate) *models.ApplicationCertificateInfo {
+	return &models.ApplicationCertificateInfo{
+		Thumbprint:     Thumbprint(cert),
+		Subject:        cert.Subject.String(),
+		ApplicationURI: applicationURI(cert),
+		NotBefore:      cert.NotBefore,
+		NotAfter:       cert.NotAfter,
+	}
+}
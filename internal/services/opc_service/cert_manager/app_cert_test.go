@@ -0,0 +1,61 @@
+package cert_manager
+
+import (
+	"bytes"
+	"opc_ua_service/internal/config"
+	"opc_ua_service/internal/middleware/logging"
+	"os"
+	"testing"
+)
+
+func TestApplicationCertificateCached(t *testing.T) {
+	logger := logging.NewLogger(&logging.Config{Level: "ERROR"}, "", "test")
+	cm := NewCertificateManager(logger, config.PKIConfig{Dir: t.TempDir(), ApplicationURI: "urn:test:OpcUaService"}).(*CertificateManager)
+
+	cert, key, err := cm.ApplicationCertificate()
+	if err != nil {
+		t.Fatalf("ApplicationCertificate() error = %v", err)
+	}
+
+	// Подключения не читают сертификат с диска: он разобран при запуске
+	if err := os.Remove(cm.appCertPath()); err != nil {
+		t.Fatal(err)
+	}
+	cached, cachedKey, err := cm.ApplicationCertificate()
+	if err != nil {
+		t.Fatalf("ApplicationCertificate() без файла error = %v", err)
+	}
+	if !bytes.Equal(cached, cert) || cachedKey != key {
+		t.Fatal("ApplicationCertificate() вернул другой сертификат")
+	}
+
+	// Перевыпуск сразу заменяет разобранный сертификат
+	if _, err := cm.RenewApplicationCertificate(); err != nil {
+		t.Fatalf("RenewApplicationCertificate() error = %v", err)
+	}
+	renewed, renewedKey, err := cm.ApplicationCertificate()
+	if err != nil {
+		t.Fatalf("ApplicationCertificate() после перевыпуска error = %v", err)
+	}
+	if bytes.Equal(renewed, cert) || renewedKey == key {
+		t.Fatal("после перевыпуска возвращается прежний сертификат")
+	}
+	onDisk, err := os.ReadFile(cm.appCertPath())
+	if err != nil || !bytes.Equal(onDisk, renewed) {
+		t.Fatalf("сертификат на диске не совпадает с текущим: %v", err)
+	}
+}
+
+func TestApplicationCertificateUnavailable(t *testing.T) {
+	logger := logging.NewLogger(&logging.Config{Level: "ERROR"}, "", "test")
+	// Каталог PKI - файл: сертификат нельзя ни создать, ни прочитать
+	dir := t.TempDir() + "/pki"
+	if err := os.WriteFile(dir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cm := NewCertificateManager(logger, config.PKIConfig{Dir: dir}).(*CertificateManager)
+
+	if _, _, err := cm.ApplicationCertificate(); err == nil {
+		t.Fatal("ожидалась ошибка без сертификата приложения")
+	}
+}
@@ -1,26 +1,43 @@
 package cert_manager
 
 import (
-	"context"
-	"crypto/rsa"
+	"crypto"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"github.com/awcullen/opcua/client"
 	"github.com/awcullen/opcua/ua"
+	"opc_ua_service/internal/config"
 	"opc_ua_service/internal/interfaces"
 	"opc_ua_service/internal/middleware/logging"
+	"sync"
 	"time"
 )
 
 type CertificateManager struct {
-	logger *logging.Logger
+	logger   *logging.Logger
+	pkiDir   string
+	pkiMu    sync.Mutex
+	identity ApplicationIdentity
+	appMu    sync.RWMutex
+	// appCert и appKey - разобранный сертификат приложения, загружается при запуске и перевыпуске. Защищены appMu
+	appCert *x509.Certificate
+	appKey  crypto.Signer
 }
 
-func NewCertificateManager(logger *logging.Logger) interfaces.CertificateManagerService {
-	return &CertificateManager{
-		logger: logger.WithPrefix("CERT_MANAGER"),
+func NewCertificateManager(logger *logging.Logger, cfg config.PKIConfig) interfaces.CertificateManagerService {
+	cm := &CertificateManager{
+		logger:   logger.WithPrefix("CERT_MANAGER"),
+		pkiDir:   cfg.Dir,
+		identity: newApplicationIdentity(cfg),
 	}
+	if err := cm.ensurePKIDirs(); err != nil {
+		cm.logger.Error("Failed to prepare PKI directories", "dir", cfg.Dir, "error", err)
+	}
+	if err := cm.ensureApplicationCertificate(); err != nil {
+		cm.logger.Error("Failed to prepare application certificate", "error", err)
+	}
+	return cm
 }
 
 // DecodeCertificate принимает сертификат в виде байтов (DER или PEM)
@@ -43,53 +60,6 @@ func (cm *CertificateManager) DecodeCertificate(data []byte) (*x509.Certificate,
 	return cert, nil
 }
 
-func (cm *CertificateManager) printEndpoints(resp []ua.EndpointDescription, selected *ua.EndpointDescription, policyID string) {
-	cm.logger.Info("🔍 Available Endpoints:")
-
-	for i, ep := range resp {
-		cm.logger.Info("[%d] EndpointURL: %s", i, ep.EndpointURL)
-		cm.logger.Info("    SecurityPolicy: %s", ep.SecurityPolicyURI)
-		cm.logger.Info("    SecurityMode: %s", ep.SecurityMode)
-		cm.logger.Info("    UserIdentityTokens:")
-		for _, token := range ep.UserIdentityTokens {
-			cm.logger.Info("      - Type: %s, PolicyID: %s, SecurityPolicy: %s",
-				token.TokenType, token.PolicyID, token.SecurityPolicyURI)
-		}
-	}
-
-	if selected != nil {
-		cm.logger.Info("✅ Selected endpoint: %s", selected.EndpointURL)
-		cm.logger.Info("   Security: %s + %s", selected.SecurityPolicyURI, selected.SecurityMode)
-		cm.logger.Info("   Selected PolicyID: %s", policyID)
-	} else {
-		cm.logger.Info("✖ No endpoint supports certificate-based user authentication")
-	}
-}
-
-func (cm *CertificateManager) SelectCertificateEndpoint(ctx context.Context, endpointURL string) (*ua.EndpointDescription, string, error) {
-	resp, err := client.GetEndpoints(ctx, &ua.GetEndpointsRequest{
-		EndpointURL: endpointURL,
-	})
-	if err != nil {
-		return nil, "", err
-	}
-
-	var selected *ua.EndpointDescription
-	var policyID string
-
-	for _, ep := range resp.Endpoints {
-		for _, token := range ep.UserIdentityTokens {
-			if token.TokenType == ua.UserTokenTypeCertificate && selected == nil {
-				selected = &ep
-				policyID = token.PolicyID
-			}
-		}
-	}
-
-	//cm.printEndpoints(resp.Endpoints, selected, policyID)
-	return selected, policyID, nil
-}
-
 func (cm *CertificateManager) PrintCertInfo(label string, cert *x509.Certificate) {
 	cm.logger.Info("%s Cert: CN=%s, Valid: %s → %s\n",
 		label,
@@ -99,7 +69,7 @@ func (cm *CertificateManager) PrintCertInfo(label string, cert *x509.Certificate
 	)
 }
 
-func (cm *CertificateManager) DecodeClientCredentials(certBytes, keyBytes []byte) ([]byte, *x509.Certificate, *rsa.PrivateKey) {
+func (cm *CertificateManager) DecodeClientCredentials(certBytes, keyBytes []byte) ([]byte, *x509.Certificate, crypto.Signer) {
 	// Парсим сертификат
 	cert, err := cm.DecodeCertificate(certBytes)
 	if err != nil {
@@ -117,13 +87,44 @@ func (cm *CertificateManager) DecodeClientCredentials(certBytes, keyBytes []byte
 	return certBytes, cert, key
 }
 
-// TODO: Проверить потом без WithInsecureSkipVerify
-func (cm *CertificateManager) BuildClientOptions(endpoint *ua.EndpointDescription, policyID string, certBytes []byte, key *rsa.PrivateKey) []client.Option {
-	return []client.Option{
-		client.WithClientCertificate(certBytes, key),
-		client.WithX509Identity(certBytes, key),
-		//client.WithTrustedCertificatesFile("certs/new_server/heopcua-rootca_khrllw--340595_2025aug06_132042.der"),
+// BuildClientOptions собирает опции клиента для подключения по сертификату.
+// Сертификат сервера проверяется по хранилищу PKI
+func (cm *CertificateManager) BuildClientOptions(endpoint *ua.EndpointDescription, policyID string, certBytes []byte, key crypto.Signer) ([]client.Option, error) {
+	// Стек принимает только RSA ключи и не реализует ECC политики
+	rsaPrivateKey, err := rsaKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []client.Option{
+		client.WithClientCertificate(certBytes, rsaPrivateKey),
+		client.WithX509Identity(certBytes, rsaPrivateKey),
 		client.WithSecurityPolicyURI(endpoint.SecurityPolicyURI, endpoint.SecurityMode),
-		client.WithInsecureSkipVerify(),
 	}
+	return append(opts, cm.ServerTrustOptions()...), nil
+}
+
+// SecureChannelOptions возвращает опции защищённого канала для подключения без сертификата пользователя
+// (анонимно или по паролю): канал подписывается и шифруется сертификатом приложения сервиса.
+// Для endpoint с политикой None сертификат не нужен
+func (cm *CertificateManager) SecureChannelOptions(endpoint *ua.EndpointDescription) ([]client.Option, error) {
+	opts := []client.Option{client.WithSecurityPolicyURI(endpoint.SecurityPolicyURI, endpoint.SecurityMode)}
+	if endpoint.SecurityPolicyURI != ua.SecurityPolicyURINone {
+		certBytes, key, err := cm.ApplicationCertificate()
+		if err != nil {
+			return nil, err
+		}
+		rsaPrivateKey, err := rsaKey(key)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithClientCertificate(certBytes, rsaPrivateKey))
+	}
+	return append(opts, cm.ServerTrustOptions()...), nil
+}
+
+// HasApplicationCertificate сообщает, может ли сервис подписывать канал сертификатом приложения
+func (cm *CertificateManager) HasApplicationCertificate() bool {
+	_, _, err := cm.ApplicationCertificate()
+	return err == nil
 }
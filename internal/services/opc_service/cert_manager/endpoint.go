@@ -0,0 +1,201 @@
+package cert_manager
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// supportedPolicyURIs - политики безопасности, которые поддерживает используемый OPC UA стек
+var supportedPolicyURIs = map[string]bool{
+	ua.SecurityPolicyURINone:                true,
+	ua.SecurityPolicyURIBasic128Rsa15:       true,
+	ua.SecurityPolicyURIBasic256:            true,
+	ua.SecurityPolicyURIBasic256Sha256:      true,
+	ua.SecurityPolicyURIAes128Sha256RsaOaep: true,
+	ua.SecurityPolicyURIAes256Sha256RsaPss:  true,
+	models.PolicyEccNistP256.URI():          false,
+	models.PolicyEccNistP384.URI():          false,
+}
+
+func (cm *CertificateManager) printEndpoints(resp []ua.EndpointDescription, selected *ua.EndpointDescription, policyID string) {
+	cm.logger.Info("🔍 Available Endpoints:")
+
+	for i, ep := range resp {
+		cm.logger.Info("[%d] EndpointURL: %s", i, ep.EndpointURL)
+		cm.logger.Info("    SecurityPolicy: %s", ep.SecurityPolicyURI)
+		cm.logger.Info("    SecurityMode: %s", ep.SecurityMode)
+		cm.logger.Info("    UserIdentityTokens:")
+		for _, token := range ep.UserIdentityTokens {
+			cm.logger.Info("      - Type: %s, PolicyID: %s, SecurityPolicy: %s",
+				token.TokenType, token.PolicyID, token.SecurityPolicyURI)
+		}
+	}
+
+	if selected != nil {
+		cm.logger.Info("✅ Selected endpoint: %s", selected.EndpointURL)
+		cm.logger.Info("   Security: %s + %s", selected.SecurityPolicyURI, selected.SecurityMode)
+		cm.logger.Info("   Selected PolicyID: %s", policyID)
+	} else {
+		cm.logger.Info("✖ No endpoint matches requested security policy, mode and user token")
+	}
+}
+
+// DescribeEndpoints запрашивает у сервера список endpoint'ов и возвращает их описание
+// вместе со сведениями о сертификате сервера и его положении в хранилище
+func (cm *CertificateManager) DescribeEndpoints(ctx context.Context, endpointURL string) ([]models.EndpointDescriptionResponse, error) {
+	resp, err := client.GetEndpoints(ctx, &ua.GetEndpointsRequest{
+		EndpointURL: endpointURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]models.EndpointDescriptionResponse, 0, len(resp.Endpoints))
+	for _, ep := range resp.Endpoints {
+		desc := models.EndpointDescriptionResponse{
+			EndpointURL:       ep.EndpointURL,
+			ApplicationURI:    ep.Server.ApplicationURI,
+			ApplicationName:   ep.Server.ApplicationName.Text,
+			Policy:            models.SecurityPolicyFromURI(ep.SecurityPolicyURI),
+			SecurityPolicyURI: ep.SecurityPolicyURI,
+			Mode:              models.MessageSecurityModeFromUA(ep.SecurityMode),
+			SecurityLevel:     ep.SecurityLevel,
+			Supported:         supportedPolicyURIs[ep.SecurityPolicyURI],
+			UserTokens:        make([]models.UserTokenPolicyResponse, 0, len(ep.UserIdentityTokens)),
+		}
+		for _, token := range ep.UserIdentityTokens {
+			desc.UserTokens = append(desc.UserTokens, models.UserTokenPolicyResponse{
+				TokenType:         token.TokenType.String(),
+				PolicyID:          token.PolicyID,
+				SecurityPolicyURI: token.SecurityPolicyURI,
+			})
+		}
+		if len(ep.ServerCertificate) > 0 {
+			certs, err := x509.ParseCertificates([]byte(ep.ServerCertificate))
+			if err == nil && len(certs) > 0 {
+				info := certificateInfo(certs[0], cm.storeOf(Thumbprint(certs[0])))
+				desc.ServerCertificate = &info
+			} else {
+				cm.logger.Warn("Cannot parse server certificate", "endpoint", ep.EndpointURL, "error", err)
+			}
+		}
+		endpoints = append(endpoints, desc)
+	}
+	return endpoints, nil
+}
+
+// SelectCertificateEndpoint выбирает endpoint с аутентификацией пользователя по сертификату
+func (cm *CertificateManager) SelectCertificateEndpoint(ctx context.Context, endpointURL string, policy models.SecurityPolicyEnum, mode models.MessageSecurityModeEnum) (*ua.EndpointDescription, string, error) {
+	return cm.SelectEndpoint(ctx, endpointURL, models.EndpointRequirements{
+		Policy:               policy,
+		Mode:                 mode,
+		TokenType:            ua.UserTokenTypeCertificate,
+		HasClientCertificate: true,
+	})
+}
+
+// SelectEndpoint выбирает endpoint сервера, подходящий под запрошенные политику, режим и тип токена.
+// Если политика и режим не заданы, выбирается самый защищённый endpoint.
+// Возвращает endpoint и PolicyID токена пользователя.
+func (cm *CertificateManager) SelectEndpoint(ctx context.Context, endpointURL string, req models.EndpointRequirements) (*ua.EndpointDescription, string, error) {
+	resp, err := client.GetEndpoints(ctx, &ua.GetEndpointsRequest{
+		EndpointURL: endpointURL,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	ep, policyID, err := chooseEndpoint(resp.Endpoints, endpointURL, req)
+	if errors.Is(err, errors.ErrSecurityNotOffered) {
+		cm.printEndpoints(resp.Endpoints, nil, "")
+	}
+	return ep, policyID, err
+}
+
+// chooseEndpoint выбирает из endpoint'ов сервера самый защищённый, подходящий под требования
+func chooseEndpoint(offered []ua.EndpointDescription, endpointURL string, req models.EndpointRequirements) (*ua.EndpointDescription, string, error) {
+	if req.Policy.IsECC() {
+		return nil, "", fmt.Errorf("%w: policy %s", errors.ErrECCNotSupported, req.Policy)
+	}
+	if (req.Policy != "" && req.Policy != models.PolicyNone) || (req.Mode != "" && req.Mode != models.ModeNone) {
+		if !req.HasClientCertificate {
+			return nil, "", fmt.Errorf("%w: policy %s with mode %s requires a client application certificate",
+				errors.ErrSecurityNotOffered, orAny(string(req.Policy)), orAny(string(req.Mode)))
+		}
+	}
+
+	// Сортируем по убыванию SecurityLevel, чтобы при отсутствии запроса взять самый защищённый
+	endpoints := append([]ua.EndpointDescription(nil), offered...)
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return endpoints[i].SecurityLevel > endpoints[j].SecurityLevel
+	})
+
+	for i := range endpoints {
+		ep := &endpoints[i]
+		// ECC политики стек не реализует: такие endpoint'ы не выбираются
+		if !supportedPolicyURIs[ep.SecurityPolicyURI] {
+			continue
+		}
+		if !req.HasClientCertificate && ep.SecurityPolicyURI != ua.SecurityPolicyURINone {
+			continue
+		}
+		if req.Policy != "" && ep.SecurityPolicyURI != req.Policy.URI() {
+			continue
+		}
+		if req.Mode != "" && ep.SecurityMode != req.Mode.ToUA() {
+			continue
+		}
+		for _, token := range ep.UserIdentityTokens {
+			if token.TokenType != req.TokenType {
+				continue
+			}
+			return ep, token.PolicyID, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("%w: policy %s with mode %s and %s user token is not offered by %s; available: %s",
+		errors.ErrSecurityNotOffered,
+		orAny(string(req.Policy)), orAny(string(req.Mode)), req.TokenType, endpointURL,
+		describeOfferedSecurity(offered, req.TokenType))
+}
+
+// describeOfferedSecurity перечисляет пары политика/режим, которые сервер предлагает для заданного типа токена
+func describeOfferedSecurity(endpoints []ua.EndpointDescription, tokenType ua.UserTokenType) string {
+	var offered []string
+	seen := make(map[string]bool)
+	for _, ep := range endpoints {
+		hasToken := false
+		for _, token := range ep.UserIdentityTokens {
+			if token.TokenType == tokenType {
+				hasToken = true
+				break
+			}
+		}
+		if !hasToken {
+			continue
+		}
+		pair := fmt.Sprintf("%s/%s", models.SecurityPolicyFromURI(ep.SecurityPolicyURI), ep.SecurityMode)
+		if !seen[pair] {
+			seen[pair] = true
+			offered = append(offered, pair)
+		}
+	}
+	if len(offered) == 0 {
+		return "none"
+	}
+	return strings.Join(offered, ", ")
+}
+
+func orAny(s string) string {
+	if s == "" {
+		return "any"
+	}
+	return s
+}
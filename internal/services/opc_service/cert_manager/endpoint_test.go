@@ -0,0 +1,127 @@
+package cert_manager
+
+import (
+	"github.com/awcullen/opcua/ua"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/errors"
+	"testing"
+)
+
+func testEndpoint(policyURI string, mode ua.MessageSecurityMode, level byte, tokens ...ua.UserTokenType) ua.EndpointDescription {
+	ep := ua.EndpointDescription{
+		EndpointURL:       "opc.tcp://cnc:4840",
+		SecurityPolicyURI: policyURI,
+		SecurityMode:      mode,
+		SecurityLevel:     level,
+	}
+	for _, tokenType := range tokens {
+		ep.UserIdentityTokens = append(ep.UserIdentityTokens, ua.UserTokenPolicy{
+			PolicyID:  policyURI + "#" + tokenType.String(),
+			TokenType: tokenType,
+		})
+	}
+	return ep
+}
+
+func TestChooseEndpoint(t *testing.T) {
+	offered := []ua.EndpointDescription{
+		testEndpoint(ua.SecurityPolicyURINone, ua.MessageSecurityModeNone, 0, ua.UserTokenTypeAnonymous, ua.UserTokenTypeUserName),
+		testEndpoint(ua.SecurityPolicyURIBasic256Sha256, ua.MessageSecurityModeSign, 10, ua.UserTokenTypeAnonymous, ua.UserTokenTypeUserName),
+		testEndpoint(ua.SecurityPolicyURIBasic256Sha256, ua.MessageSecurityModeSignAndEncrypt, 20,
+			ua.UserTokenTypeAnonymous, ua.UserTokenTypeUserName, ua.UserTokenTypeCertificate),
+		testEndpoint(ua.SecurityPolicyURIAes128Sha256RsaOaep, ua.MessageSecurityModeSign, 5, ua.UserTokenTypeAnonymous),
+		// ECC политику стек не реализует: такой endpoint не должен выбираться даже с наибольшим SecurityLevel
+		testEndpoint(models.PolicyEccNistP256.URI(), ua.MessageSecurityModeSignAndEncrypt, 30, ua.UserTokenTypeAnonymous),
+	}
+
+	tests := []struct {
+		name       string
+		req        models.EndpointRequirements
+		wantPolicy string
+		wantMode   ua.MessageSecurityMode
+		wantErr    error
+	}{
+		{
+			name:       "самый защищённый endpoint без ECC",
+			req:        models.EndpointRequirements{TokenType: ua.UserTokenTypeAnonymous, HasClientCertificate: true},
+			wantPolicy: ua.SecurityPolicyURIBasic256Sha256,
+			wantMode:   ua.MessageSecurityModeSignAndEncrypt,
+		},
+		{
+			name:       "без сертификата приложения только None",
+			req:        models.EndpointRequirements{TokenType: ua.UserTokenTypeUserName},
+			wantPolicy: ua.SecurityPolicyURINone,
+			wantMode:   ua.MessageSecurityModeNone,
+		},
+		{
+			name: "заданный режим",
+			req: models.EndpointRequirements{Policy: models.PolicyBasic256Sha256, Mode: models.ModeSign,
+				TokenType: ua.UserTokenTypeUserName, HasClientCertificate: true},
+			wantPolicy: ua.SecurityPolicyURIBasic256Sha256,
+			wantMode:   ua.MessageSecurityModeSign,
+		},
+		{
+			name: "токен сертификата",
+			req: models.EndpointRequirements{Mode: models.ModeSignAndEncrypt,
+				TokenType: ua.UserTokenTypeCertificate, HasClientCertificate: true},
+			wantPolicy: ua.SecurityPolicyURIBasic256Sha256,
+			wantMode:   ua.MessageSecurityModeSignAndEncrypt,
+		},
+		{
+			name: "современная RSA политика",
+			req: models.EndpointRequirements{Policy: models.PolicyAes128Sha256RsaOaep,
+				TokenType: ua.UserTokenTypeAnonymous, HasClientCertificate: true},
+			wantPolicy: ua.SecurityPolicyURIAes128Sha256RsaOaep,
+			wantMode:   ua.MessageSecurityModeSign,
+		},
+		{
+			name: "политика не предлагается сервером",
+			req: models.EndpointRequirements{Policy: models.PolicyAes256Sha256RsaPss,
+				TokenType: ua.UserTokenTypeAnonymous, HasClientCertificate: true},
+			wantErr: errors.ErrSecurityNotOffered,
+		},
+		{
+			name: "режим не предлагается с токеном",
+			req: models.EndpointRequirements{Mode: models.ModeSign,
+				TokenType: ua.UserTokenTypeCertificate, HasClientCertificate: true},
+			wantErr: errors.ErrSecurityNotOffered,
+		},
+		{
+			name:    "защищённая политика без сертификата приложения",
+			req:     models.EndpointRequirements{Policy: models.PolicyBasic256Sha256, TokenType: ua.UserTokenTypeAnonymous},
+			wantErr: errors.ErrSecurityNotOffered,
+		},
+		{
+			name: "ECC политика",
+			req: models.EndpointRequirements{Policy: models.PolicyEccNistP256,
+				TokenType: ua.UserTokenTypeAnonymous, HasClientCertificate: true},
+			wantErr: errors.ErrECCNotSupported,
+		},
+		{
+			name:    "токен не предлагается",
+			req:     models.EndpointRequirements{TokenType: ua.UserTokenTypeIssuedToken, HasClientCertificate: true},
+			wantErr: errors.ErrSecurityNotOffered,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep, policyID, err := chooseEndpoint(offered, "opc.tcp://cnc:4840", tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ожидалась ошибка %v, получено %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if ep.SecurityPolicyURI != tt.wantPolicy || ep.SecurityMode != tt.wantMode {
+				t.Fatalf("выбран %s/%s, ожидался %s/%s", ep.SecurityPolicyURI, ep.SecurityMode, tt.wantPolicy, tt.wantMode)
+			}
+			if want := tt.wantPolicy + "#" + tt.req.TokenType.String(); policyID != want {
+				t.Fatalf("PolicyID %q, ожидался %q", policyID, want)
+			}
+		})
+	}
+}
@@ -1,82 +1,126 @@
 package cert_manager
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/errors"
 )
 
 // VerifyKeyMatchesCert проверяет, что публичный ключ сертификата соответствует приватному ключу
-func (cm *CertificateManager) VerifyKeyMatchesCert(cert *x509.Certificate, key *rsa.PrivateKey) error {
-	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+func (cm *CertificateManager) VerifyKeyMatchesCert(cert *x509.Certificate, key crypto.Signer) error {
+	pub, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
 	if !ok {
-		cm.logger.Error("Certificate public key is not RSA")
-		return fmt.Errorf("certificate public key is not RSA")
+		cm.logger.Error("Unsupported certificate public key type", "type", fmt.Sprintf("%T", cert.PublicKey))
+		return fmt.Errorf("unsupported certificate public key type %T", cert.PublicKey)
 	}
 
-	if pub.N.Cmp(key.N) != 0 || pub.E != key.E {
+	if !pub.Equal(key.Public()) {
 		cm.logger.Error("Client certificate and private key do not match")
-		return fmt.Errorf("client certificate and private key do not match")
+		return errors.ErrCertificateKeyMismatch
 	}
 
-	cm.logger.Info("Certificate and private key match successfully")
+	cm.logger.Debug("Certificate and private key match successfully", "keyType", KeyType(key))
 	return nil
 }
 
-// DecodePrivateKey принимает приватный ключ в виде байтов (PEM/DER, PKCS#1/PKCS#8)
-// Возвращает *rsa.PrivateKey
-func (cm *CertificateManager) DecodePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+// DecodePrivateKey принимает приватный ключ в виде байтов (PEM/DER, PKCS#1/PKCS#8/SEC 1)
+// Возвращает *rsa.PrivateKey или *ecdsa.PrivateKey
+func (cm *CertificateManager) DecodePrivateKey(data []byte) (crypto.Signer, error) {
 	// Пробуем как PEM
 	if block, _ := pem.Decode(data); block != nil {
-		cm.logger.Info("PEM block detected: %s", block.Type)
+		cm.logger.Debug("PEM block detected", "type", block.Type)
 		switch block.Type {
 		case "RSA PRIVATE KEY": // PKCS#1
 			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
 			if err != nil {
-				cm.logger.Error("Cannot parse PKCS#1 private key: %v", err)
+				cm.logger.Error("Cannot parse PKCS#1 private key", "error", err)
 				return nil, fmt.Errorf("cannot parse PKCS#1 private key: %w", err)
 			}
-			cm.logger.Info("PKCS#1 private key parsed successfully")
+			return key, nil
+
+		case "EC PRIVATE KEY": // SEC 1
+			key, err := x509.ParseECPrivateKey(block.Bytes)
+			if err != nil {
+				cm.logger.Error("Cannot parse EC private key", "error", err)
+				return nil, fmt.Errorf("cannot parse EC private key: %w", err)
+			}
 			return key, nil
 
 		case "PRIVATE KEY": // PKCS#8
 			parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 			if err != nil {
-				cm.logger.Error("Cannot parse PKCS#8 private key: %v", err)
+				cm.logger.Error("Cannot parse PKCS#8 private key", "error", err)
 				return nil, fmt.Errorf("cannot parse PKCS#8 private key: %w", err)
 			}
-			rsaKey, ok := parsedKey.(*rsa.PrivateKey)
-			if !ok {
-				cm.logger.Error("Parsed PKCS#8 key is not an RSA private key")
-				return nil, fmt.Errorf("not an RSA private key")
-			}
-			cm.logger.Info("PKCS#8 private key parsed successfully")
-			return rsaKey, nil
+			return asSigner(parsedKey)
 
 		default:
-			cm.logger.Error("Unsupported PEM key type: %s", block.Type)
+			cm.logger.Error("Unsupported PEM key type", "type", block.Type)
 			return nil, fmt.Errorf("unsupported key type: %s", block.Type)
 		}
 	}
 
 	// Пробуем как DER PKCS#8
 	if parsedKey, err := x509.ParsePKCS8PrivateKey(data); err == nil {
-		rsaKey, ok := parsedKey.(*rsa.PrivateKey)
-		if !ok {
-			cm.logger.Error("Parsed DER PKCS#8 key is not an RSA private key")
-			return nil, fmt.Errorf("not an RSA private key")
-		}
-		cm.logger.Info("DER PKCS#8 private key parsed successfully")
-		return rsaKey, nil
+		return asSigner(parsedKey)
 	}
 
 	// Пробуем как DER PKCS#1
 	if key, err := x509.ParsePKCS1PrivateKey(data); err == nil {
-		cm.logger.Info("DER PKCS#1 private key parsed successfully")
+		return key, nil
+	}
+
+	// Пробуем как DER SEC 1
+	if key, err := x509.ParseECPrivateKey(data); err == nil {
 		return key, nil
 	}
 
 	cm.logger.Error("Cannot parse private key (unsupported format)")
 	return nil, fmt.Errorf("cannot parse private key (unsupported format)")
 }
+
+// asSigner оставляет только поддерживаемые OPC UA типы ключей: RSA и ECDSA
+func asSigner(key any) (crypto.Signer, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// KeyType возвращает читаемое имя типа ключа: RSA-2048, ECC_nistP256 и т.п.
+func KeyType(key crypto.Signer) string {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return fmt.Sprintf("RSA-%d", k.N.BitLen())
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return string(models.PolicyEccNistP256)
+		case elliptic.P384():
+			return string(models.PolicyEccNistP384)
+		default:
+			return "ECC_" + k.Curve.Params().Name
+		}
+	default:
+		return fmt.Sprintf("%T", key)
+	}
+}
+
+// rsaKey приводит ключ к RSA: используемый OPC UA стек подписывает и шифрует только RSA ключами
+func rsaKey(key crypto.Signer) (*rsa.PrivateKey, error) {
+	k, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s client key, only RSA keys are supported", errors.ErrECCNotSupported, KeyType(key))
+	}
+	return k, nil
+}
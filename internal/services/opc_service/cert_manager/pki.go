@@ -0,0 +1,255 @@
+package cert_manager
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Структура каталогов PKI в соответствии с OPC UA Part 12:
+//
+//	<pki>/trusted/certs, <pki>/trusted/crl   - доверенные сертификаты серверов и их CRL
+//	<pki>/issuers/certs, <pki>/issuers/crl   - промежуточные/корневые CA (используются только для построения цепочки)
+//	<pki>/rejected/certs                     - отклонённые сертификаты серверов (карантин)
+func (cm *CertificateManager) certsDir(store models.CertificateStoreEnum) string {
+	return filepath.Join(cm.pkiDir, string(store), "certs")
+}
+
+func (cm *CertificateManager) crlDir(store models.CertificateStoreEnum) string {
+	return filepath.Join(cm.pkiDir, string(store), "crl")
+}
+
+// ensurePKIDirs создает каталоги хранилища сертификатов
+func (cm *CertificateManager) ensurePKIDirs() error {
+	dirs := []string{
+		cm.certsDir(models.StoreTrusted),
+		cm.crlDir(models.StoreTrusted),
+		cm.certsDir(models.StoreIssuers),
+		cm.crlDir(models.StoreIssuers),
+		cm.certsDir(models.StoreRejected),
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create PKI directory %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// ServerTrustOptions возвращает опции клиента для проверки сертификата сервера по хранилищу.
+// Отклонённые сертификаты стек складывает в rejected/certs автоматически.
+func (cm *CertificateManager) ServerTrustOptions() []client.Option {
+	return []client.Option{
+		client.WithTrustedCertificatesPaths(cm.certsDir(models.StoreTrusted), cm.crlDir(models.StoreTrusted)),
+		client.WithIssuerCertificatesPaths(cm.certsDir(models.StoreIssuers), cm.crlDir(models.StoreIssuers)),
+		client.WithRejectedCertificatesPath(cm.certsDir(models.StoreRejected)),
+	}
+}
+
+// PrepareServerTrust реализует режим trust-on-first-use: сертификат сервера добавляется в доверенные,
+// если для его ApplicationURI в хранилище ещё нет ни одного доверенного сертификата.
+// Сменившийся сертификат уже известного сервера не доверяется автоматически и уходит в карантин при подключении.
+func (cm *CertificateManager) PrepareServerTrust(endpoint *ua.EndpointDescription, trustOnFirstUse bool) error {
+	if !trustOnFirstUse || endpoint == nil || len(endpoint.ServerCertificate) == 0 {
+		return nil
+	}
+
+	certs, err := x509.ParseCertificates([]byte(endpoint.ServerCertificate))
+	if err != nil || len(certs) == 0 {
+		return fmt.Errorf("cannot parse server certificate: %w", err)
+	}
+	serverCert := certs[0]
+	thumbprint := Thumbprint(serverCert)
+
+	cm.pkiMu.Lock()
+	defer cm.pkiMu.Unlock()
+
+	trusted, err := cm.readStore(models.StoreTrusted)
+	if err != nil {
+		return err
+	}
+	appURI := applicationURI(serverCert)
+	for _, entry := range trusted {
+		if entry.info.Thumbprint == thumbprint {
+			return nil
+		}
+		if appURI != "" && entry.info.ApplicationURI == appURI {
+			cm.logger.Warn("Server certificate changed, trust-on-first-use skipped",
+				"applicationURI", appURI, "thumbprint", thumbprint, "trusted", entry.info.Thumbprint)
+			return nil
+		}
+	}
+
+	if err := writeCertificate(filepath.Join(cm.certsDir(models.StoreTrusted), thumbprint+".crt"), serverCert); err != nil {
+		return err
+	}
+	if rejected, err := cm.findInStore(models.StoreRejected, thumbprint); err == nil {
+		_ = os.Remove(rejected.path)
+	}
+
+	cm.logger.Info("Server certificate trusted on first use",
+		"subject", serverCert.Subject.String(), "thumbprint", thumbprint, "endpoint", endpoint.EndpointURL)
+	return nil
+}
+
+// ListServerCertificates возвращает сертификаты из указанного раздела хранилища
+func (cm *CertificateManager) ListServerCertificates(store models.CertificateStoreEnum) ([]models.ServerCertificateInfo, error) {
+	if err := store.Validate(); err != nil {
+		return nil, err
+	}
+
+	cm.pkiMu.Lock()
+	defer cm.pkiMu.Unlock()
+
+	entries, err := cm.readStore(store)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]models.ServerCertificateInfo, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry.info)
+	}
+	return list, nil
+}
+
+// TrustServerCertificate переносит сертификат из карантина в доверенные
+func (cm *CertificateManager) TrustServerCertificate(thumbprint string) (*models.ServerCertificateInfo, error) {
+	return cm.moveServerCertificate(thumbprint, models.StoreRejected, models.StoreTrusted)
+}
+
+// RevokeServerCertificate отзывает доверие к сертификату, перенося его в карантин
+func (cm *CertificateManager) RevokeServerCertificate(thumbprint string) (*models.ServerCertificateInfo, error) {
+	return cm.moveServerCertificate(thumbprint, models.StoreTrusted, models.StoreRejected)
+}
+
+func (cm *CertificateManager) moveServerCertificate(thumbprint string, from, to models.CertificateStoreEnum) (*models.ServerCertificateInfo, error) {
+	thumbprint = strings.ToLower(thumbprint)
+
+	cm.pkiMu.Lock()
+	defer cm.pkiMu.Unlock()
+
+	entry, err := cm.findInStore(from, thumbprint)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeCertificate(filepath.Join(cm.certsDir(to), thumbprint+".crt"), entry.cert); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(entry.path); err != nil {
+		return nil, fmt.Errorf("failed to remove %s: %w", entry.path, err)
+	}
+
+	cm.logger.Info("Server certificate moved", "thumbprint", thumbprint, "from", from, "to", to)
+
+	info := entry.info
+	info.Store = to
+	return &info, nil
+}
+
+// storeOf возвращает раздел хранилища, в котором лежит сертификат, или пустую строку
+func (cm *CertificateManager) storeOf(thumbprint string) models.CertificateStoreEnum {
+	cm.pkiMu.Lock()
+	defer cm.pkiMu.Unlock()
+
+	for _, store := range []models.CertificateStoreEnum{models.StoreTrusted, models.StoreRejected} {
+		if _, err := cm.findInStore(store, thumbprint); err == nil {
+			return store
+		}
+	}
+	return ""
+}
+
+// storeEntry - сертификат, прочитанный из файла хранилища
+type storeEntry struct {
+	path string
+	cert *x509.Certificate
+	info models.ServerCertificateInfo
+}
+
+// readStore читает все сертификаты раздела. Нечитаемые файлы пропускаются
+func (cm *CertificateManager) readStore(store models.CertificateStoreEnum) ([]storeEntry, error) {
+	dir := cm.certsDir(store)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var entries []storeEntry
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			cm.logger.Warn("Failed to read certificate file", "path", path, "error", err)
+			continue
+		}
+		cert, err := cm.DecodeCertificate(data)
+		if err != nil {
+			cm.logger.Warn("Skipping invalid certificate file", "path", path, "error", err)
+			continue
+		}
+		entries = append(entries, storeEntry{path: path, cert: cert, info: certificateInfo(cert, store)})
+	}
+	return entries, nil
+}
+
+// findInStore ищет сертификат в разделе по SHA-1 отпечатку
+func (cm *CertificateManager) findInStore(store models.CertificateStoreEnum, thumbprint string) (*storeEntry, error) {
+	entries, err := cm.readStore(store)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].info.Thumbprint == thumbprint {
+			return &entries[i], nil
+		}
+	}
+	return nil, errors.NewNotFoundError(fmt.Sprintf("certificate %s not found in %s store", thumbprint, store))
+}
+
+// Thumbprint возвращает SHA-1 отпечаток сертификата в hex
+func Thumbprint(cert *x509.Certificate) string {
+	sum := sha1.Sum(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func certificateInfo(cert *x509.Certificate, store models.CertificateStoreEnum) models.ServerCertificateInfo {
+	return models.ServerCertificateInfo{
+		Thumbprint:     Thumbprint(cert),
+		Store:          store,
+		Subject:        cert.Subject.String(),
+		Issuer:         cert.Issuer.String(),
+		ApplicationURI: applicationURI(cert),
+		NotBefore:      cert.NotBefore,
+		NotAfter:       cert.NotAfter,
+	}
+}
+
+func applicationURI(cert *x509.Certificate) string {
+	if len(cert.URIs) == 0 {
+		return ""
+	}
+	return cert.URIs[0].String()
+}
+
+func writeCertificate(path string, cert *x509.Certificate) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write certificate %s: %w", path, err)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package opc_service
 
 import (
+	"opc_ua_service/internal/config"
 	"opc_ua_service/internal/interfaces"
 	"opc_ua_service/internal/middleware/logging"
 	"opc_ua_service/internal/services/opc_service/cert_manager"
@@ -14,8 +15,8 @@ type OpcService struct {
 	interfaces.OpcCommunicatorService
 }
 
-func NewOpcService(producer interfaces.KafkaService, logger *logging.Logger) interfaces.OpcService {
-	certManager := cert_manager.NewCertificateManager(logger)
+func NewOpcService(producer interfaces.KafkaService, logger *logging.Logger, cfg *config.Config) interfaces.OpcService {
+	certManager := cert_manager.NewCertificateManager(logger, cfg.PKI)
 	opcConnector := opc_connector.NewOpcConnector(certManager, logger)
 	opcCommunicator := opc_communicator.NewOpcCommunicator(opcConnector, producer, logger)
 
@@ -0,0 +1,362 @@
+package opc_communicator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"sort"
+	"time"
+)
+
+// Параметры подписки на события тревог
+const (
+	alarmClientHandle       uint32 = 0xFFFFFFFF // ClientHandle MonitoredItem событий, не пересекается с индексами узлов
+	alarmQueueSize                 = 1000       // событий в очереди сервера между публикациями
+	alarmPublishingInterval        = 1000       // мс, для отдельной подписки в режиме опроса
+)
+
+// Поля событий в порядке SelectClauses фильтра alarmEventFilter
+const (
+	alarmFieldEventType = iota
+	alarmFieldSourceNode
+	alarmFieldSourceName
+	alarmFieldTime
+	alarmFieldMessage
+	alarmFieldSeverity
+	alarmFieldConditionName
+	alarmFieldActiveState
+	alarmFieldAckedState
+	alarmFieldConditionID
+	alarmFieldCount
+)
+
+// alarmState - активные тревоги станка по ConditionId
+type alarmState struct {
+	active map[string]models.AlarmsResponse
+	// refreshed - тревоги, пришедшие между RefreshStartEvent и RefreshEndEvent; nil вне ConditionRefresh
+	refreshed map[string]bool
+}
+
+// alarmChange - появление или снятие тревоги
+type alarmChange struct {
+	event models.AlarmEventTypeEnum
+	alarm models.AlarmsResponse
+}
+
+// alarmEventFilter выбирает поля AlarmConditionType и пропускает только тревоги и события начала и конца
+// ConditionRefresh
+func alarmEventFilter() ua.EventFilter {
+	field := func(typeID ua.NodeID, path ...string) ua.SimpleAttributeOperand {
+		browsePath := make([]ua.QualifiedName, len(path))
+		for i, name := range path {
+			browsePath[i] = ua.NewQualifiedName(0, name)
+		}
+		return ua.SimpleAttributeOperand{TypeDefinitionID: typeID, BrowsePath: browsePath, AttributeID: ua.AttributeIDValue}
+	}
+	ofType := func(typeID ua.NodeID) ua.ContentFilterElement {
+		return ua.ContentFilterElement{FilterOperator: ua.FilterOperatorOfType, FilterOperands: []ua.ExtensionObject{ua.LiteralOperand{Value: typeID}}}
+	}
+	or := func(a, b uint32) ua.ContentFilterElement {
+		return ua.ContentFilterElement{FilterOperator: ua.FilterOperatorOr, FilterOperands: []ua.ExtensionObject{ua.ElementOperand{Index: a}, ua.ElementOperand{Index: b}}}
+	}
+
+	return ua.EventFilter{
+		SelectClauses: []ua.SimpleAttributeOperand{
+			field(ua.ObjectTypeIDBaseEventType, "EventType"),
+			field(ua.ObjectTypeIDBaseEventType, "SourceNode"),
+			field(ua.ObjectTypeIDBaseEventType, "SourceName"),
+			field(ua.ObjectTypeIDBaseEventType, "Time"),
+			field(ua.ObjectTypeIDBaseEventType, "Message"),
+			field(ua.ObjectTypeIDBaseEventType, "Severity"),
+			field(ua.ObjectTypeIDConditionType, "ConditionName"),
+			field(ua.ObjectTypeIDAlarmConditionType, "ActiveState", "Id"),
+			field(ua.ObjectTypeIDAcknowledgeableConditionType, "AckedState", "Id"),
+			{TypeDefinitionID: ua.ObjectTypeIDConditionType, AttributeID: ua.AttributeIDNodeID}, // ConditionId
+		},
+		WhereClause: ua.ContentFilter{Elements: []ua.ContentFilterElement{
+			or(1, 2),
+			ofType(ua.ObjectTypeIDAlarmConditionType),
+			or(3, 4),
+			ofType(ua.ObjectTypeIDRefreshStartEventType),
+			ofType(ua.ObjectTypeIDRefreshEndEventType),
+		}},
+	}
+}
+
+// alarmMonitoredItem - MonitoredItem событий объекта Server
+func alarmMonitoredItem() ua.MonitoredItemCreateRequest {
+	return ua.MonitoredItemCreateRequest{
+		ItemToMonitor:  ua.ReadValueID{NodeID: ua.ObjectIDServer, AttributeID: ua.AttributeIDEventNotifier},
+		MonitoringMode: ua.MonitoringModeReporting,
+		RequestedParameters: ua.MonitoringParameters{
+			ClientHandle:  alarmClientHandle,
+			QueueSize:     alarmQueueSize,
+			DiscardOldest: true,
+			Filter:        alarmEventFilter(),
+		},
+	}
+}
+
+// runAlarmSubscription поддерживает отдельную подписку на тревоги в режиме опроса, пока не будет остановлен сбор
+// данных. В режиме подписки MonitoredItem событий добавляется в подписку данных: Publish запросы одной сессии
+// получают уведомления любых её подписок.
+func (o *OpcCommunicator) runAlarmSubscription(ctx context.Context, id uuid.UUID, connInfo *models.ConnectionInfo) {
+	for {
+		connInfo.Mu.RLock()
+		var conn *client.Client
+		if connInfo.IsHealthy() {
+			conn = connInfo.Conn
+		}
+		connInfo.Mu.RUnlock()
+
+		if conn != nil {
+			supported, err := o.subscribeAlarms(ctx, id, conn)
+			if ctx.Err() != nil {
+				return
+			}
+			if !supported {
+				o.logger.Warn("Server does not support alarm events, alarms are not monitored", "UUID", id, "error", err)
+				return
+			}
+			o.logger.Warn("Alarm subscription lost, it will be recreated", "UUID", id, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(subscriptionRetryDelay):
+		}
+	}
+}
+
+// subscribeAlarms создаёт подписку с MonitoredItem событий и обрабатывает тревоги, пока сессия жива.
+// supported=false, если сервер отклонил MonitoredItem событий.
+func (o *OpcCommunicator) subscribeAlarms(ctx context.Context, id uuid.UUID, conn *client.Client) (bool, error) {
+	sub, err := conn.CreateSubscription(ctx, &ua.CreateSubscriptionRequest{
+		RequestedPublishingInterval: alarmPublishingInterval,
+		RequestedMaxKeepAliveCount:  subscriptionKeepAliveCount,
+		RequestedLifetimeCount:      subscriptionLifetimeCount,
+		PublishingEnabled:           true,
+	})
+	if err != nil {
+		return true, fmt.Errorf("create subscription: %w", err)
+	}
+	defer o.deleteSubscription(conn, sub.SubscriptionID)
+
+	created, err := conn.CreateMonitoredItems(ctx, &ua.CreateMonitoredItemsRequest{
+		SubscriptionID:     sub.SubscriptionID,
+		TimestampsToReturn: ua.TimestampsToReturnBoth,
+		ItemsToCreate:      []ua.MonitoredItemCreateRequest{alarmMonitoredItem()},
+	})
+	if err != nil {
+		return true, fmt.Errorf("create monitored items: %w", err)
+	}
+	if len(created.Results) == 0 || created.Results[0].StatusCode.IsBad() {
+		status := ua.BadUnexpectedError
+		if len(created.Results) > 0 {
+			status = created.Results[0].StatusCode
+		}
+		return false, fmt.Errorf("create event monitored item: %w", status)
+	}
+	o.refreshAlarms(ctx, id, conn, sub.SubscriptionID)
+
+	timeoutHint := uint32(sub.RevisedPublishingInterval*float64(sub.RevisedMaxKeepAliveCount)*2) + 10000
+	acks := []ua.SubscriptionAcknowledgement{}
+	for {
+		res, err := conn.Publish(ctx, &ua.PublishRequest{
+			RequestHeader:                ua.RequestHeader{TimeoutHint: timeoutHint},
+			SubscriptionAcknowledgements: acks,
+		})
+		if err != nil {
+			return true, fmt.Errorf("publish: %w", err)
+		}
+
+		acks = []ua.SubscriptionAcknowledgement{}
+		if len(res.NotificationMessage.NotificationData) == 0 {
+			continue
+		}
+		acks = append(acks, ua.SubscriptionAcknowledgement{SubscriptionID: res.SubscriptionID, SequenceNumber: res.NotificationMessage.SequenceNumber})
+
+		for _, data := range res.NotificationMessage.NotificationData {
+			switch body := data.(type) {
+			case ua.EventNotificationList:
+				o.handleAlarmEvents(id, body)
+			case ua.StatusChangeNotification:
+				return true, fmt.Errorf("subscription status changed: %w", body.Status)
+			}
+		}
+	}
+}
+
+// refreshAlarms запрашивает ConditionRefresh: сервер присылает текущее состояние всех тревог между
+// RefreshStartEvent и RefreshEndEvent, поэтому после переподключения список активных тревог восстанавливается
+func (o *OpcCommunicator) refreshAlarms(ctx context.Context, id uuid.UUID, conn *client.Client, subscriptionID uint32) {
+	if _, err := o.CallOPCMethod(ctx, conn, ua.ObjectTypeIDConditionType, ua.MethodIDConditionTypeConditionRefresh, subscriptionID); err != nil {
+		o.logger.Warn("ConditionRefresh failed, active alarms are restored from new events only", "UUID", id, "error", err)
+	}
+}
+
+// handleAlarmEvents обновляет активные тревоги станка по событиям и отправляет в Kafka появление и снятие тревог
+func (o *OpcCommunicator) handleAlarmEvents(id uuid.UUID, events ua.EventNotificationList) {
+	var changes []alarmChange
+
+	o.mu.Lock()
+	state, ok := o.alarms[id]
+	if !ok {
+		state = &alarmState{active: make(map[string]models.AlarmsResponse)}
+		o.alarms[id] = state
+	}
+	for _, event := range events.Events {
+		if event.ClientHandle != alarmClientHandle || len(event.EventFields) < alarmFieldCount {
+			continue
+		}
+
+		switch event.EventFields[alarmFieldEventType] {
+		case ua.ObjectTypeIDRefreshStartEventType:
+			state.refreshed = make(map[string]bool)
+			continue
+		case ua.ObjectTypeIDRefreshEndEventType:
+			// Тревоги, о которых сервер не сообщил при обновлении, сняты, пока сессии не было
+			if state.refreshed != nil {
+				for key, alarm := range state.active {
+					if !state.refreshed[key] {
+						delete(state.active, key)
+						alarm.Active = false
+						changes = append(changes, alarmChange{models.AlarmCleared, alarm})
+					}
+				}
+			}
+			state.refreshed = nil
+			continue
+		}
+
+		alarm := alarmFromEvent(event.EventFields)
+		if state.refreshed != nil {
+			state.refreshed[alarm.ConditionID] = true
+		}
+		_, wasActive := state.active[alarm.ConditionID]
+		switch {
+		case alarm.Active:
+			state.active[alarm.ConditionID] = alarm
+			if !wasActive {
+				changes = append(changes, alarmChange{models.AlarmRaised, alarm})
+			}
+		case wasActive:
+			delete(state.active, alarm.ConditionID)
+			changes = append(changes, alarmChange{models.AlarmCleared, alarm})
+		}
+	}
+	o.mu.Unlock()
+
+	o.publishAlarmEvents(id, changes)
+}
+
+// alarmFromEvent собирает тревогу из полей события. Если сервер не вернул ConditionId,
+// тревога определяется источником и именем условия
+func alarmFromEvent(fields []ua.Variant) models.AlarmsResponse {
+	alarm := models.AlarmsResponse{}
+	if eventType, ok := fields[alarmFieldEventType].(ua.NodeID); ok {
+		alarm.EventType = fmt.Sprint(eventType)
+	}
+	if source, ok := fields[alarmFieldSourceNode].(ua.NodeID); ok {
+		alarm.SourceNode = fmt.Sprint(source)
+	}
+	alarm.SourceName, _ = fields[alarmFieldSourceName].(string)
+	alarm.Time, _ = fields[alarmFieldTime].(time.Time)
+	if message, ok := fields[alarmFieldMessage].(ua.LocalizedText); ok {
+		alarm.Message = message.Text
+	}
+	alarm.Severity, _ = fields[alarmFieldSeverity].(uint16)
+	alarm.ConditionName, _ = fields[alarmFieldConditionName].(string)
+	alarm.Active, _ = fields[alarmFieldActiveState].(bool)
+	alarm.Acked, _ = fields[alarmFieldAckedState].(bool)
+	if conditionID, ok := fields[alarmFieldConditionID].(ua.NodeID); ok && conditionID != nil {
+		alarm.ConditionID = fmt.Sprint(conditionID)
+	} else {
+		alarm.ConditionID = alarm.SourceNode + "/" + alarm.ConditionName
+	}
+	if alarm.Time.IsZero() {
+		alarm.Time = time.Now()
+	}
+	return alarm
+}
+
+// publishAlarmEvents отправляет появление и снятие тревог одного уведомления в топик тревог Kafka одним запросом,
+// чтобы цикл публикации подписки не ждал отдельной записи на каждую тревогу
+func (o *OpcCommunicator) publishAlarmEvents(id uuid.UUID, changes []alarmChange) {
+	if len(changes) == 0 {
+		return
+	}
+	o.mu.Lock()
+	machineID := o.machineIDs[id]
+	o.mu.Unlock()
+
+	payloads := make([][]byte, 0, len(changes))
+	published := make([]alarmChange, 0, len(changes))
+	for _, c := range changes {
+		payload, err := json.Marshal(models.AlarmEvent{
+			MachineUUID: id,
+			MachineId:   machineID,
+			Event:       c.event,
+			Timestamp:   c.alarm.Time.UnixMilli(),
+			Alarm:       c.alarm,
+		})
+		if err != nil {
+			o.logger.Error("Failed to marshal alarm event", "UUID", id, "conditionID", c.alarm.ConditionID, "error", err)
+			continue
+		}
+		payloads = append(payloads, payload)
+		published = append(published, c)
+	}
+	if len(payloads) == 0 {
+		return
+	}
+	if err := o.producer.ProduceAlarms(context.Background(), []byte(id.String()), payloads); err != nil {
+		o.logger.Error("Failed to send alarm events to Kafka", "UUID", id, "events", len(payloads), "error", err)
+		return
+	}
+	for _, c := range published {
+		o.logger.Info("Alarm event published", "UUID", id, "event", c.event, "conditionID", c.alarm.ConditionID, "severity", c.alarm.Severity)
+	}
+}
+
+// applyAlarms добавляет в снимок станка активные тревоги, самые серьёзные первыми.
+// AlarmStatus - сообщение самой серьёзной тревоги
+func (o *OpcCommunicator) applyAlarms(id uuid.UUID, resp *models.MachineDataResponse) {
+	o.mu.Lock()
+	state, ok := o.alarms[id]
+	var alarms []models.AlarmsResponse
+	if ok {
+		alarms = make([]models.AlarmsResponse, 0, len(state.active))
+		for _, alarm := range state.active {
+			alarms = append(alarms, alarm)
+		}
+	}
+	o.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	sort.Slice(alarms, func(a, b int) bool {
+		if alarms[a].Severity != alarms[b].Severity {
+			return alarms[a].Severity > alarms[b].Severity
+		}
+		return alarms[a].Time.After(alarms[b].Time)
+	})
+	resp.Alarms = alarms
+	resp.HasAlarms = len(alarms) > 0
+	if resp.HasAlarms {
+		resp.AlarmStatus = alarms[0].Message
+	}
+}
+
+// clearAlarms забывает тревоги станка после остановки сбора данных
+func (o *OpcCommunicator) clearAlarms(id uuid.UUID) {
+	o.mu.Lock()
+	delete(o.alarms, id)
+	o.mu.Unlock()
+}
@@ -0,0 +1,112 @@
+package opc_communicator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/internal/middleware/logging"
+	"testing"
+	"time"
+)
+
+// alarmProducer запоминает отправленные пакеты тревог
+type alarmProducer struct {
+	batches [][][]byte
+	err     error
+}
+
+func (p *alarmProducer) Produce(context.Context, []byte, []byte) error { return nil }
+
+func (p *alarmProducer) ProduceAlarms(_ context.Context, _ []byte, values [][]byte) error {
+	p.batches = append(p.batches, values)
+	return p.err
+}
+
+func (p *alarmProducer) Close() error { return nil }
+
+func alarmEvent(condition string, active bool) ua.EventFieldList {
+	fields := make([]ua.Variant, alarmFieldCount)
+	fields[alarmFieldEventType] = ua.ObjectTypeIDAlarmConditionType
+	fields[alarmFieldSourceNode] = ua.NewNodeIDNumeric(2, 100)
+	fields[alarmFieldTime] = time.Now()
+	fields[alarmFieldSeverity] = uint16(500)
+	fields[alarmFieldConditionName] = condition
+	fields[alarmFieldActiveState] = active
+	fields[alarmFieldConditionID] = ua.NewNodeIDString(2, condition)
+	return ua.EventFieldList{ClientHandle: alarmClientHandle, EventFields: fields}
+}
+
+func newAlarmCommunicator(producer *alarmProducer) *OpcCommunicator {
+	return &OpcCommunicator{
+		alarms:     make(map[uuid.UUID]*alarmState),
+		machineIDs: make(map[uuid.UUID]string),
+		producer:   producer,
+		logger:     logging.NewLogger(&logging.Config{}, "", "test"),
+	}
+}
+
+func TestHandleAlarmEventsBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  []ua.EventFieldList
+		batches int
+		sent    []models.AlarmEventTypeEnum
+	}{
+		{
+			name:    "все тревоги уведомления одним пакетом",
+			events:  []ua.EventFieldList{alarmEvent("Overheat", true), alarmEvent("DoorOpen", true), alarmEvent("LowOil", false)},
+			batches: 1,
+			sent:    []models.AlarmEventTypeEnum{models.AlarmRaised, models.AlarmRaised},
+		},
+		{
+			name:   "без изменений тревог Kafka не вызывается",
+			events: []ua.EventFieldList{alarmEvent("LowOil", false)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &alarmProducer{}
+			id := uuid.New()
+			newAlarmCommunicator(producer).handleAlarmEvents(id, ua.EventNotificationList{Events: tt.events})
+
+			if len(producer.batches) != tt.batches {
+				t.Fatalf("отправлено пакетов %d, ожидалось %d", len(producer.batches), tt.batches)
+			}
+			if tt.batches == 0 {
+				return
+			}
+			batch := producer.batches[0]
+			if len(batch) != len(tt.sent) {
+				t.Fatalf("в пакете %d событий, ожидалось %d", len(batch), len(tt.sent))
+			}
+			for i, payload := range batch {
+				var event models.AlarmEvent
+				if err := json.Unmarshal(payload, &event); err != nil {
+					t.Fatalf("событие %d: %v", i, err)
+				}
+				if event.Event != tt.sent[i] || event.MachineUUID != id {
+					t.Fatalf("событие %d = %s для %s, ожидалось %s для %s", i, event.Event, event.MachineUUID, tt.sent[i], id)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleAlarmEventsProducerError(t *testing.T) {
+	producer := &alarmProducer{err: errors.New("broker unavailable")}
+	oc := newAlarmCommunicator(producer)
+	id := uuid.New()
+
+	oc.handleAlarmEvents(id, ua.EventNotificationList{Events: []ua.EventFieldList{alarmEvent("Overheat", true)}})
+
+	// Ошибка Kafka не теряет тревогу в снимке станка
+	if _, ok := oc.alarms[id].active["ns=2;s=Overheat"]; !ok {
+		t.Fatalf("активные тревоги = %v, ожидалась Overheat", oc.alarms[id].active)
+	}
+	if len(producer.batches) != 1 {
+		t.Fatalf("отправлено пакетов %d, ожидался 1", len(producer.batches))
+	}
+}
@@ -0,0 +1,251 @@
+package opc_communicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/opc_custom"
+	"time"
+)
+
+// Параметры обхода адресного пространства
+const (
+	browseTimeout         = 30 * time.Second
+	browseNodesPerRequest = 100 // узлов в одном BrowseRequest
+	browseMaxRefsPerNode  = 500 // RequestedMaxReferencesPerNode, остальное догружается через BrowseNext
+	browseMaxNextRequests = 1000
+	browseValueAttributes = 2 // DataType и Value для каждой переменной
+	namespaceArrayNodeID  = 2255
+)
+
+// builtinDataTypeNames - имена стандартных типов данных пространства имён 0
+var builtinDataTypeNames = map[uint32]string{
+	1: "Boolean", 2: "SByte", 3: "Byte", 4: "Int16", 5: "UInt16", 6: "Int32", 7: "UInt32", 8: "Int64", 9: "UInt64",
+	10: "Float", 11: "Double", 12: "String", 13: "DateTime", 14: "Guid", 15: "ByteString", 16: "XmlElement",
+	17: "NodeId", 18: "ExpandedNodeId", 19: "StatusCode", 20: "QualifiedName", 21: "LocalizedText", 22: "Structure",
+	23: "DataValue", 24: "BaseDataType", 25: "DiagnosticInfo", 26: "Number", 27: "Integer", 28: "UInteger",
+	29: "Enumeration", 290: "Duration", 294: "UtcTime", 295: "LocaleId", 884: "Range", 887: "EUInformation",
+}
+
+// browseItem - узел, ожидающий обхода своих ссылок
+type browseItem struct {
+	nodeID ua.NodeID
+	node   *models.BrowseNode // nil для начального узла
+}
+
+// Browse обходит адресное пространство станка от начального узла на заданную глубину.
+// Большие папки догружаются через BrowseNext по continuation point; при достижении MaxNodes
+// оставшиеся continuation point освобождаются, а ответ помечается как усечённый.
+func (oc *OpcCommunicator) Browse(id uuid.UUID, req models.BrowseRequest) (*models.BrowseResponse, error) {
+	if err := req.Normalize(); err != nil {
+		return nil, err
+	}
+	startNodeID, _ := req.StartNodeID()
+	referenceTypeID, _ := req.ReferenceTypeID()
+	direction, _ := req.Direction.ToUA()
+
+	connInfo, conn, err := oc.session(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(connInfo.Ctx, browseTimeout)
+	defer cancel()
+
+	namespaceURIs := oc.readNamespaceArray(ctx, conn)
+
+	resp := &models.BrowseResponse{
+		StartNodeID: fmt.Sprint(startNodeID),
+		Depth:       req.Depth,
+		Nodes:       []*models.BrowseNode{},
+	}
+	visited := map[string]bool{fmt.Sprint(startNodeID): true}
+	var variables []*models.BrowseNode
+
+	level := []browseItem{{nodeID: startNodeID}}
+	for depth := 1; depth <= req.Depth && len(level) > 0 && !resp.Truncated; depth++ {
+		var next []browseItem
+		for start := 0; start < len(level) && !resp.Truncated; start += browseNodesPerRequest {
+			chunk := level[start:min(start+browseNodesPerRequest, len(level))]
+
+			descriptions := make([]ua.BrowseDescription, 0, len(chunk))
+			for _, item := range chunk {
+				descriptions = append(descriptions, ua.BrowseDescription{
+					NodeID:          item.nodeID,
+					BrowseDirection: direction,
+					ReferenceTypeID: referenceTypeID,
+					IncludeSubtypes: *req.IncludeSubtypes,
+					ResultMask:      uint32(ua.BrowseResultMaskAll),
+				})
+			}
+
+			refsByItem, truncated, err := oc.browseAll(ctx, conn, descriptions, req.MaxNodes-resp.NodeCount)
+			if err != nil {
+				return nil, err
+			}
+			resp.Truncated = resp.Truncated || truncated
+
+			for i, refs := range refsByItem {
+				for _, ref := range refs {
+					nodeID := ua.ToNodeID(ref.NodeID, namespaceURIs)
+					if nodeID == nil {
+						continue
+					}
+					if resp.NodeCount >= req.MaxNodes {
+						resp.Truncated = true
+						break
+					}
+					node := &models.BrowseNode{
+						NodeID:        fmt.Sprint(nodeID),
+						BrowseName:    ref.BrowseName.String(),
+						DisplayName:   ref.DisplayName.Text,
+						NodeClass:     ref.NodeClass.String(),
+						ReferenceType: fmt.Sprint(ref.ReferenceTypeID),
+					}
+					resp.NodeCount++
+
+					parent := chunk[i].node
+					if parent == nil {
+						resp.Nodes = append(resp.Nodes, node)
+					} else {
+						parent.Children = append(parent.Children, node)
+					}
+					if ref.NodeClass == ua.NodeClassVariable {
+						variables = append(variables, node)
+					}
+
+					// Узел, достижимый по нескольким ссылкам, раскрывается только один раз
+					if !visited[node.NodeID] {
+						visited[node.NodeID] = true
+						next = append(next, browseItem{nodeID: nodeID, node: node})
+					}
+				}
+			}
+		}
+		level = next
+	}
+
+	if *req.ReadValues && len(variables) > 0 {
+		oc.fillVariableValues(ctx, id, connInfo, conn, variables)
+	}
+	return resp, nil
+}
+
+// browseAll выполняет Browse и догружает ссылки через BrowseNext, пока не кончатся continuation point
+// или не будет набрано limit ссылок. Возвращает ссылки по каждому описанию и признак усечения.
+func (oc *OpcCommunicator) browseAll(ctx context.Context, conn *client.Client, descriptions []ua.BrowseDescription, limit int) ([][]ua.ReferenceDescription, bool, error) {
+	res, err := conn.Browse(ctx, &ua.BrowseRequest{
+		RequestedMaxReferencesPerNode: browseMaxRefsPerNode,
+		NodesToBrowse:                 descriptions,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("browse request failed: %w", err)
+	}
+	if len(res.Results) != len(descriptions) {
+		return nil, false, fmt.Errorf("browse returned %d results for %d nodes", len(res.Results), len(descriptions))
+	}
+
+	refs := make([][]ua.ReferenceDescription, len(descriptions))
+	pending := map[int]ua.ByteString{}
+	total := 0
+	for i, result := range res.Results {
+		if result.StatusCode.IsBad() {
+			oc.logger.Warn("Browse failed for node", "nodeID", descriptions[i].NodeID, "status", opc_custom.StatusCodeName(result.StatusCode))
+			continue
+		}
+		refs[i] = result.References
+		total += len(result.References)
+		if len(result.ContinuationPoint) > 0 {
+			pending[i] = result.ContinuationPoint
+		}
+	}
+
+	for requests := 0; len(pending) > 0; requests++ {
+		indexes := make([]int, 0, len(pending))
+		points := make([]ua.ByteString, 0, len(pending))
+		for i, cp := range pending {
+			indexes = append(indexes, i)
+			points = append(points, cp)
+		}
+
+		// Лимит достигнут: освобождаем continuation point на сервере
+		if total >= limit || requests >= browseMaxNextRequests {
+			_, _ = conn.BrowseNext(ctx, &ua.BrowseNextRequest{ReleaseContinuationPoints: true, ContinuationPoints: points})
+			return refs, true, nil
+		}
+
+		next, err := conn.BrowseNext(ctx, &ua.BrowseNextRequest{ContinuationPoints: points})
+		if err != nil {
+			return nil, false, fmt.Errorf("browse next request failed: %w", err)
+		}
+		pending = map[int]ua.ByteString{}
+		for k, result := range next.Results {
+			if k >= len(indexes) {
+				break
+			}
+			i := indexes[k]
+			if result.StatusCode.IsBad() {
+				oc.logger.Warn("Browse next failed for node", "nodeID", descriptions[i].NodeID, "status", opc_custom.StatusCodeName(result.StatusCode))
+				continue
+			}
+			refs[i] = append(refs[i], result.References...)
+			total += len(result.References)
+			if len(result.ContinuationPoint) > 0 {
+				pending[i] = result.ContinuationPoint
+			}
+		}
+	}
+	return refs, total > limit, nil
+}
+
+// fillVariableValues читает DataType и текущее значение переменных пакетами в пределах MaxNodesPerRead
+func (oc *OpcCommunicator) fillVariableValues(ctx context.Context, id uuid.UUID, connInfo *models.ConnectionInfo, conn *client.Client, variables []*models.BrowseNode) {
+	toRead := make([]ua.ReadValueID, 0, len(variables)*browseValueAttributes)
+	for _, node := range variables {
+		nodeID := ua.ParseNodeID(node.NodeID)
+		toRead = append(toRead,
+			ua.ReadValueID{NodeID: nodeID, AttributeID: ua.AttributeIDDataType},
+			ua.ReadValueID{NodeID: nodeID, AttributeID: ua.AttributeIDValue},
+		)
+	}
+
+	results, err := oc.readAttributes(ctx, conn, toRead, oc.maxNodesPerRead(ctx, id, connInfo))
+	if err != nil {
+		oc.logger.Warn("Failed to read values of browsed variables", "UUID", id, "error", err)
+		return
+	}
+
+	for i, node := range variables {
+		dataType, value := results[i*browseValueAttributes], results[i*browseValueAttributes+1]
+		if dataTypeID, ok := dataType.Value.(ua.NodeID); ok && dataType.StatusCode.IsGood() {
+			node.DataType = dataTypeName(dataTypeID)
+		}
+		node.StatusCode = opc_custom.StatusCodeName(value.StatusCode)
+		if !value.StatusCode.IsBad() {
+			node.Value = value.Value
+		}
+	}
+}
+
+// readNamespaceArray читает таблицу пространств имён сервера для преобразования ExpandedNodeID
+func (oc *OpcCommunicator) readNamespaceArray(ctx context.Context, conn *client.Client) []string {
+	results, err := oc.readNodeValues(ctx, conn, []ua.NodeID{ua.NewNodeIDNumeric(0, namespaceArrayNodeID)}, 1)
+	if err != nil || results[0].StatusCode.IsBad() {
+		return nil
+	}
+	uris, _ := results[0].Value.([]string)
+	return uris
+}
+
+// dataTypeName возвращает имя стандартного типа данных или NodeID для пользовательских типов
+func dataTypeName(nodeID ua.NodeID) string {
+	if numeric, ok := nodeID.(ua.NodeIDNumeric); ok && numeric.NamespaceIndex == 0 {
+		if name, ok := builtinDataTypeNames[numeric.ID]; ok {
+			return name
+		}
+	}
+	return fmt.Sprint(nodeID)
+}
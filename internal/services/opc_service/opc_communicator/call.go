@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"github.com/awcullen/opcua/client"
 	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/errors"
+	"opc_ua_service/pkg/opc_custom"
 )
 
 // CallOPCMethod вызывает метод на OPC UA сервере
@@ -30,8 +34,132 @@ func (oc *OpcCommunicator) CallOPCMethod(ctx context.Context, c *client.Client,
 
 	result := resp.Results[0]
 	if !ua.StatusCode.IsGood(result.StatusCode) {
-		return nil, fmt.Errorf("method call failed: %s", result.StatusCode)
+		return nil, fmt.Errorf("%w: %s: %w", errors.ErrMethodCallFailed, opc_custom.StatusCodeName(result.StatusCode), result.StatusCode)
 	}
 
 	return result.OutputArguments, nil
 }
+
+// CallMethod вызывает метод на объекте станка. Входные аргументы проверяются по свойству InputArguments метода
+// и приводятся к типам аргументов, выходные возвращаются с именами и типами из OutputArguments.
+func (oc *OpcCommunicator) CallMethod(id uuid.UUID, req models.MethodCallRequest) (*models.MethodCallResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidArguments, err)
+	}
+	objectID, methodID := ua.ParseNodeID(req.ObjectID), ua.ParseNodeID(req.MethodID)
+
+	connInfo, conn, err := oc.session(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(connInfo.Ctx, nodeAccessTimeout)
+	defer cancel()
+
+	inputArgs, outputArgs, err := oc.methodArguments(ctx, conn, methodID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Arguments) != len(inputArgs) {
+		return nil, fmt.Errorf("%w: method expects %d arguments, got %d", errors.ErrInvalidArguments, len(inputArgs), len(req.Arguments))
+	}
+	inputs := make([]ua.Variant, len(inputArgs))
+	for i, arg := range inputArgs {
+		value, err := coerceArgument(req.Arguments[i], arg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: argument %d (%s): %v", errors.ErrInvalidArguments, i, arg.Name, err)
+		}
+		inputs[i] = value
+	}
+
+	outputs, err := oc.CallOPCMethod(ctx, conn, objectID, methodID, inputs...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.MethodCallResponse{
+		ObjectID:   fmt.Sprint(objectID),
+		MethodID:   fmt.Sprint(methodID),
+		StatusCode: opc_custom.StatusCodeName(ua.Good),
+		Outputs:    make([]models.MethodArgument, 0, len(outputs)),
+	}
+	for i, output := range outputs {
+		arg := models.MethodArgument{Value: jsonValue(output)}
+		if i < len(outputArgs) {
+			arg.Name = outputArgs[i].Name
+			arg.DataType = dataTypeName(outputArgs[i].DataType)
+		}
+		resp.Outputs = append(resp.Outputs, arg)
+	}
+
+	oc.logger.Info("Method called", "UUID", id, "objectID", resp.ObjectID, "methodID", resp.MethodID)
+	return resp, nil
+}
+
+// methodArguments читает свойства InputArguments и OutputArguments метода. Отсутствующее свойство означает,
+// что у метода нет аргументов.
+func (oc *OpcCommunicator) methodArguments(ctx context.Context, conn *client.Client, methodID ua.NodeID) ([]ua.Argument, []ua.Argument, error) {
+	properties := []string{"InputArguments", "OutputArguments"}
+	paths := make([]ua.BrowsePath, 0, len(properties))
+	for _, name := range properties {
+		paths = append(paths, ua.BrowsePath{
+			StartingNode: methodID,
+			RelativePath: ua.RelativePath{Elements: []ua.RelativePathElement{{
+				ReferenceTypeID: ua.ReferenceTypeIDHasProperty,
+				TargetName:      ua.NewQualifiedName(0, name),
+			}}},
+		})
+	}
+
+	res, err := conn.TranslateBrowsePathsToNodeIDs(ctx, &ua.TranslateBrowsePathsToNodeIDsRequest{BrowsePaths: paths})
+	if err != nil {
+		return nil, nil, fmt.Errorf("translate browse paths failed: %w", err)
+	}
+	if len(res.Results) != len(paths) {
+		return nil, nil, fmt.Errorf("translate browse paths returned %d results for %d paths", len(res.Results), len(paths))
+	}
+
+	args := make([][]ua.Argument, len(properties))
+	for i, result := range res.Results {
+		if result.StatusCode.IsBad() || len(result.Targets) == 0 {
+			continue
+		}
+		values, err := oc.readNodeValues(ctx, conn, []ua.NodeID{ua.ToNodeID(result.Targets[0].TargetID, nil)}, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if values[0].StatusCode.IsBad() {
+			return nil, nil, fmt.Errorf("read %s: %w", properties[i], values[0].StatusCode)
+		}
+		objects, _ := values[0].Value.([]ua.ExtensionObject)
+		for _, obj := range objects {
+			switch arg := obj.(type) {
+			case ua.Argument:
+				args[i] = append(args[i], arg)
+			case *ua.Argument:
+				args[i] = append(args[i], *arg)
+			default:
+				return nil, nil, fmt.Errorf("unexpected %s element type %T", properties[i], obj)
+			}
+		}
+	}
+	return args[0], args[1], nil
+}
+
+// coerceArgument проверяет размерность значения по ValueRank аргумента и приводит его к DataType
+func coerceArgument(value any, arg ua.Argument) (ua.Variant, error) {
+	_, isArray := value.([]any)
+	switch {
+	case arg.ValueRank == ua.ValueRankScalar && isArray:
+		return nil, fmt.Errorf("scalar value expected")
+	case arg.ValueRank >= ua.ValueRankOneOrMoreDimensions && !isArray:
+		return nil, fmt.Errorf("array value expected")
+	case arg.ValueRank > ua.ValueRankOneDimension:
+		return nil, fmt.Errorf("multidimensional arrays are not supported")
+	}
+	if value == nil {
+		return nil, fmt.Errorf("value is required")
+	}
+	return coerceValue(value, nil, arg.DataType)
+}
@@ -3,11 +3,12 @@ package opc_communicator
 import (
 	"context"
 	"fmt"
-	"github.com/awcullen/opcua/ua"
+	"github.com/awcullen/opcua/client"
 	"github.com/google/uuid"
-	_ "opc_ua_service/internal/domain/models"
+	"opc_ua_service/internal/domain/models"
 	"opc_ua_service/internal/interfaces"
 	"opc_ua_service/internal/middleware/logging"
+	"opc_ua_service/pkg/errors"
 	"opc_ua_service/pkg/opc_custom"
 	"sync"
 )
@@ -16,6 +17,11 @@ import (
 type OpcCommunicator struct {
 	connector     interfaces.OpcConnectorService
 	pollCancelMap map[uuid.UUID]context.CancelFunc
+	readLimits    map[uuid.UUID]readLimit
+	namespaces    map[uuid.UUID]namespaceTable
+	backfillJobs  map[uuid.UUID]*models.BackfillJob // последняя задача дозаполнения по каждому станку
+	alarms        map[uuid.UUID]*alarmState         // активные тревоги станков, пока идёт сбор данных
+	machineIDs    map[uuid.UUID]string              // серийные номера станков из последних снимков
 	producer      interfaces.KafkaService
 	mu            sync.Mutex
 	logger        *logging.Logger
@@ -26,72 +32,125 @@ func NewOpcCommunicator(connector interfaces.OpcConnectorService, producer inter
 	return &OpcCommunicator{
 		connector:     connector,
 		pollCancelMap: make(map[uuid.UUID]context.CancelFunc),
+		readLimits:    make(map[uuid.UUID]readLimit),
+		namespaces:    make(map[uuid.UUID]namespaceTable),
+		backfillJobs:  make(map[uuid.UUID]*models.BackfillJob),
+		alarms:        make(map[uuid.UUID]*alarmState),
+		machineIDs:    make(map[uuid.UUID]string),
 		producer:      producer,
 		logger:        logger.WithPrefix("COMMUNICATOR"),
 	}
 }
 
+// session возвращает ConnectionInfo и клиент установленной сессии станка
+func (oc *OpcCommunicator) session(id uuid.UUID) (*models.ConnectionInfo, *client.Client, error) {
+	connInfo, err := oc.connector.GetConnectionInfoByUUID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	connInfo.Mu.RLock()
+	conn := connInfo.Conn
+	connInfo.Mu.RUnlock()
+	return connInfo, conn, nil
+}
+
 func (oc *OpcCommunicator) ReadMachineData(id uuid.UUID) (interfaces.MachineData, error) {
 	connInfo, err := oc.connector.GetConnectionInfoByUUID(id)
 	if err != nil {
 		return nil, fmt.Errorf("connection not found: %w", err)
 	}
+	return oc.readMachineData(id, connInfo, interfaces.ResolveMachineData(id, connInfo.Manufacturer, connInfo.Model))
+}
 
-	// Создаём объект данных машины через фабрику
-	machine := interfaces.MachineDataFactory(connInfo.Manufacturer, connInfo.Model)
-	if machine == nil {
+// readMachineData читает все узлы модели станка в новый объект данных, созданный newMachine
+func (oc *OpcCommunicator) readMachineData(id uuid.UUID, connInfo *models.ConnectionInfo, newMachine interfaces.MachineDataConstructor) (interfaces.MachineData, error) {
+	if newMachine == nil {
 		return nil, fmt.Errorf("unsupported machine type: %s %s", connInfo.Manufacturer, connInfo.Model)
 	}
+	machine := newMachine()
 
-	// Список NodeID, которые нужно читать для этой машины
-	nodeIDs := machine.GetRelevantNodeIDs()
-	if len(nodeIDs) == 0 {
-		return nil, fmt.Errorf("no nodes defined for machine %s %s", connInfo.Manufacturer, connInfo.Model)
+	// Узлы модели в индексах пространств имён текущей сессии
+	nodes, err := oc.resolveModelNodes(connInfo.Ctx, id, connInfo, machine)
+	if err != nil {
+		oc.connector.ReportReadResult(id, err)
+		return nil, fmt.Errorf("failed to resolve machine nodes: %w", err)
 	}
 
-	// Считываем значение каждого узла
-	for _, nodeID := range nodeIDs {
-		val, err := oc.readNodeValue(connInfo.Ctx, connInfo.Conn, nodeID)
-		if err != nil {
-			oc.logger.Error("Failed to read node %s: %v", nodeID, err)
+	// Читаем все узлы пакетами в пределах MaxNodesPerRead сервера
+	connInfo.Mu.RLock()
+	conn := connInfo.Conn
+	connInfo.Mu.RUnlock()
+
+	results, err := oc.readNodeValues(connInfo.Ctx, conn, nodes.nodeIDs, oc.maxNodesPerRead(connInfo.Ctx, id, connInfo))
+	if err != nil {
+		oc.connector.ReportReadResult(id, err)
+		return nil, fmt.Errorf("failed to read machine data: %w", err)
+	}
+
+	// Сопоставляем результаты с узлами, у каждого свой StatusCode
+	var failed int
+	var readErr error
+	for i, key := range nodes.keys {
+		result := results[i]
+		machine.RecordSample(key, result)
+		if result.StatusCode.IsBad() {
+			oc.logger.Error("Failed to read node", "nodeID", key, "status", result.StatusCode)
+			failed++
+			readErr = result.StatusCode
 			continue
 		}
 
 		// Декодируем значение в структуру
-		if err := machine.ConvertNodeToMachineData(nodeID.String(), val); err != nil {
-			oc.logger.Error("Failed to convert node %s: %v", nodeID, err)
+		if err := machine.ConvertNodeToMachineData(key, result.Value); err != nil {
+			oc.logger.Error("Failed to convert node", "UUID", id, "nodeID", key, "error", err)
 			continue
 		}
 	}
 
+	// Ошибки чтения при живой сессии переводят соединение в Degraded
+	if failed > 0 {
+		oc.connector.ReportReadResult(id, fmt.Errorf("failed to read %d of %d nodes: %w", failed, len(nodes.nodeIDs), readErr))
+	} else {
+		oc.connector.ReportReadResult(id, nil)
+	}
+
 	return machine, nil
 }
 
+// GetControlProgramInfo читает стек выполнения управляющей программы. Модели без узла стека выполнения
+// возвращают ErrUnsupportedForModel
 func (oc *OpcCommunicator) GetControlProgramInfo(id uuid.UUID) ([]opc_custom.ProgramPositionDataType, error) {
 	connInfo, err := oc.connector.GetConnectionInfoByUUID(id)
 	if err != nil {
 		return nil, fmt.Errorf("connection not found: %w", err)
 	}
-	nodeID := ua.NewNodeIDNumeric(1, 100006)
 
-	machine := interfaces.MachineDataFactory(connInfo.Manufacturer, connInfo.Model)
+	machine := interfaces.MachineDataFactory(id, connInfo.Manufacturer, connInfo.Model)
 	if machine == nil {
 		return nil, fmt.Errorf("unsupported machine type: %s %s", connInfo.Manufacturer, connInfo.Model)
 	}
+	stackMachine, ok := machine.(interfaces.ExecutionStackMachineData)
+	if !ok {
+		return nil, fmt.Errorf("%w: execution stack of %s %s", errors.ErrUnsupportedForModel, connInfo.Manufacturer, connInfo.Model)
+	}
+	programNode := stackMachine.ExecutionStackNode()
 
-	val, err := oc.readNodeValue(connInfo.Ctx, connInfo.Conn, nodeID)
+	nodeID, err := oc.resolveModelNode(connInfo.Ctx, id, connInfo, programNode)
 	if err != nil {
-		oc.logger.Error("Failed to read node %s: %v", nodeID, err)
+		return nil, fmt.Errorf("failed to resolve execution stack node: %w", err)
 	}
+	connInfo.Mu.RLock()
+	conn := connInfo.Conn
+	connInfo.Mu.RUnlock()
 
-	// Декодируем значение в структуру
-	if err := machine.ConvertNodeToMachineData(nodeID.String(), val); err != nil {
-		oc.logger.Error("Failed to convert node %s: %v", nodeID, err)
-	}
-	data, err := machine.GetExecutionStack()
+	val, err := oc.readNodeValue(connInfo.Ctx, conn, nodeID)
 	if err != nil {
-		oc.logger.Error("Failed to get execution stack: %v", err)
+		return nil, fmt.Errorf("failed to read execution stack: %w", err)
 	}
 
-	return data, nil
+	// Декодируем значение в структуру
+	if err := machine.ConvertNodeToMachineData(programNode.String(), val); err != nil {
+		return nil, fmt.Errorf("failed to convert execution stack: %w", err)
+	}
+	return machine.GetExecutionStack()
 }
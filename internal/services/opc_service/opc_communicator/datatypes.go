@@ -0,0 +1,259 @@
+package opc_communicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/opc_custom"
+	"reflect"
+)
+
+// defaultBinaryEncoding - BrowseName кодировки структуры, в которой её передаёт бинарный протокол
+const defaultBinaryEncoding = "Default Binary"
+
+// dataTypeResolver строит Go-типы структур сервера по DataTypeDefinition, а для серверов OPC UA 1.03 -
+// по словарю типов кодировки Default Binary
+type dataTypeResolver struct {
+	oc           *OpcCommunicator
+	ctx          context.Context
+	conn         *client.Client
+	uris         []string
+	resolving    map[string]bool
+	dictionaries map[string]*opc_custom.TypeDictionary
+}
+
+// registerNodeDataTypes читает DataType узлов и регистрирует декодеры для структур, которых нет в opc_custom,
+// чтобы значения узлов пришли в виде map[string]any. Ошибки не прерывают чтение: такие значения останутся пустыми
+func (oc *OpcCommunicator) registerNodeDataTypes(ctx context.Context, id uuid.UUID, connInfo *models.ConnectionInfo, conn *client.Client, nodeIDs []ua.NodeID) {
+	toRead := make([]ua.ReadValueID, len(nodeIDs))
+	for i, nodeID := range nodeIDs {
+		toRead[i] = ua.ReadValueID{NodeID: nodeID, AttributeID: ua.AttributeIDDataType}
+	}
+	results, err := oc.readAttributes(ctx, conn, toRead, oc.maxNodesPerRead(ctx, id, connInfo))
+	if err != nil {
+		oc.logger.Warn("Failed to read node data types", "UUID", id, "error", err)
+		return
+	}
+	table, err := oc.namespaceTable(ctx, id, connInfo)
+	if err != nil {
+		oc.logger.Warn("Failed to read namespace table for data types", "UUID", id, "error", err)
+		return
+	}
+
+	resolver := &dataTypeResolver{
+		oc:           oc,
+		ctx:          ctx,
+		conn:         conn,
+		uris:         table.uris,
+		resolving:    make(map[string]bool),
+		dictionaries: make(map[string]*opc_custom.TypeDictionary),
+	}
+	seen := make(map[string]bool)
+	for _, result := range results {
+		dataTypeID, ok := result.Value.(ua.NodeID)
+		if !ok || result.StatusCode.IsBad() {
+			continue
+		}
+		if numeric, ok := dataTypeID.(ua.NodeIDNumeric); ok && numeric.NamespaceIndex == 0 {
+			continue // стандартные типы декодирует awcullen/opcua
+		}
+		key := resolver.key(dataTypeID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if _, err := resolver.goType(dataTypeID); err != nil {
+			oc.logger.Warn("Failed to build decoder for data type", "UUID", id, "dataType", key, "error", err)
+		}
+	}
+}
+
+// key - идентификатор типа данных с URI пространства имён, не зависящий от индексов сессии
+func (r *dataTypeResolver) key(dataTypeID ua.NodeID) string {
+	return ua.ToExpandedNodeID(dataTypeID, r.uris).String()
+}
+
+// goType возвращает Go-тип значения типа данных сервера
+func (r *dataTypeResolver) goType(dataTypeID ua.NodeID) (reflect.Type, error) {
+	if numeric, ok := dataTypeID.(ua.NodeIDNumeric); ok && numeric.NamespaceIndex == 0 {
+		if typ, ok := opc_custom.BuiltinType(numeric.ID); ok {
+			return typ, nil
+		}
+	}
+	key := r.key(dataTypeID)
+	if typ, ok := opc_custom.FindDataType(key); ok {
+		return typ, nil
+	}
+	if r.resolving[key] {
+		return nil, fmt.Errorf("recursive data type %s", key)
+	}
+	r.resolving[key] = true
+	defer delete(r.resolving, key)
+
+	typ, err := r.resolve(dataTypeID, key)
+	if err != nil {
+		return nil, err
+	}
+	opc_custom.StoreDataType(key, typ)
+	return typ, nil
+}
+
+// resolve строит Go-тип по DataTypeDefinition. Если сервер его не поддерживает, структура ищется в словаре
+// типов, а подтип стандартного типа (например, Duration) получает тип своего родителя
+func (r *dataTypeResolver) resolve(dataTypeID ua.NodeID, key string) (reflect.Type, error) {
+	results, err := r.oc.readAttributes(r.ctx, r.conn, []ua.ReadValueID{{NodeID: dataTypeID, AttributeID: ua.AttributeIDDataTypeDefinition}}, 1)
+	if err != nil {
+		return nil, err
+	}
+	if results[0].StatusCode.IsGood() {
+		switch def := results[0].Value.(type) {
+		case ua.StructureDefinition:
+			return r.fromDefinition(key, def)
+		case *ua.StructureDefinition:
+			return r.fromDefinition(key, *def)
+		case ua.EnumDefinition, *ua.EnumDefinition:
+			return reflect.TypeOf(int32(0)), nil // перечисления кодируются как Int32
+		}
+	}
+
+	encodingID, supertypeID, err := r.references(dataTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if encodingID != nil {
+		if typ, ok := ua.FindTypeForBinaryEncodingID(ua.ToExpandedNodeID(encodingID, r.uris)); ok {
+			return typ, nil
+		}
+		return r.fromDictionary(encodingID)
+	}
+	if supertypeID == nil {
+		return nil, fmt.Errorf("data type %s has no definition", key)
+	}
+	return r.goType(supertypeID)
+}
+
+// fromDefinition строит Go-тип структуры по StructureDefinition и регистрирует его для кодировки по умолчанию
+func (r *dataTypeResolver) fromDefinition(key string, def ua.StructureDefinition) (reflect.Type, error) {
+	if def.StructureType != ua.StructureTypeStructure {
+		return nil, fmt.Errorf("%s of %s is not supported", def.StructureType, key)
+	}
+	var encodingID ua.ExpandedNodeID
+	if def.DefaultEncodingID != nil {
+		encodingID = ua.ToExpandedNodeID(def.DefaultEncodingID, r.uris)
+		if typ, ok := ua.FindTypeForBinaryEncodingID(encodingID); ok {
+			return typ, nil
+		}
+	}
+
+	fields := make([]opc_custom.StructureField, len(def.Fields))
+	for i, field := range def.Fields {
+		typ, err := r.goType(field.DataType)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		switch field.ValueRank {
+		case ua.ValueRankScalar:
+		case ua.ValueRankOneDimension:
+			typ = reflect.SliceOf(typ)
+		default:
+			return nil, fmt.Errorf("field %s: value rank %d is not supported", field.Name, field.ValueRank)
+		}
+		fields[i] = opc_custom.StructureField{Name: field.Name, Type: typ}
+	}
+
+	typ := opc_custom.NewStructureType(key, fields)
+	if def.DefaultEncodingID != nil {
+		return opc_custom.RegisterStructure(encodingID, typ)
+	}
+	return typ, nil
+}
+
+// fromDictionary строит Go-тип структуры по словарю типов: кодировка ссылается HasDescription на описание
+// с именем структуры, описание входит HasComponent в словарь
+func (r *dataTypeResolver) fromDictionary(encodingID ua.NodeID) (reflect.Type, error) {
+	descriptionID, err := r.target(encodingID, ua.ReferenceTypeIDHasDescription, ua.BrowseDirectionForward)
+	if err != nil {
+		return nil, err
+	}
+	dictionaryID, err := r.target(descriptionID, ua.ReferenceTypeIDHasComponent, ua.BrowseDirectionInverse)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := r.oc.readNodeValues(r.ctx, r.conn, []ua.NodeID{descriptionID}, 1)
+	if err != nil {
+		return nil, err
+	}
+	name, ok := values[0].Value.(string)
+	if values[0].StatusCode.IsBad() || !ok {
+		return nil, fmt.Errorf("read data type description %s: %v", fmt.Sprint(descriptionID), values[0].StatusCode)
+	}
+
+	// Словарь общий для всех структур пространства имён, читается один раз
+	dictKey := r.key(dictionaryID)
+	dict, ok := r.dictionaries[dictKey]
+	if !ok {
+		values, err := r.oc.readNodeValues(r.ctx, r.conn, []ua.NodeID{dictionaryID}, 1)
+		if err != nil {
+			return nil, err
+		}
+		data, ok := values[0].Value.(ua.ByteString)
+		if values[0].StatusCode.IsBad() || !ok {
+			return nil, fmt.Errorf("read type dictionary %s: %v", dictKey, values[0].StatusCode)
+		}
+		if dict, err = opc_custom.ParseTypeDictionary([]byte(data)); err != nil {
+			return nil, err
+		}
+		r.dictionaries[dictKey] = dict
+	}
+
+	typ, err := dict.StructureType(name)
+	if err != nil {
+		return nil, err
+	}
+	return opc_custom.RegisterStructure(ua.ToExpandedNodeID(encodingID, r.uris), typ)
+}
+
+// references возвращает кодировку Default Binary и родительский тип типа данных
+func (r *dataTypeResolver) references(dataTypeID ua.NodeID) (ua.NodeID, ua.NodeID, error) {
+	res, err := r.conn.Browse(r.ctx, &ua.BrowseRequest{
+		NodesToBrowse: []ua.BrowseDescription{
+			{NodeID: dataTypeID, BrowseDirection: ua.BrowseDirectionForward, ReferenceTypeID: ua.ReferenceTypeIDHasEncoding, ResultMask: uint32(ua.BrowseResultMaskBrowseName)},
+			{NodeID: dataTypeID, BrowseDirection: ua.BrowseDirectionInverse, ReferenceTypeID: ua.ReferenceTypeIDHasSubtype, ResultMask: uint32(ua.BrowseResultMaskBrowseName)},
+		},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("browse data type %s: %w", fmt.Sprint(dataTypeID), err)
+	}
+	if len(res.Results) != 2 {
+		return nil, nil, fmt.Errorf("browse data type %s returned %d results", fmt.Sprint(dataTypeID), len(res.Results))
+	}
+
+	var encodingID, supertypeID ua.NodeID
+	for _, ref := range res.Results[0].References {
+		if ref.BrowseName.Name == defaultBinaryEncoding {
+			encodingID = ua.ToNodeID(ref.NodeID, r.uris)
+		}
+	}
+	if refs := res.Results[1].References; len(refs) > 0 {
+		supertypeID = ua.ToNodeID(refs[0].NodeID, r.uris)
+	}
+	return encodingID, supertypeID, nil
+}
+
+// target возвращает первый узел по ссылке referenceTypeID
+func (r *dataTypeResolver) target(nodeID, referenceTypeID ua.NodeID, direction ua.BrowseDirection) (ua.NodeID, error) {
+	res, err := r.conn.Browse(r.ctx, &ua.BrowseRequest{
+		NodesToBrowse: []ua.BrowseDescription{{NodeID: nodeID, BrowseDirection: direction, ReferenceTypeID: referenceTypeID}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("browse %s: %w", fmt.Sprint(nodeID), err)
+	}
+	if len(res.Results) == 0 || len(res.Results[0].References) == 0 {
+		return nil, fmt.Errorf("node %s has no %s reference", fmt.Sprint(nodeID), fmt.Sprint(referenceTypeID))
+	}
+	return ua.ToNodeID(res.Results[0].References[0].NodeID, r.uris), nil
+}
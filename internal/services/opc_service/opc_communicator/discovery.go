@@ -0,0 +1,175 @@
+package opc_communicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/internal/interfaces"
+	"opc_ua_service/pkg/machine_models"
+	"time"
+)
+
+// discoveryRefreshInterval - через сколько узлы модели, найденные обходом, ищутся заново в той же сессии:
+// у сервера могут появиться новые задания или шпиндели
+const discoveryRefreshInterval = 10 * time.Minute
+
+// discoverModelNodes находит узлы модели станка обходом адресного пространства. Результат кэшируется
+// вместе с таблицей пространств имён сессии, поэтому опрос не обходит сервер на каждом цикле
+func (oc *OpcCommunicator) discoverModelNodes(ctx context.Context, id uuid.UUID, connInfo *models.ConnectionInfo, machine interfaces.DiscoverableMachineData) error {
+	table, err := oc.namespaceTable(ctx, id, connInfo)
+	if err != nil {
+		return err
+	}
+	if table.discovered != nil && time.Since(table.discoveredAt) < discoveryRefreshInterval {
+		machine.UseDiscoveredNodes(table.discovered)
+		return nil
+	}
+
+	connInfo.Mu.RLock()
+	conn := connInfo.Conn
+	connInfo.Mu.RUnlock()
+
+	browseCtx, cancel := context.WithTimeout(ctx, browseTimeout)
+	defer cancel()
+	nodes, err := machine.DiscoverNodes(&modelBrowser{oc: oc, ctx: browseCtx, conn: conn, uris: table.uris})
+	if err != nil {
+		if table.discovered != nil {
+			// Повторный обход не удался: продолжаем с узлами, найденными ранее в этой сессии
+			oc.logger.Warn("Failed to refresh machine nodes", "UUID", id, "sessionID", table.sessionID, "error", err)
+			machine.UseDiscoveredNodes(table.discovered)
+			return nil
+		}
+		return fmt.Errorf("discover machine nodes: %w", err)
+	}
+
+	oc.mu.Lock()
+	if current, ok := oc.namespaces[id]; ok && current.sessionID == table.sessionID {
+		current.discovered, current.discoveredAt = nodes, time.Now()
+		oc.namespaces[id] = current
+	}
+	oc.mu.Unlock()
+
+	oc.logger.Info("Machine nodes discovered", "UUID", id, "sessionID", table.sessionID, "root", nodes.Root, "nodes", nodes.Len())
+	machine.UseDiscoveredNodes(nodes)
+	return nil
+}
+
+// modelBrowser - обход адресного пространства сессии для моделей станков с узлами, найденными во время работы
+type modelBrowser struct {
+	oc   *OpcCommunicator
+	ctx  context.Context
+	conn *client.Client
+	uris []string
+}
+
+// Children возвращает узлы, на которые ссылаются иерархические ссылки узла, с NodeID по URI пространства имён
+func (b *modelBrowser) Children(node ua.ExpandedNodeID) ([]machine_models.BrowsedNode, error) {
+	nodeID := ua.ToNodeID(node, b.uris)
+	if nodeID == nil {
+		return nil, fmt.Errorf("namespace %s is not in the server namespace table", node.NamespaceURI)
+	}
+
+	refs, _, err := b.oc.browseAll(b.ctx, b.conn, []ua.BrowseDescription{{
+		NodeID:          nodeID,
+		BrowseDirection: ua.BrowseDirectionForward,
+		ReferenceTypeID: ua.ReferenceTypeIDHierarchicalReferences,
+		IncludeSubtypes: true,
+		ResultMask:      uint32(ua.BrowseResultMaskAll),
+	}}, browseMaxRefsPerNode)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]machine_models.BrowsedNode, 0, len(refs[0]))
+	for _, ref := range refs[0] {
+		if ref.NodeID.ServerIndex != 0 {
+			continue
+		}
+		child := ua.ToNodeID(ref.NodeID, b.uris)
+		if child == nil {
+			continue
+		}
+		children = append(children, machine_models.BrowsedNode{
+			NodeID:     ua.ToExpandedNodeID(child, b.uris),
+			BrowseName: ref.BrowseName.Name,
+			NodeClass:  ref.NodeClass,
+		})
+	}
+	return children, nil
+}
+
+// EnumNames возвращает имена значений перечисления - типа данных переменной: по свойству EnumStrings
+// или EnumValues типа данных
+func (b *modelBrowser) EnumNames(variable ua.ExpandedNodeID) (map[int64]string, error) {
+	nodeID := ua.ToNodeID(variable, b.uris)
+	if nodeID == nil {
+		return nil, fmt.Errorf("namespace %s is not in the server namespace table", variable.NamespaceURI)
+	}
+	results, err := b.oc.readAttributes(b.ctx, b.conn, []ua.ReadValueID{{NodeID: nodeID, AttributeID: ua.AttributeIDDataType}}, 1)
+	if err != nil {
+		return nil, err
+	}
+	dataType, ok := results[0].Value.(ua.NodeID)
+	if results[0].StatusCode.IsBad() || !ok {
+		return nil, fmt.Errorf("read data type of %s: %w", variable, results[0].StatusCode)
+	}
+
+	properties, err := b.Children(ua.ToExpandedNodeID(dataType, b.uris))
+	if err != nil {
+		return nil, err
+	}
+	for _, property := range properties {
+		if property.BrowseName != "EnumStrings" && property.BrowseName != "EnumValues" {
+			continue
+		}
+		values, err := b.oc.readNodeValues(b.ctx, b.conn, []ua.NodeID{ua.ToNodeID(property.NodeID, b.uris)}, 1)
+		if err != nil {
+			return nil, err
+		}
+		if values[0].StatusCode.IsBad() {
+			return nil, fmt.Errorf("read %s of %s: %w", property.BrowseName, dataType, values[0].StatusCode)
+		}
+		return enumNames(values[0].Value), nil
+	}
+	return nil, fmt.Errorf("data type %s is not an enumeration", dataType)
+}
+
+// Value читает значение переменной
+func (b *modelBrowser) Value(variable ua.ExpandedNodeID) (any, error) {
+	nodeID := ua.ToNodeID(variable, b.uris)
+	if nodeID == nil {
+		return nil, fmt.Errorf("namespace %s is not in the server namespace table", variable.NamespaceURI)
+	}
+	values, err := b.oc.readNodeValues(b.ctx, b.conn, []ua.NodeID{nodeID}, 1)
+	if err != nil {
+		return nil, err
+	}
+	if values[0].StatusCode.IsBad() {
+		return nil, values[0].StatusCode
+	}
+	return values[0].Value, nil
+}
+
+// enumNames разбирает значение свойства EnumStrings или EnumValues
+func enumNames(value any) map[int64]string {
+	names := make(map[int64]string)
+	switch v := value.(type) {
+	case []ua.LocalizedText:
+		for i, text := range v {
+			names[int64(i)] = text.Text
+		}
+	case []ua.ExtensionObject:
+		for _, item := range v {
+			switch enum := item.(type) {
+			case ua.EnumValueType:
+				names[enum.Value] = enum.DisplayName.Text
+			case *ua.EnumValueType:
+				names[enum.Value] = enum.DisplayName.Text
+			}
+		}
+	}
+	return names
+}
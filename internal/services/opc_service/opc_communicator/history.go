@@ -0,0 +1,390 @@
+package opc_communicator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/internal/interfaces"
+	"opc_ua_service/pkg/errors"
+	"opc_ua_service/pkg/machine_models"
+	"opc_ua_service/pkg/opc_custom"
+	"sort"
+	"time"
+)
+
+// Параметры чтения истории
+const (
+	historyRequestTimeout = 60 * time.Second // таймаут одного HistoryRead запроса
+	historyMaxPages       = 10000            // защита от сервера, бесконечно возвращающего continuation point
+)
+
+// historyResult - сырые исторические значения одного узла
+type historyResult struct {
+	values    []ua.DataValue
+	truncated bool
+	err       error
+}
+
+// ReadHistory читает сырые исторические значения узлов станка за интервал времени
+func (oc *OpcCommunicator) ReadHistory(id uuid.UUID, req models.HistoryReadRequest) (*models.HistoryReadResponse, error) {
+	if err := req.Normalize(); err != nil {
+		return nil, err
+	}
+
+	connInfo, conn, err := oc.session(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(connInfo.Ctx, nodeAccessTimeout)
+	nodeIDs, errs, err := oc.resolveNodes(ctx, conn, req.Nodes)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.HistoryReadResponse{Results: make([]models.NodeHistory, len(req.Nodes))}
+	var toRead []ua.NodeID
+	var indexes []int
+	for i, ref := range req.Nodes {
+		resp.Results[i] = models.NodeHistory{NodeID: ref.NodeID, BrowsePath: ref.BrowsePath, Samples: []models.HistorySample{}}
+		if errs[i] != nil {
+			resp.Results[i].Error = errs[i].Error()
+			continue
+		}
+		resp.Results[i].NodeID = fmt.Sprint(nodeIDs[i])
+		toRead = append(toRead, nodeIDs[i])
+		indexes = append(indexes, i)
+	}
+	if len(toRead) == 0 {
+		return resp, nil
+	}
+
+	oc.registerNodeDataTypes(connInfo.Ctx, id, connInfo, conn, toRead)
+	results, err := oc.historyReadRaw(connInfo.Ctx, conn, toRead, req.StartTime, req.EndTime, req.MaxValuesPerNode, req.ReturnBounds)
+	if err != nil {
+		return nil, err
+	}
+	for k, result := range results {
+		history := &resp.Results[indexes[k]]
+		history.Truncated = result.truncated
+		if result.err != nil {
+			history.Error = result.err.Error()
+		}
+		for _, value := range result.values {
+			quality := machine_models.NewValueQuality(history.NodeID, value)
+			sample := models.HistorySample{
+				Quality:         quality.Quality,
+				StatusCode:      quality.StatusCode,
+				SourceTimestamp: quality.SourceTimestamp,
+				ServerTimestamp: quality.ServerTimestamp,
+			}
+			if !value.StatusCode.IsBad() {
+				sample.Value = jsonValue(value.Value)
+			}
+			history.Samples = append(history.Samples, sample)
+		}
+	}
+	return resp, nil
+}
+
+// historyReadRaw выполняет HistoryReadRaw для узлов и догружает значения по continuation point,
+// пока они не кончатся или у узла не наберётся maxValues значений. Continuation point узлов,
+// достигших лимита, освобождаются на сервере.
+func (oc *OpcCommunicator) historyReadRaw(ctx context.Context, conn *client.Client, nodeIDs []ua.NodeID, start, end time.Time, maxValues int, returnBounds bool) ([]historyResult, error) {
+	details := ua.ReadRawModifiedDetails{
+		StartTime:        start,
+		EndTime:          end,
+		NumValuesPerNode: uint32(min(models.DefaultHistoryPageSize, maxValues)),
+		ReturnBounds:     returnBounds,
+	}
+
+	results := make([]historyResult, len(nodeIDs))
+	pending := make(map[int]ua.ByteString, len(nodeIDs))
+	for i := range nodeIDs {
+		pending[i] = ""
+	}
+
+	for page := 0; len(pending) > 0; page++ {
+		var indexes, released []int
+		var toRead, toRelease []ua.HistoryReadValueID
+		for i, cp := range pending {
+			if cp != "" && (len(results[i].values) >= maxValues || page >= historyMaxPages) {
+				results[i].truncated = true
+				released = append(released, i)
+				toRelease = append(toRelease, ua.HistoryReadValueID{NodeID: nodeIDs[i], ContinuationPoint: cp})
+				continue
+			}
+			indexes = append(indexes, i)
+			toRead = append(toRead, ua.HistoryReadValueID{NodeID: nodeIDs[i], ContinuationPoint: cp})
+		}
+		for _, i := range released {
+			delete(pending, i)
+		}
+		if len(toRelease) > 0 {
+			oc.releaseHistoryContinuationPoints(ctx, conn, details, toRelease)
+		}
+		if len(toRead) == 0 {
+			break
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, historyRequestTimeout)
+		res, err := conn.HistoryRead(reqCtx, &ua.HistoryReadRequest{
+			HistoryReadDetails: details,
+			TimestampsToReturn: ua.TimestampsToReturnBoth,
+			NodesToRead:        toRead,
+		})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("history read request failed: %w", err)
+		}
+		if len(res.Results) != len(toRead) {
+			return nil, fmt.Errorf("history read returned %d results for %d nodes", len(res.Results), len(toRead))
+		}
+
+		for k, result := range res.Results {
+			i := indexes[k]
+			delete(pending, i)
+			if result.StatusCode.IsBad() {
+				results[i].err = fmt.Errorf("history read failed: %s", opc_custom.StatusCodeName(result.StatusCode))
+				continue
+			}
+
+			var values []ua.DataValue
+			switch data := result.HistoryData.(type) {
+			case ua.HistoryData:
+				values = data.DataValues
+			case *ua.HistoryData:
+				values = data.DataValues
+			case nil:
+			default:
+				results[i].err = fmt.Errorf("unexpected history data type %T", result.HistoryData)
+				continue
+			}
+			for k := range values {
+				values[k].Value = opc_custom.DecodeStructures(values[k].Value)
+			}
+			if free := maxValues - len(results[i].values); len(values) > free {
+				values = values[:free]
+				results[i].truncated = true
+			}
+			results[i].values = append(results[i].values, values...)
+
+			if len(result.ContinuationPoint) > 0 {
+				pending[i] = result.ContinuationPoint
+			}
+		}
+	}
+	return results, nil
+}
+
+// releaseHistoryContinuationPoints освобождает continuation point на сервере. Ошибки игнорируются:
+// сервер в любом случае удалит их по таймауту
+func (oc *OpcCommunicator) releaseHistoryContinuationPoints(ctx context.Context, conn *client.Client, details ua.ReadRawModifiedDetails, nodes []ua.HistoryReadValueID) {
+	reqCtx, cancel := context.WithTimeout(ctx, historyRequestTimeout)
+	defer cancel()
+	_, _ = conn.HistoryRead(reqCtx, &ua.HistoryReadRequest{
+		HistoryReadDetails:        details,
+		TimestampsToReturn:        ua.TimestampsToReturnBoth,
+		ReleaseContinuationPoints: true,
+		NodesToRead:               nodes,
+	})
+}
+
+// ---------------------------------------------------------------------------------------------------------------
+
+// StartBackfill запускает в фоне задачу дозаполнения: история узлов модели станка за интервал
+// собирается в снимки по меткам времени и отправляется в Kafka с признаком backfilled.
+// Для одного станка одновременно выполняется только одна задача.
+func (oc *OpcCommunicator) StartBackfill(id uuid.UUID, req models.BackfillRequest) (*models.BackfillJob, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	connInfo, conn, err := oc.session(id)
+	if err != nil {
+		return nil, err
+	}
+	if interfaces.MachineDataFactory(id, connInfo.Manufacturer, connInfo.Model) == nil {
+		return nil, fmt.Errorf("unsupported machine type: %s %s", connInfo.Manufacturer, connInfo.Model)
+	}
+
+	job := &models.BackfillJob{
+		ID:          uuid.New(),
+		MachineUUID: id,
+		Status:      models.BackfillRunning,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		StartedAt:   time.Now(),
+	}
+
+	oc.mu.Lock()
+	if last, ok := oc.backfillJobs[id]; ok && last.Status == models.BackfillRunning {
+		oc.mu.Unlock()
+		return nil, fmt.Errorf("%w for machine %s", errors.ErrBackfillRunning, id)
+	}
+	oc.backfillJobs[id] = job
+	snapshot := *job
+	oc.mu.Unlock()
+
+	go oc.runBackfill(connInfo, conn, job)
+
+	oc.logger.Info("Backfill started", "UUID", id, "job", job.ID, "startTime", req.StartTime, "endTime", req.EndTime)
+	return &snapshot, nil
+}
+
+// GetBackfillJob возвращает последнюю задачу дозаполнения станка
+func (oc *OpcCommunicator) GetBackfillJob(id uuid.UUID) (*models.BackfillJob, error) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	job, ok := oc.backfillJobs[id]
+	if !ok {
+		return nil, errors.NewNotFoundError("backfill job not found")
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// historySample - историческое значение узла модели для воспроизведения
+type historySample struct {
+	nodeID string
+	value  ua.DataValue
+	at     time.Time
+}
+
+// runBackfill читает историю узлов модели и воспроизводит её в порядке времени.
+// Узлы без истории (например, серийный номер) заполняются текущими значениями, чтобы снимки имели ключ станка.
+func (oc *OpcCommunicator) runBackfill(connInfo *models.ConnectionInfo, conn *client.Client, job *models.BackfillJob) {
+	ctx := connInfo.Ctx
+	machine := interfaces.MachineDataFactory(job.MachineUUID, connInfo.Manufacturer, connInfo.Model)
+	finish := func(err error) {
+		now := time.Now()
+		oc.mu.Lock()
+		job.FinishedAt = &now
+		job.Status = models.BackfillCompleted
+		if err != nil {
+			job.Status = models.BackfillFailed
+			job.Error = err.Error()
+		}
+		published := job.PublishedCount
+		oc.mu.Unlock()
+		if err != nil {
+			oc.logger.Error("Backfill failed", "UUID", job.MachineUUID, "job", job.ID, "error", err)
+			return
+		}
+		oc.logger.Info("Backfill completed", "UUID", job.MachineUUID, "job", job.ID, "published", published)
+	}
+
+	nodes, err := oc.resolveModelNodes(ctx, job.MachineUUID, connInfo, machine)
+	if err != nil {
+		finish(err)
+		return
+	}
+
+	results, err := oc.historyReadRaw(ctx, conn, nodes.nodeIDs, job.StartTime, job.EndTime, models.MaxHistoryMaxValuesPerNode, false)
+	if err != nil {
+		finish(err)
+		return
+	}
+
+	var samples []historySample
+	var withoutHistory []int
+	truncated := false
+	for i, result := range results {
+		truncated = truncated || result.truncated
+		if result.err != nil || len(result.values) == 0 {
+			withoutHistory = append(withoutHistory, i)
+			continue
+		}
+		for _, value := range result.values {
+			at := value.SourceTimestamp
+			if at.IsZero() {
+				at = value.ServerTimestamp
+			}
+			if at.IsZero() {
+				continue
+			}
+			samples = append(samples, historySample{nodeID: nodes.keys[i], value: value, at: at})
+		}
+	}
+	oc.mu.Lock()
+	job.SampleCount = len(samples)
+	job.Truncated = truncated
+	oc.mu.Unlock()
+	if len(samples) == 0 {
+		finish(fmt.Errorf("server returned no history for the machine nodes in this interval"))
+		return
+	}
+
+	if len(withoutHistory) > 0 {
+		readCtx, cancel := context.WithTimeout(ctx, nodeAccessTimeout)
+		nodeIDs := make([]ua.NodeID, len(withoutHistory))
+		for k, i := range withoutHistory {
+			nodeIDs[k] = nodes.nodeIDs[i]
+		}
+		values, err := oc.readNodeValues(readCtx, conn, nodeIDs, oc.maxNodesPerRead(readCtx, job.MachineUUID, connInfo))
+		cancel()
+		if err != nil {
+			finish(fmt.Errorf("read current values of nodes without history: %w", err))
+			return
+		}
+		for k, value := range values {
+			if value.StatusCode.IsBad() {
+				continue
+			}
+			_ = machine.ConvertNodeToMachineData(nodes.keys[withoutHistory[k]], value.Value)
+		}
+	}
+
+	sort.SliceStable(samples, func(a, b int) bool { return samples[a].at.Before(samples[b].at) })
+	published := 0
+	for start := 0; start < len(samples); {
+		if ctx.Err() != nil {
+			finish(fmt.Errorf("connection closed: %w", ctx.Err()))
+			return
+		}
+
+		// Значения с одной меткой времени попадают в один снимок, остальные поля переносятся из предыдущего
+		end := start
+		for end < len(samples) && samples[end].at.Equal(samples[start].at) {
+			sample := samples[end]
+			machine.RecordSample(sample.nodeID, sample.value)
+			if !sample.value.StatusCode.IsBad() {
+				if err := machine.ConvertNodeToMachineData(sample.nodeID, sample.value.Value); err != nil {
+					oc.logger.Error("Failed to convert node", "nodeID", sample.nodeID, "error", err)
+				}
+			}
+			end++
+		}
+		start = end
+
+		if err := oc.publishBackfilledData(machine); err != nil {
+			finish(err)
+			return
+		}
+		published++
+		oc.mu.Lock()
+		job.PublishedCount = published
+		oc.mu.Unlock()
+	}
+	finish(nil)
+}
+
+// publishBackfilledData отправляет восстановленный из истории снимок станка в Kafka с признаком backfilled
+func (oc *OpcCommunicator) publishBackfilledData(data interfaces.MachineData) error {
+	resp := data.ToResponse()
+	resp.Backfilled = true
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("marshal backfilled data: %w", err)
+	}
+	if err := oc.producer.Produce(context.Background(), []byte(resp.MachineId), payload); err != nil {
+		return fmt.Errorf("send backfilled data to Kafka: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package opc_communicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/machine_models"
+	"slices"
+)
+
+// buildInfoNodes - переменные ServerStatus/BuildInfo, которые читаются для определения модели станка
+var buildInfoNodes = []ua.NodeID{
+	ua.VariableIDServerServerStatusBuildInfoProductName,
+	ua.VariableIDServerServerStatusBuildInfoProductURI,
+	ua.VariableIDServerServerStatusBuildInfoManufacturerName,
+	ua.VariableIDServerServerStatusBuildInfoSoftwareVersion,
+}
+
+// IdentifyServer читает сведения сервера о себе: BuildInfo, таблицу пространств имён и Identification экземпляра
+// MachineTool (OPC 40501). Identification необязателен: его отсутствие или ошибка обхода не прерывают чтение
+func (oc *OpcCommunicator) IdentifyServer(id uuid.UUID) (*models.ServerIdentity, error) {
+	connInfo, conn, err := oc.session(id)
+	if err != nil {
+		return nil, fmt.Errorf("connection not found: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(connInfo.Ctx, browseTimeout)
+	defer cancel()
+
+	table, err := oc.namespaceTable(ctx, id, connInfo)
+	if err != nil {
+		return nil, err
+	}
+	values, err := oc.readNodeValues(ctx, conn, buildInfoNodes, oc.maxNodesPerRead(ctx, id, connInfo))
+	if err != nil {
+		return nil, fmt.Errorf("read build info: %w", err)
+	}
+
+	identity := &models.ServerIdentity{Namespaces: table.uris}
+	for i, field := range []*string{&identity.ProductName, &identity.ProductURI, &identity.ManufacturerName, &identity.SoftwareVersion} {
+		if values[i].StatusCode.IsGood() {
+			*field = identityText(values[i].Value)
+		}
+	}
+
+	if slices.Contains(table.uris, machine_models.MachineToolNamespaceURI) {
+		if err := oc.readMachineToolIdentification(ctx, id, connInfo, table, identity); err != nil {
+			oc.logger.Warn("Failed to read MachineTool identification", "UUID", id, "error", err)
+		}
+	}
+
+	oc.logger.Info("Server identified", "UUID", id, "product", identity.ProductName,
+		"manufacturer", identity.ManufacturerName, "softwareVersion", identity.SoftwareVersion)
+	return identity, nil
+}
+
+// readMachineToolIdentification дополняет сведения сервера производителем, моделью и серийным номером из
+// Identification экземпляра MachineTool
+func (oc *OpcCommunicator) readMachineToolIdentification(ctx context.Context, id uuid.UUID, connInfo *models.ConnectionInfo, table namespaceTable, identity *models.ServerIdentity) error {
+	connInfo.Mu.RLock()
+	conn := connInfo.Conn
+	connInfo.Mu.RUnlock()
+
+	vars, err := machine_models.FindMachineToolIdentification(&modelBrowser{oc: oc, ctx: ctx, conn: conn, uris: table.uris})
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]*string{
+		"Manufacturer": &identity.MachineManufacturer,
+		"Model":        &identity.MachineModel,
+		"SerialNumber": &identity.SerialNumber,
+	}
+	var nodeIDs []ua.NodeID
+	var targets []*string
+	for name, field := range fields {
+		if node, ok := vars[name]; ok {
+			nodeIDs = append(nodeIDs, ua.ToNodeID(node.NodeID, table.uris))
+			targets = append(targets, field)
+		}
+	}
+	if len(nodeIDs) == 0 {
+		return nil
+	}
+
+	values, err := oc.readNodeValues(ctx, conn, nodeIDs, oc.maxNodesPerRead(ctx, id, connInfo))
+	if err != nil {
+		return err
+	}
+	for i, target := range targets {
+		if values[i].StatusCode.IsGood() {
+			*target = identityText(values[i].Value)
+		}
+	}
+	return nil
+}
+
+// identityText - текст значения переменной BuildInfo или Identification
+func identityText(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case ua.LocalizedText:
+		return val.Text
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,143 @@
+package opc_communicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/internal/interfaces"
+	"opc_ua_service/pkg/machine_models"
+	"strings"
+	"time"
+)
+
+// namespaceTable - таблица пространств имён сервера и разрешённые по ней узлы модели для конкретной сессии
+type namespaceTable struct {
+	sessionID string
+	uris      []string
+	nodes     *resolvedNodes
+	nodesKey  string // узлы модели, для которых разрешены nodes: версия профиля может изменить их набор
+
+	discovered   *machine_models.DiscoveredNodes // узлы модели, найденные обходом адресного пространства
+	discoveredAt time.Time
+}
+
+// resolvedNodes - узлы модели станка, разрешённые по таблице пространств имён сессии
+type resolvedNodes struct {
+	nodeIDs []ua.NodeID // NodeID узлов в текущей сессии
+	keys    []string    // ключи узлов в модели: nsu=<URI>;<идентификатор>
+}
+
+// namespaceTable возвращает NamespaceArray сервера для текущей сессии. Таблица читается один раз на сессию
+// и перечитывается после переподключения: индексы пространств имён отличаются у серверов и версий прошивки.
+func (oc *OpcCommunicator) namespaceTable(ctx context.Context, id uuid.UUID, connInfo *models.ConnectionInfo) (namespaceTable, error) {
+	connInfo.Mu.RLock()
+	conn, sessionID := connInfo.Conn, connInfo.SessionID
+	connInfo.Mu.RUnlock()
+
+	oc.mu.Lock()
+	table, ok := oc.namespaces[id]
+	oc.mu.Unlock()
+	if ok && table.sessionID == sessionID {
+		return table, nil
+	}
+
+	results, err := oc.readNodeValues(ctx, conn, []ua.NodeID{ua.NewNodeIDNumeric(0, namespaceArrayNodeID)}, 1)
+	if err != nil {
+		// Сессия недоступна: не кэшируем, попробуем при следующем чтении
+		return namespaceTable{}, fmt.Errorf("read namespace array: %w", err)
+	}
+	if results[0].StatusCode.IsBad() {
+		return namespaceTable{}, fmt.Errorf("read namespace array: %w", results[0].StatusCode)
+	}
+	uris, ok := results[0].Value.([]string)
+	if !ok {
+		return namespaceTable{}, fmt.Errorf("unexpected namespace array type %T", results[0].Value)
+	}
+
+	table = namespaceTable{sessionID: sessionID, uris: uris}
+	oc.mu.Lock()
+	oc.namespaces[id] = table
+	oc.mu.Unlock()
+
+	oc.logger.Info("Namespace table loaded", "UUID", id, "sessionID", sessionID, "namespaces", len(uris))
+	return table, nil
+}
+
+// resolveModelNodes разрешает узлы модели станка по таблице пространств имён текущей сессии.
+// Узлы из пространств имён, которых нет на сервере, пропускаются с предупреждением.
+func (oc *OpcCommunicator) resolveModelNodes(ctx context.Context, id uuid.UUID, connInfo *models.ConnectionInfo, machine interfaces.MachineData) (resolvedNodes, error) {
+	if discoverable, ok := machine.(interfaces.DiscoverableMachineData); ok {
+		if err := oc.discoverModelNodes(ctx, id, connInfo, discoverable); err != nil {
+			return resolvedNodes{}, err
+		}
+	}
+	modelNodes := machine.GetRelevantNodeIDs()
+	if len(modelNodes) == 0 {
+		return resolvedNodes{}, fmt.Errorf("no nodes defined for machine %s %s", connInfo.Manufacturer, connInfo.Model)
+	}
+
+	keys := make([]string, len(modelNodes))
+	for i, node := range modelNodes {
+		keys[i] = node.String()
+	}
+	nodesKey := strings.Join(keys, "\n")
+
+	table, err := oc.namespaceTable(ctx, id, connInfo)
+	if err != nil {
+		return resolvedNodes{}, err
+	}
+	if table.nodes != nil && table.nodesKey == nodesKey {
+		return *table.nodes, nil
+	}
+
+	nodes := resolvedNodes{
+		nodeIDs: make([]ua.NodeID, 0, len(modelNodes)),
+		keys:    make([]string, 0, len(modelNodes)),
+	}
+	var missing []string
+	for i, node := range modelNodes {
+		nodeID := ua.ToNodeID(node, table.uris)
+		if nodeID == nil {
+			missing = append(missing, keys[i])
+			continue
+		}
+		nodes.nodeIDs = append(nodes.nodeIDs, nodeID)
+		nodes.keys = append(nodes.keys, keys[i])
+	}
+	if len(missing) > 0 {
+		oc.logger.Warn("Machine model nodes are outside the server namespace table", "UUID", id, "sessionID", table.sessionID, "nodes", missing)
+	}
+	if len(nodes.nodeIDs) == 0 {
+		return resolvedNodes{}, fmt.Errorf("server has no namespaces of machine %s %s", connInfo.Manufacturer, connInfo.Model)
+	}
+
+	// Декодеры структур производителя нужны до первого чтения значений: без них тело ExtensionObject теряется
+	connInfo.Mu.RLock()
+	conn := connInfo.Conn
+	connInfo.Mu.RUnlock()
+	oc.registerNodeDataTypes(ctx, id, connInfo, conn, nodes.nodeIDs)
+
+	// Сохраняем разрешённые узлы, только если за время чтения сессия не сменилась
+	oc.mu.Lock()
+	if current, ok := oc.namespaces[id]; ok && current.sessionID == table.sessionID {
+		current.nodes, current.nodesKey = &nodes, nodesKey
+		oc.namespaces[id] = current
+	}
+	oc.mu.Unlock()
+	return nodes, nil
+}
+
+// resolveModelNode разрешает отдельный узел модели по таблице пространств имён текущей сессии
+func (oc *OpcCommunicator) resolveModelNode(ctx context.Context, id uuid.UUID, connInfo *models.ConnectionInfo, node ua.ExpandedNodeID) (ua.NodeID, error) {
+	table, err := oc.namespaceTable(ctx, id, connInfo)
+	if err != nil {
+		return nil, err
+	}
+	nodeID := ua.ToNodeID(node, table.uris)
+	if nodeID == nil {
+		return nil, fmt.Errorf("namespace %s is not in the server namespace table", node.NamespaceURI)
+	}
+	return nodeID, nil
+}
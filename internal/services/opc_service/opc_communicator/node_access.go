@@ -0,0 +1,247 @@
+package opc_communicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"math"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/machine_models"
+	"opc_ua_service/pkg/opc_custom"
+	"time"
+)
+
+// nodeAccessTimeout - таймаут разового чтения или записи узлов через REST API
+const nodeAccessTimeout = 30 * time.Second
+
+// ReadNodes читает значения произвольных узлов станка по NodeID или пути просмотра.
+// Ошибки отдельных узлов возвращаются в их результатах, ошибка функции означает сбой всего запроса.
+func (oc *OpcCommunicator) ReadNodes(id uuid.UUID, refs []models.NodeRef) ([]models.NodeValue, error) {
+	connInfo, conn, err := oc.session(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(connInfo.Ctx, nodeAccessTimeout)
+	defer cancel()
+
+	nodeIDs, errs, err := oc.resolveNodes(ctx, conn, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.NodeValue, len(refs))
+	toRead := make([]ua.NodeID, 0, len(refs))
+	indexes := make([]int, 0, len(refs))
+	for i, ref := range refs {
+		results[i] = models.NodeValue{BrowsePath: ref.BrowsePath, NodeID: ref.NodeID, Quality: models.QualityBad}
+		if errs[i] != nil {
+			results[i].Error = errs[i].Error()
+			continue
+		}
+		results[i].NodeID = fmt.Sprint(nodeIDs[i])
+		toRead = append(toRead, nodeIDs[i])
+		indexes = append(indexes, i)
+	}
+	if len(toRead) == 0 {
+		return results, nil
+	}
+
+	oc.registerNodeDataTypes(ctx, id, connInfo, conn, toRead)
+	values, err := oc.readNodeValues(ctx, conn, toRead, oc.maxNodesPerRead(ctx, id, connInfo))
+	if err != nil {
+		return nil, err
+	}
+	for k, value := range values {
+		result := &results[indexes[k]]
+		quality := machine_models.NewValueQuality(result.NodeID, value)
+		result.Quality = quality.Quality
+		result.StatusCode = quality.StatusCode
+		result.SourceTimestamp = quality.SourceTimestamp
+		result.ServerTimestamp = quality.ServerTimestamp
+		if !value.StatusCode.IsBad() {
+			result.Value = jsonValue(value.Value)
+		}
+	}
+	return results, nil
+}
+
+// WriteNodes записывает значения в узлы станка. Перед записью проверяется, что узел разрешён allowed,
+// и читаются его DataType и текущее значение: к типу значения приводится новое, а прежнее попадает в результат.
+func (oc *OpcCommunicator) WriteNodes(id uuid.UUID, items []models.NodeWriteItem, allowed func(nodeID string) bool) ([]models.NodeWriteResult, error) {
+	connInfo, conn, err := oc.session(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(connInfo.Ctx, nodeAccessTimeout)
+	defer cancel()
+
+	refs := make([]models.NodeRef, len(items))
+	for i, item := range items {
+		refs[i] = item.NodeRef
+	}
+	nodeIDs, errs, err := oc.resolveNodes(ctx, conn, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.NodeWriteResult, len(items))
+	var toCheck []int
+	for i, item := range items {
+		results[i] = models.NodeWriteResult{NodeID: item.NodeID, BrowsePath: item.BrowsePath, Value: item.Value}
+		if errs[i] != nil {
+			results[i].Error = errs[i].Error()
+			continue
+		}
+		results[i].NodeID = fmt.Sprint(nodeIDs[i])
+		if !allowed(results[i].NodeID) {
+			results[i].Error = "node is not in the writable nodes list of the machine"
+			continue
+		}
+		results[i].Allowed = true
+		toCheck = append(toCheck, i)
+	}
+	if len(toCheck) == 0 {
+		return results, nil
+	}
+
+	// Текущие DataType и значение нужны для приведения типа и журнала аудита
+	toRead := make([]ua.ReadValueID, 0, len(toCheck)*browseValueAttributes)
+	for _, i := range toCheck {
+		toRead = append(toRead,
+			ua.ReadValueID{NodeID: nodeIDs[i], AttributeID: ua.AttributeIDDataType},
+			ua.ReadValueID{NodeID: nodeIDs[i], AttributeID: ua.AttributeIDValue},
+		)
+	}
+	attributes, err := oc.readAttributes(ctx, conn, toRead, oc.maxNodesPerRead(ctx, id, connInfo))
+	if err != nil {
+		return nil, err
+	}
+
+	var toWrite []ua.WriteValue
+	var writeIndexes []int
+	for k, i := range toCheck {
+		dataType, current := attributes[k*browseValueAttributes], attributes[k*browseValueAttributes+1]
+		if dataType.StatusCode.IsBad() {
+			results[i].StatusCode = opc_custom.StatusCodeName(dataType.StatusCode)
+			results[i].Error = "failed to read node data type"
+			continue
+		}
+		if !current.StatusCode.IsBad() {
+			results[i].PreviousValue = jsonValue(current.Value)
+		}
+
+		dataTypeID, _ := dataType.Value.(ua.NodeID)
+		var currentValue ua.Variant
+		if !current.StatusCode.IsBad() {
+			currentValue = current.Value
+		}
+		value, err := coerceValue(items[i].Value, currentValue, dataTypeID)
+		if err != nil {
+			results[i].Error = fmt.Sprintf("invalid value: %v", err)
+			continue
+		}
+
+		toWrite = append(toWrite, ua.WriteValue{
+			NodeID:      nodeIDs[i],
+			AttributeID: ua.AttributeIDValue,
+			Value:       ua.DataValue{Value: value},
+		})
+		writeIndexes = append(writeIndexes, i)
+	}
+	if len(toWrite) == 0 {
+		return results, nil
+	}
+
+	res, err := conn.Write(ctx, &ua.WriteRequest{NodesToWrite: toWrite})
+	if err != nil {
+		return nil, fmt.Errorf("write request failed: %w", err)
+	}
+	if len(res.Results) != len(toWrite) {
+		return nil, fmt.Errorf("write request returned %d results for %d nodes", len(res.Results), len(toWrite))
+	}
+	for k, status := range res.Results {
+		i := writeIndexes[k]
+		results[i].StatusCode = opc_custom.StatusCodeName(status)
+		results[i].Written = status.IsGood()
+		if !status.IsGood() {
+			results[i].Error = "server rejected write"
+		}
+	}
+
+	oc.logger.Info("Nodes written", "UUID", id, "requested", len(items), "written", len(toWrite))
+	return results, nil
+}
+
+// resolveNodes получает NodeID для ссылок на узлы. Пути просмотра переводятся одним запросом
+// TranslateBrowsePathsToNodeIDs по иерархическим ссылкам. Возвращает NodeID и ошибку по каждой ссылке.
+func (oc *OpcCommunicator) resolveNodes(ctx context.Context, conn *client.Client, refs []models.NodeRef) ([]ua.NodeID, []error, error) {
+	nodeIDs := make([]ua.NodeID, len(refs))
+	errs := make([]error, len(refs))
+
+	var paths []ua.BrowsePath
+	var pathIndexes []int
+	for i, ref := range refs {
+		if err := ref.Validate(); err != nil {
+			errs[i] = err
+			continue
+		}
+		if ref.NodeID != "" {
+			nodeIDs[i] = ua.ParseNodeID(ref.NodeID)
+			continue
+		}
+
+		startNode, _ := ref.StartNode()
+		names := ref.PathElements()
+		elements := make([]ua.RelativePathElement, 0, len(names))
+		for _, name := range names {
+			elements = append(elements, ua.RelativePathElement{
+				ReferenceTypeID: ua.ReferenceTypeIDHierarchicalReferences,
+				IncludeSubtypes: true,
+				TargetName:      name,
+			})
+		}
+		paths = append(paths, ua.BrowsePath{StartingNode: startNode, RelativePath: ua.RelativePath{Elements: elements}})
+		pathIndexes = append(pathIndexes, i)
+	}
+	if len(paths) == 0 {
+		return nodeIDs, errs, nil
+	}
+
+	res, err := conn.TranslateBrowsePathsToNodeIDs(ctx, &ua.TranslateBrowsePathsToNodeIDsRequest{BrowsePaths: paths})
+	if err != nil {
+		return nil, nil, fmt.Errorf("translate browse paths failed: %w", err)
+	}
+	if len(res.Results) != len(paths) {
+		return nil, nil, fmt.Errorf("translate browse paths returned %d results for %d paths", len(res.Results), len(paths))
+	}
+
+	var namespaceURIs []string
+	for k, result := range res.Results {
+		i := pathIndexes[k]
+		if result.StatusCode.IsBad() {
+			errs[i] = fmt.Errorf("browse path not resolved: %s", opc_custom.StatusCodeName(result.StatusCode))
+			continue
+		}
+		for _, target := range result.Targets {
+			// Цель с RemainingPathIndex меньше MaxUint32 или ненулевым ServerIndex находится на другом сервере
+			if target.RemainingPathIndex != math.MaxUint32 || target.TargetID.ServerIndex != 0 {
+				continue
+			}
+			if target.TargetID.NamespaceURI != "" && namespaceURIs == nil {
+				namespaceURIs = oc.readNamespaceArray(ctx, conn)
+			}
+			nodeIDs[i] = ua.ToNodeID(target.TargetID, namespaceURIs)
+			if nodeIDs[i] != nil {
+				break
+			}
+		}
+		if nodeIDs[i] == nil {
+			errs[i] = fmt.Errorf("browse path not resolved: no local target")
+		}
+	}
+	return nodeIDs, errs, nil
+}
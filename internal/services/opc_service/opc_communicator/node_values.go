@@ -0,0 +1,200 @@
+package opc_communicator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// builtinDataTypeValues - нулевые значения стандартных типов данных, к которым приводится записываемое значение
+var builtinDataTypeValues = map[uint32]any{
+	1: false, 2: int8(0), 3: uint8(0), 4: int16(0), 5: uint16(0), 6: int32(0), 7: uint32(0), 8: int64(0), 9: uint64(0),
+	10: float32(0), 11: float64(0), 12: "", 13: time.Time{}, 15: ua.ByteString(""), 21: ua.LocalizedText{},
+	29: int32(0), 290: float64(0), 294: time.Time{}, 295: "",
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	byteStringType    = reflect.TypeOf(ua.ByteString(""))
+	localizedTextType = reflect.TypeOf(ua.LocalizedText{})
+)
+
+// coerceValue приводит значение из JSON к типу узла. Тип берётся из текущего значения узла,
+// а если оно пустое - из стандартного DataType. Массивы приводятся поэлементно.
+func coerceValue(value any, current ua.Variant, dataType ua.NodeID) (ua.Variant, error) {
+	target := reflect.TypeOf(current)
+	if target == nil {
+		numeric, ok := dataType.(ua.NodeIDNumeric)
+		if !ok || numeric.NamespaceIndex != 0 {
+			return nil, fmt.Errorf("cannot determine value type for data type %v", dataType)
+		}
+		zero, ok := builtinDataTypeValues[numeric.ID]
+		if !ok {
+			return nil, fmt.Errorf("writing data type %s is not supported", dataTypeName(dataType))
+		}
+		target = reflect.TypeOf(zero)
+		if _, isArray := value.([]any); isArray {
+			target = reflect.SliceOf(target)
+		}
+	}
+
+	if target.Kind() == reflect.Slice {
+		items, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("array value expected")
+		}
+		result := reflect.MakeSlice(target, len(items), len(items))
+		for i, item := range items {
+			v, err := coerceScalar(item, target.Elem())
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			result.Index(i).Set(v)
+		}
+		return result.Interface(), nil
+	}
+
+	v, err := coerceScalar(value, target)
+	if err != nil {
+		return nil, err
+	}
+	if text, ok := current.(ua.LocalizedText); ok {
+		// Локаль сохраняется, меняется только текст
+		lt := v.Interface().(ua.LocalizedText)
+		lt.Locale = text.Locale
+		return lt, nil
+	}
+	return v.Interface(), nil
+}
+
+// coerceScalar приводит скалярное значение из JSON (bool, float64, string) к типу target
+func coerceScalar(value any, target reflect.Type) (reflect.Value, error) {
+	switch target {
+	case timeType:
+		s, ok := value.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("RFC 3339 time string expected, got %T", value)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(t.UTC()), nil
+	case byteStringType:
+		s, ok := value.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("base64 string expected, got %T", value)
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(ua.ByteString(b)), nil
+	case localizedTextType:
+		s, ok := value.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("string expected, got %T", value)
+		}
+		return reflect.ValueOf(ua.LocalizedText{Text: s}), nil
+	}
+
+	result := reflect.New(target).Elem()
+	switch target.Kind() {
+	case reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			result.SetBool(v)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.SetBool(b)
+		default:
+			return reflect.Value{}, fmt.Errorf("boolean expected, got %T", value)
+		}
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch v := value.(type) {
+		case float64:
+			if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+				return reflect.Value{}, fmt.Errorf("integer expected, got %v", v)
+			}
+			n = int64(v)
+		case string:
+			var err error
+			if n, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return reflect.Value{}, err
+			}
+		default:
+			return reflect.Value{}, fmt.Errorf("integer expected, got %T", value)
+		}
+		if result.OverflowInt(n) {
+			return reflect.Value{}, fmt.Errorf("value %d overflows %s", n, target)
+		}
+		result.SetInt(n)
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch v := value.(type) {
+		case float64:
+			if v != math.Trunc(v) || v < 0 || v >= math.MaxUint64 {
+				return reflect.Value{}, fmt.Errorf("unsigned integer expected, got %v", v)
+			}
+			n = uint64(v)
+		case string:
+			var err error
+			if n, err = strconv.ParseUint(v, 10, 64); err != nil {
+				return reflect.Value{}, err
+			}
+		default:
+			return reflect.Value{}, fmt.Errorf("unsigned integer expected, got %T", value)
+		}
+		if result.OverflowUint(n) {
+			return reflect.Value{}, fmt.Errorf("value %d overflows %s", n, target)
+		}
+		result.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch v := value.(type) {
+		case float64:
+			f = v
+		case string:
+			var err error
+			if f, err = strconv.ParseFloat(v, 64); err != nil {
+				return reflect.Value{}, err
+			}
+		default:
+			return reflect.Value{}, fmt.Errorf("number expected, got %T", value)
+		}
+		if result.OverflowFloat(f) {
+			return reflect.Value{}, fmt.Errorf("value %v overflows %s", f, target)
+		}
+		result.SetFloat(f)
+
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("string expected, got %T", value)
+		}
+		result.SetString(s)
+
+	default:
+		return reflect.Value{}, fmt.Errorf("writing values of type %s is not supported", target)
+	}
+	return result, nil
+}
+
+// jsonValue подготавливает прочитанное значение для ответа REST API: ByteString кодируется в base64
+func jsonValue(value ua.Variant) any {
+	if b, ok := value.(ua.ByteString); ok {
+		return b.String()
+	}
+	return value
+}
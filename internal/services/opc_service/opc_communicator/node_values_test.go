@@ -0,0 +1,58 @@
+package opc_communicator
+
+import (
+	"github.com/awcullen/opcua/ua"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCoerceValue(t *testing.T) {
+	at := time.Date(2025, 8, 22, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    any
+		current  ua.Variant
+		dataType ua.NodeID
+		want     ua.Variant
+		wantErr  bool
+	}{
+		{name: "bool", value: true, current: false, want: true},
+		{name: "bool из строки", value: "true", current: false, want: true},
+		{name: "int16", value: float64(-300), current: int16(1), want: int16(-300)},
+		{name: "int32 из строки", value: "42", current: int32(0), want: int32(42)},
+		{name: "дробное в целое", value: 1.5, current: int32(0), wantErr: true},
+		{name: "переполнение int8", value: float64(200), current: int8(0), wantErr: true},
+		{name: "отрицательное в uint16", value: float64(-1), current: uint16(0), wantErr: true},
+		{name: "переполнение byte", value: float64(256), current: uint8(0), wantErr: true},
+		{name: "float32", value: 2.5, current: float32(0), want: float32(2.5)},
+		{name: "double из строки", value: "3.25", current: float64(0), want: 3.25},
+		{name: "число вместо строки", value: float64(1), current: "", wantErr: true},
+		{name: "строка", value: "P100", current: "", want: "P100"},
+		{name: "время", value: "2025-08-22T15:00:00+03:00", current: time.Time{}, want: at},
+		{name: "неверное время", value: "вчера", current: time.Time{}, wantErr: true},
+		{name: "ByteString", value: "AQI=", current: ua.ByteString(""), want: ua.ByteString("\x01\x02")},
+		{name: "LocalizedText сохраняет локаль", value: "Станок", current: ua.LocalizedText{Text: "Machine", Locale: "ru"},
+			want: ua.LocalizedText{Text: "Станок", Locale: "ru"}},
+		{name: "массив", value: []any{float64(1), float64(2)}, current: []int32{0}, want: []int32{1, 2}},
+		{name: "ошибка элемента массива", value: []any{float64(1), "x"}, current: []int32{0}, wantErr: true},
+		{name: "скаляр вместо массива", value: float64(1), current: []int32{0}, wantErr: true},
+		{name: "тип по DataType", value: float64(7), dataType: ua.DataTypeIDUInt32, want: uint32(7)},
+		{name: "массив по DataType", value: []any{"a", "b"}, dataType: ua.DataTypeIDString, want: []string{"a", "b"}},
+		{name: "неизвестный DataType", value: float64(1), dataType: ua.NewNodeIDNumeric(2, 3001), wantErr: true},
+		{name: "неподдерживаемый DataType", value: "x", dataType: ua.DataTypeIDGUID, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := coerceValue(tt.value, tt.current, tt.dataType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("coerceValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("coerceValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
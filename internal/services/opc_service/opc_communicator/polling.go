@@ -2,15 +2,19 @@ package opc_communicator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	_ "opc_ua_service/internal/domain/models"
+	connection_models "opc_ua_service/internal/domain/models/connection_models"
+	"opc_ua_service/internal/interfaces"
 	"opc_ua_service/pkg/errors"
 	"time"
 )
 
 func (o *OpcCommunicator) StartPollingForMachine(id uuid.UUID) error {
-	connInfo, err := o.connector.GetConnectionInfoByUUID(id)
+	// Опрос можно запустить и для переподключающейся сессии: тики до восстановления просто пропускаются
+	connInfo, err := o.connector.FindConnectionInfo(id)
 	if err != nil {
 		return err
 	}
@@ -28,30 +32,36 @@ func (o *OpcCommunicator) StartPollingForMachine(id uuid.UUID) error {
 	o.pollCancelMap[id] = cancel
 	o.mu.Unlock()
 
-	interval := connInfo.Config.Config.GetTimeout()
-	connInfo.IsPolled = true
+	// Модель станка выбирается один раз: новая версия профиля применяется к следующему запуску опроса
+	newMachine := interfaces.ResolveMachineData(id, connInfo.Manufacturer, connInfo.Model)
+
+	interval := connection_models.PollInterval(connInfo.Config.Config.GetTimeout())
+	setPolled(connInfo, true)
+	go o.runAlarmSubscription(ctx, id, connInfo)
 	go func() {
+		defer o.clearAlarms(id)
+
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
-				o.logger.Info("Stopped polling for machine %s", connInfo.SessionID)
-				connInfo.IsPolled = false
+				o.logger.Info("Stopped polling for machine", "UUID", id, "sessionID", connInfo.SessionID)
+				setPolled(connInfo, false)
 				return
 			case <-ticker.C:
-				data, err := o.ReadMachineData(id)
+				current, err := o.connector.GetConnectionInfoByUUID(id)
 				if err != nil {
-					o.logger.Error("Error polling machine %s: %v", connInfo.SessionID, err)
+					o.logger.Error("Error polling machine", "UUID", id, "sessionID", connInfo.SessionID, "error", err)
 					continue
 				}
-				dataResponse := data.ToResponse()
-				dataJSON := data.ToJSON()
-				err = o.producer.Produce(context.Background(), []byte(dataResponse.MachineId), []byte(dataJSON))
+				data, err := o.readMachineData(id, current, newMachine)
 				if err != nil {
-					o.logger.Error("Failed to send data to Kafka", "machineId", dataResponse.MachineId, "error", err)
+					o.logger.Error("Error polling machine", "UUID", id, "sessionID", connInfo.SessionID, "error", err)
+					continue
 				}
+				o.publishMachineData(id, data)
 			}
 		}
 	}()
@@ -70,6 +80,27 @@ func (o *OpcCommunicator) StopPollingForMachine(id uuid.UUID) error {
 	o.mu.Unlock()
 
 	cancel()
-	o.logger.Info("Polling manually stopped for machine %s", id)
+	o.logger.Info("Polling manually stopped for machine", "UUID", id)
 	return nil
 }
+
+// publishMachineData отправляет снимок данных станка с активными тревогами в Kafka
+func (o *OpcCommunicator) publishMachineData(id uuid.UUID, data interfaces.MachineData) {
+	dataResponse := data.ToResponse()
+	o.applyAlarms(id, &dataResponse)
+	if dataResponse.MachineId != "" {
+		o.mu.Lock()
+		o.machineIDs[id] = dataResponse.MachineId
+		o.mu.Unlock()
+	}
+
+	dataJSON, err := json.MarshalIndent(dataResponse, "", "  ")
+	if err != nil {
+		o.logger.Error("Failed to marshal machine data", "UUID", id, "error", err)
+		return
+	}
+	err = o.producer.Produce(context.Background(), []byte(dataResponse.MachineId), dataJSON)
+	if err != nil {
+		o.logger.Error("Failed to send data to Kafka", "machineId", dataResponse.MachineId, "error", err)
+	}
+}
@@ -5,21 +5,112 @@ import (
 	"fmt"
 	"github.com/awcullen/opcua/client"
 	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/opc_custom"
 )
 
+// defaultMaxNodesPerRead используется, если сервер не сообщает ограничение MaxNodesPerRead (0 - без ограничения)
+const defaultMaxNodesPerRead = 1000
+
+// readLimit - ограничение MaxNodesPerRead, прочитанное для конкретной сессии
+type readLimit struct {
+	sessionID       string
+	maxNodesPerRead int
+}
+
 // readNodeValue читает один узел с сервера OPC UA
-func (oc *OpcCommunicator) readNodeValue(ctx context.Context, c *client.Client, nodeID ua.NodeIDNumeric) (ua.Variant, error) {
-	req := &ua.ReadRequest{
-		NodesToRead: []ua.ReadValueID{
-			{NodeID: nodeID, AttributeID: ua.AttributeIDValue},
-		},
-	}
-	resp, err := c.Read(ctx, req)
+func (oc *OpcCommunicator) readNodeValue(ctx context.Context, c *client.Client, nodeID ua.NodeID) (ua.Variant, error) {
+	results, err := oc.readNodeValues(ctx, c, []ua.NodeID{nodeID}, 1)
 	if err != nil {
-		return nil, fmt.Errorf("read request failed: %w", err)
+		return nil, err
+	}
+	if results[0].StatusCode.IsBad() {
+		return nil, fmt.Errorf("read node %s: %w", nodeID, results[0].StatusCode)
+	}
+	return results[0].Value, nil
+}
+
+// readNodeValues читает значения узлов минимальным числом ReadRequest, не превышая maxNodesPerRead узлов в запросе.
+// Результаты возвращаются в порядке nodeIDs, у каждого свой StatusCode.
+func (oc *OpcCommunicator) readNodeValues(ctx context.Context, c *client.Client, nodeIDs []ua.NodeID, maxNodesPerRead int) ([]ua.DataValue, error) {
+	nodesToRead := make([]ua.ReadValueID, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		nodesToRead = append(nodesToRead, ua.ReadValueID{NodeID: nodeID, AttributeID: ua.AttributeIDValue})
+	}
+	return oc.readAttributes(ctx, c, nodesToRead, maxNodesPerRead)
+}
+
+// readAttributes читает произвольные атрибуты узлов пакетами не больше maxNodesPerRead.
+// Результаты возвращаются в порядке toRead, динамические структуры - в виде map[string]any.
+func (oc *OpcCommunicator) readAttributes(ctx context.Context, c *client.Client, toRead []ua.ReadValueID, maxNodesPerRead int) ([]ua.DataValue, error) {
+	if c == nil {
+		return nil, fmt.Errorf("no active session")
+	}
+	results := make([]ua.DataValue, 0, len(toRead))
+	for _, batch := range readBatches(len(toRead), maxNodesPerRead) {
+		nodesToRead := toRead[batch[0]:batch[1]]
+
+		resp, err := c.Read(ctx, &ua.ReadRequest{NodesToRead: nodesToRead, TimestampsToReturn: ua.TimestampsToReturnBoth})
+		if err != nil {
+			return nil, fmt.Errorf("read request failed: %w", err)
+		}
+		if len(resp.Results) != len(nodesToRead) {
+			return nil, fmt.Errorf("read request returned %d results for %d nodes", len(resp.Results), len(nodesToRead))
+		}
+		for _, result := range resp.Results {
+			result.Value = opc_custom.DecodeStructures(result.Value)
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// readBatches делит count узлов на пакеты не больше maxNodesPerRead и возвращает границы [начало, конец) пакетов.
+// Ограничение 0 или меньше - все узлы одним запросом
+func readBatches(count, maxNodesPerRead int) [][2]int {
+	if maxNodesPerRead <= 0 {
+		maxNodesPerRead = count
+	}
+	var batches [][2]int
+	for start := 0; start < count; start += maxNodesPerRead {
+		batches = append(batches, [2]int{start, min(start+maxNodesPerRead, count)})
+	}
+	return batches
+}
+
+// maxNodesPerRead возвращает ограничение сервера MaxNodesPerRead для текущей сессии.
+// Значение читается один раз на сессию и перечитывается после переподключения.
+func (oc *OpcCommunicator) maxNodesPerRead(ctx context.Context, id uuid.UUID, connInfo *models.ConnectionInfo) int {
+	connInfo.Mu.RLock()
+	conn, sessionID := connInfo.Conn, connInfo.SessionID
+	connInfo.Mu.RUnlock()
+
+	oc.mu.Lock()
+	limit, ok := oc.readLimits[id]
+	oc.mu.Unlock()
+	if ok && limit.sessionID == sessionID {
+		return limit.maxNodesPerRead
 	}
-	if len(resp.Results) == 0 {
-		return nil, fmt.Errorf("no results for node %s", nodeID)
+
+	limit = readLimit{sessionID: sessionID, maxNodesPerRead: defaultMaxNodesPerRead}
+	results, err := oc.readNodeValues(ctx, conn, []ua.NodeID{ua.VariableIDServerServerCapabilitiesOperationLimitsMaxNodesPerRead}, 1)
+	switch {
+	case err != nil:
+		// Сессия недоступна: не кэшируем, попробуем при следующем чтении
+		return defaultMaxNodesPerRead
+	case results[0].StatusCode.IsBad():
+		oc.logger.Warn("Server does not report MaxNodesPerRead, using default", "UUID", id, "status", results[0].StatusCode, "default", defaultMaxNodesPerRead)
+	default:
+		if v, ok := results[0].Value.(uint32); ok && v > 0 {
+			limit.maxNodesPerRead = int(v)
+		}
 	}
-	return resp.Results[0].Value, nil
+
+	oc.mu.Lock()
+	oc.readLimits[id] = limit
+	oc.mu.Unlock()
+
+	oc.logger.Info("Read operation limit detected", "UUID", id, "sessionID", sessionID, "maxNodesPerRead", limit.maxNodesPerRead)
+	return limit.maxNodesPerRead
 }
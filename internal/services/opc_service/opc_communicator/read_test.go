@@ -0,0 +1,31 @@
+package opc_communicator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadBatches(t *testing.T) {
+	tests := []struct {
+		name            string
+		count           int
+		maxNodesPerRead int
+		want            [][2]int
+	}{
+		{name: "нет узлов", count: 0, maxNodesPerRead: 10, want: nil},
+		{name: "без ограничения", count: 7, maxNodesPerRead: 0, want: [][2]int{{0, 7}}},
+		{name: "отрицательное ограничение", count: 3, maxNodesPerRead: -1, want: [][2]int{{0, 3}}},
+		{name: "меньше ограничения", count: 5, maxNodesPerRead: 10, want: [][2]int{{0, 5}}},
+		{name: "ровно ограничение", count: 10, maxNodesPerRead: 10, want: [][2]int{{0, 10}}},
+		{name: "неполный последний пакет", count: 25, maxNodesPerRead: 10, want: [][2]int{{0, 10}, {10, 20}, {20, 25}}},
+		{name: "по одному узлу", count: 3, maxNodesPerRead: 1, want: [][2]int{{0, 1}, {1, 2}, {2, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readBatches(tt.count, tt.maxNodesPerRead); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("readBatches(%d, %d) = %v, want %v", tt.count, tt.maxNodesPerRead, got, tt.want)
+			}
+		})
+	}
+}
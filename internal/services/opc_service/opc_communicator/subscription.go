@@ -0,0 +1,286 @@
+package opc_communicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	connection_models "opc_ua_service/internal/domain/models/connection_models"
+	"opc_ua_service/internal/interfaces"
+	"opc_ua_service/pkg/errors"
+	"opc_ua_service/pkg/opc_custom"
+	"strings"
+	"time"
+)
+
+// Параметры подписки OPC UA
+const (
+	subscriptionKeepAliveCount = 10              // публикаций без изменений до keep-alive сообщения
+	subscriptionLifetimeCount  = 30              // публикаций без Publish запросов до удаления подписки сервером
+	subscriptionRetryDelay     = 5 * time.Second // пауза перед пересозданием подписки после потери сессии
+)
+
+// StartSubscriptionForMachine запускает получение данных станка через подписку OPC UA.
+// Сервер присылает изменения узлов модели, из них собирается снимок станка и отправляется в Kafka.
+// После переподключения сессии подписка создаётся заново.
+func (o *OpcCommunicator) StartSubscriptionForMachine(id uuid.UUID, settings connection_models.SubscriptionSettings) error {
+	if err := settings.Validate(); err != nil {
+		return fmt.Errorf("invalid subscription settings: %w", err)
+	}
+
+	connInfo, err := o.connector.FindConnectionInfo(id)
+	if err != nil {
+		return err
+	}
+	if connInfo == nil {
+		return errors.NewNotFoundError("connection not found")
+	}
+
+	machine := interfaces.MachineDataFactory(id, connInfo.Manufacturer, connInfo.Model)
+	if machine == nil {
+		return fmt.Errorf("unsupported machine type: %s %s", connInfo.Manufacturer, connInfo.Model)
+	}
+	// Узлы модели, найденные обходом, известны только после подключения: проверяется лишь формат NodeID
+	_, discoverable := machine.(interfaces.DiscoverableMachineData)
+	if err := validateItemSettings(machine.GetRelevantNodeIDs(), discoverable, settings); err != nil {
+		return err
+	}
+
+	if settings.PublishingInterval == 0 {
+		settings.PublishingInterval = int(connection_models.PollInterval(connInfo.Config.Config.GetTimeout()) / time.Millisecond)
+	}
+
+	o.mu.Lock()
+	if _, exists := o.pollCancelMap[id]; exists {
+		o.mu.Unlock()
+		return fmt.Errorf("polling already started for machine %s", id)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	o.pollCancelMap[id] = cancel
+	o.mu.Unlock()
+
+	setPolled(connInfo, true)
+	go func() {
+		defer setPolled(connInfo, false)
+		defer o.clearAlarms(id)
+		o.runSubscription(ctx, id, connInfo, machine, settings)
+	}()
+
+	o.logger.Info("Subscription started", "UUID", id, "publishingInterval", settings.PublishingInterval)
+	return nil
+}
+
+// setPolled отмечает, что для соединения идёт сбор данных
+func setPolled(connInfo *models.ConnectionInfo, polled bool) {
+	connInfo.Mu.Lock()
+	connInfo.IsPolled = polled
+	connInfo.Mu.Unlock()
+}
+
+// validateItemSettings проверяет, что параметры заданы для узлов модели. Узел задаётся ключом модели
+// (nsu=<URI>;<идентификатор>) или NodeID сервера (ns=<индекс>;<идентификатор>), который сопоставляется
+// с узлами модели после разрешения пространств имён сессии. Для модели с узлами, найденными обходом
+// (discoverable), ключи nsu= не сверяются: узлы станут известны после подключения
+func validateItemSettings(modelNodes []ua.ExpandedNodeID, discoverable bool, settings connection_models.SubscriptionSettings) error {
+	if len(modelNodes) == 0 && !discoverable {
+		return fmt.Errorf("no nodes defined for this machine model")
+	}
+	known := make(map[string]bool, len(modelNodes))
+	for _, node := range modelNodes {
+		known[node.String()] = true
+	}
+	for _, item := range settings.Items {
+		if strings.HasPrefix(item.NodeID, "nsu=") {
+			if !discoverable && !known[item.NodeID] {
+				return fmt.Errorf("node %s is not read for this machine model", item.NodeID)
+			}
+			continue
+		}
+		if ua.ParseNodeID(item.NodeID) == nil {
+			return fmt.Errorf("invalid nodeID %s", item.NodeID)
+		}
+	}
+	return nil
+}
+
+// buildMonitoredItems формирует MonitoredItems для разрешённых узлов модели. ClientHandle равен индексу узла в nodes
+func buildMonitoredItems(nodes resolvedNodes, settings connection_models.SubscriptionSettings) []ua.MonitoredItemCreateRequest {
+	items := make([]ua.MonitoredItemCreateRequest, 0, len(nodes.nodeIDs))
+	for i, nodeID := range nodes.nodeIDs {
+		itemSettings := settings.ItemSettings(nodes.keys[i])
+		if serverID := fmt.Sprint(nodeID); !settings.HasItem(nodes.keys[i]) && settings.HasItem(serverID) {
+			itemSettings = settings.ItemSettings(serverID)
+		}
+
+		params := ua.MonitoringParameters{
+			ClientHandle:     uint32(i),
+			SamplingInterval: float64(itemSettings.SamplingInterval),
+			QueueSize:        itemSettings.QueueSize,
+			DiscardOldest:    true,
+		}
+		switch itemSettings.DeadbandType {
+		case connection_models.DeadbandAbsolute:
+			params.Filter = ua.DataChangeFilter{Trigger: ua.DataChangeTriggerStatusValue, DeadbandType: uint32(ua.DeadbandTypeAbsolute), DeadbandValue: itemSettings.DeadbandValue}
+		case connection_models.DeadbandPercent:
+			params.Filter = ua.DataChangeFilter{Trigger: ua.DataChangeTriggerStatusValue, DeadbandType: uint32(ua.DeadbandTypePercent), DeadbandValue: itemSettings.DeadbandValue}
+		}
+
+		items = append(items, ua.MonitoredItemCreateRequest{
+			ItemToMonitor:       ua.ReadValueID{NodeID: nodeID, AttributeID: ua.AttributeIDValue},
+			MonitoringMode:      ua.MonitoringModeReporting,
+			RequestedParameters: params,
+		})
+	}
+	return items
+}
+
+// runSubscription поддерживает подписку, пока не будет остановлено получение данных.
+// Пока сессия переподключается, ждёт её восстановления, затем разрешает узлы модели по пространствам имён
+// новой сессии и создаёт подписку заново.
+func (o *OpcCommunicator) runSubscription(ctx context.Context, id uuid.UUID, connInfo *models.ConnectionInfo, machine interfaces.MachineData, settings connection_models.SubscriptionSettings) {
+	for {
+		connInfo.Mu.RLock()
+		var conn *client.Client
+		if connInfo.IsHealthy() {
+			conn = connInfo.Conn
+		}
+		connInfo.Mu.RUnlock()
+
+		if conn != nil {
+			nodes, err := o.resolveModelNodes(ctx, id, connInfo, machine)
+			if err == nil {
+				items := append(buildMonitoredItems(nodes, settings), alarmMonitoredItem())
+				err = o.subscribe(ctx, id, conn, machine, nodes, items, settings.PublishingInterval)
+			}
+			if ctx.Err() != nil {
+				o.logger.Info("Subscription stopped", "UUID", id)
+				return
+			}
+			o.logger.Warn("Subscription lost, it will be recreated", "UUID", id, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			o.logger.Info("Subscription stopped", "UUID", id)
+			return
+		case <-time.After(subscriptionRetryDelay):
+		}
+	}
+}
+
+// subscribe создаёт подписку в сессии conn и обрабатывает уведомления, пока сессия жива
+func (o *OpcCommunicator) subscribe(ctx context.Context, id uuid.UUID, conn *client.Client, machine interfaces.MachineData, nodes resolvedNodes, items []ua.MonitoredItemCreateRequest, publishingInterval int) error {
+	sub, err := conn.CreateSubscription(ctx, &ua.CreateSubscriptionRequest{
+		RequestedPublishingInterval: float64(publishingInterval),
+		RequestedMaxKeepAliveCount:  subscriptionKeepAliveCount,
+		RequestedLifetimeCount:      subscriptionLifetimeCount,
+		PublishingEnabled:           true,
+	})
+	if err != nil {
+		return fmt.Errorf("create subscription: %w", err)
+	}
+	defer o.deleteSubscription(conn, sub.SubscriptionID)
+
+	created, err := conn.CreateMonitoredItems(ctx, &ua.CreateMonitoredItemsRequest{
+		SubscriptionID:     sub.SubscriptionID,
+		TimestampsToReturn: ua.TimestampsToReturnBoth,
+		ItemsToCreate:      items,
+	})
+	if err != nil {
+		return fmt.Errorf("create monitored items: %w", err)
+	}
+
+	// Последний MonitoredItem - события тревог, сервер без поддержки событий его отклоняет
+	var failed int
+	for i, result := range created.Results {
+		if i >= len(nodes.keys) {
+			if result.StatusCode.IsBad() {
+				o.logger.Warn("Server does not support alarm events, alarms are not monitored", "UUID", id, "status", result.StatusCode)
+			} else {
+				o.refreshAlarms(ctx, id, conn, sub.SubscriptionID)
+			}
+			continue
+		}
+		if result.StatusCode.IsBad() {
+			failed++
+			o.logger.Error("Failed to create monitored item", "UUID", id, "nodeID", nodes.keys[i], "status", result.StatusCode)
+		}
+	}
+	if failed == len(nodes.keys) {
+		return fmt.Errorf("server rejected all %d monitored items", failed)
+	}
+	if failed > 0 {
+		o.connector.ReportReadResult(id, fmt.Errorf("server rejected %d of %d monitored items", failed, len(nodes.keys)))
+	}
+
+	// Таймаут Publish должен покрывать паузу до keep-alive сообщения
+	timeoutHint := uint32(sub.RevisedPublishingInterval*float64(sub.RevisedMaxKeepAliveCount)*2) + 10000
+	acks := []ua.SubscriptionAcknowledgement{}
+	for {
+		res, err := conn.Publish(ctx, &ua.PublishRequest{
+			RequestHeader:                ua.RequestHeader{TimeoutHint: timeoutHint},
+			SubscriptionAcknowledgements: acks,
+		})
+		if err != nil {
+			return fmt.Errorf("publish: %w", err)
+		}
+
+		// Keep-alive сообщение не содержит данных и не подтверждается
+		acks = []ua.SubscriptionAcknowledgement{}
+		if len(res.NotificationMessage.NotificationData) == 0 {
+			continue
+		}
+		acks = append(acks, ua.SubscriptionAcknowledgement{SubscriptionID: res.SubscriptionID, SequenceNumber: res.NotificationMessage.SequenceNumber})
+
+		changed, badItems, dataItems := 0, 0, 0
+		for _, data := range res.NotificationMessage.NotificationData {
+			switch body := data.(type) {
+			case ua.DataChangeNotification:
+				dataItems += len(body.MonitoredItems)
+				for _, item := range body.MonitoredItems {
+					if int(item.ClientHandle) >= len(nodes.keys) {
+						continue
+					}
+					nodeID := nodes.keys[item.ClientHandle]
+					item.Value.Value = opc_custom.DecodeStructures(item.Value.Value)
+					machine.RecordSample(nodeID, item.Value)
+					if item.Value.StatusCode.IsBad() {
+						// Плохое значение тоже попадает в снимок: потребитель увидит качество Bad
+						changed++
+						badItems++
+						o.logger.Error("Bad value in data change", "UUID", id, "nodeID", nodeID, "status", item.Value.StatusCode)
+						continue
+					}
+					if err := machine.ConvertNodeToMachineData(nodeID, item.Value.Value); err != nil {
+						o.logger.Error("Failed to convert node", "UUID", id, "nodeID", nodeID, "error", err)
+						continue
+					}
+					changed++
+				}
+			case ua.EventNotificationList:
+				o.handleAlarmEvents(id, body)
+			case ua.StatusChangeNotification:
+				return fmt.Errorf("subscription status changed: %w", body.Status)
+			}
+		}
+
+		// Состояние соединения меняют только значения узлов: уведомления о тревогах не подтверждают чтение данных
+		if badItems > 0 {
+			o.connector.ReportReadResult(id, fmt.Errorf("%d monitored items reported bad status", badItems))
+		} else if dataItems > 0 {
+			o.connector.ReportReadResult(id, nil)
+		}
+		if changed > 0 {
+			o.publishMachineData(id, machine)
+		}
+	}
+}
+
+// deleteSubscription удаляет подписку на сервере. Ошибки игнорируются: сессия могла уже закрыться
+func (o *OpcCommunicator) deleteSubscription(conn *client.Client, subscriptionID uint32) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_, _ = conn.DeleteSubscriptions(ctx, &ua.DeleteSubscriptionsRequest{SubscriptionIDs: []uint32{subscriptionID}})
+}
@@ -4,17 +4,33 @@ import (
 	"context"
 	"fmt"
 	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"opc_ua_service/internal/domain/models"
 	connectiion_models "opc_ua_service/internal/domain/models/connection_models"
 )
 
 // ConnectAnonymous Анонимное подключение
 func (oc *OpcConnector) ConnectAnonymous(config connectiion_models.AnonymousConnection) (*client.Client, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
 	defer cancel()
 
-	//TODO: Дописать параметры подключения
-	clientOpts := []client.Option{
-		//client.WithAnonymous(),
+	endpoint, _, err := oc.certManager.SelectEndpoint(ctx, config.EndpointURL, models.EndpointRequirements{
+		Policy:    models.SecurityPolicyEnum(config.Policy),
+		Mode:      models.MessageSecurityModeEnum(config.Mode),
+		TokenType: ua.UserTokenTypeAnonymous,
+		// Защищённый канал строится на сертификате приложения сервиса
+		HasClientCertificate: oc.certManager.HasApplicationCertificate(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ConnectAnonymous failed: %w", err)
+	}
+	if err := oc.certManager.PrepareServerTrust(endpoint, config.TrustOnFirstUse); err != nil {
+		return nil, fmt.Errorf("ConnectAnonymous failed: %w", err)
+	}
+
+	clientOpts, err := oc.certManager.SecureChannelOptions(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("ConnectAnonymous failed: %w", err)
 	}
 
 	conn, err := oc.createConnection(ctx, config.EndpointURL, clientOpts...)
@@ -2,30 +2,38 @@ package opc_connector
 
 import (
 	"context"
+	"crypto"
 	"fmt"
 	"github.com/awcullen/opcua/client"
+	"opc_ua_service/internal/domain/models"
 	connectiion_models "opc_ua_service/internal/domain/models/connection_models"
-	"time"
 )
 
 // ConnectWithCertificate Подключение по сертификату
 func (oc *OpcConnector) ConnectWithCertificate(config connectiion_models.CertificateConnection) (*client.Client, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
 	defer cancel()
 
-	certBytes, clientCert, clientKey := oc.certManager.DecodeClientCredentials(config.Certificate, config.Key)
-	if err := oc.certManager.VerifyKeyMatchesCert(clientCert, clientKey); err != nil {
+	certBytes, clientKey, err := oc.clientCredentials(config)
+	if err != nil {
 		return nil, err
 	}
 
-	endpoint, policyID, err := oc.certManager.SelectCertificateEndpoint(ctx, config.EndpointURL)
+	endpoint, policyID, err := oc.certManager.SelectCertificateEndpoint(ctx, config.EndpointURL, models.SecurityPolicyEnum(config.Policy), models.MessageSecurityModeEnum(config.Mode))
 	if err != nil {
 		return nil, err
 	}
 	if policyID == "" || endpoint == nil {
 		return nil, fmt.Errorf("no policy found for endpoint %s", config.EndpointURL)
 	}
-	clientOpts := oc.certManager.BuildClientOptions(endpoint, policyID, certBytes, clientKey)
+	if err := oc.certManager.PrepareServerTrust(endpoint, config.TrustOnFirstUse); err != nil {
+		return nil, err
+	}
+	clientOpts, err := oc.certManager.BuildClientOptions(endpoint, policyID, certBytes, clientKey)
+	if err != nil {
+		return nil, err
+	}
+	clientOpts = append(clientOpts, client.WithApplicationName(oc.certManager.ApplicationName()))
 
 	conn, err := oc.createConnection(ctx, config.EndpointURL, clientOpts...)
 	if err != nil {
@@ -34,3 +42,47 @@ func (oc *OpcConnector) ConnectWithCertificate(config connectiion_models.Certifi
 
 	return conn, nil
 }
+
+// clientCredentials возвращает сертификат (DER) и ключ для подключения.
+// Если сертификат в конфигурации не задан, используется сертификат приложения сервиса
+func (oc *OpcConnector) clientCredentials(config connectiion_models.CertificateConnection) ([]byte, crypto.Signer, error) {
+	if config.UsesApplicationCertificate() {
+		return oc.certManager.ApplicationCertificate()
+	}
+	if config.Credentials != nil {
+		return config.Credentials.Certificate, config.Credentials.Key, nil
+	}
+
+	credentials, err := oc.parseClientCredentials(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return credentials.Certificate, credentials.Key, nil
+}
+
+// parseClientCredentials разбирает сертификат и ключ конфигурации и проверяет, что они составляют пару
+func (oc *OpcConnector) parseClientCredentials(config connectiion_models.CertificateConnection) (*connectiion_models.ClientCredentials, error) {
+	_, clientCert, clientKey := oc.certManager.DecodeClientCredentials(config.Certificate, config.Key)
+	if clientCert == nil || clientKey == nil {
+		return nil, fmt.Errorf("invalid client certificate or key")
+	}
+	if err := oc.certManager.VerifyKeyMatchesCert(clientCert, clientKey); err != nil {
+		return nil, err
+	}
+	return &connectiion_models.ClientCredentials{Certificate: clientCert.Raw, Key: clientKey}, nil
+}
+
+// prepareCredentials один раз разбирает сертификат и ключ конфигурации подключения по сертификату.
+// Вызывается до того, как конфигурация попадёт в пул, поэтому запись в неё не пересекается с переподключением
+func (oc *OpcConnector) prepareCredentials(config connectiion_models.ConnectionConfigImpl) error {
+	c, ok := config.(*connectiion_models.CertificateConnection)
+	if !ok || c.UsesApplicationCertificate() || c.Credentials != nil {
+		return nil
+	}
+	credentials, err := oc.parseClientCredentials(*c)
+	if err != nil {
+		return err
+	}
+	c.Credentials = credentials
+	return nil
+}
@@ -0,0 +1,85 @@
+package opc_connector
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"opc_ua_service/internal/config"
+	connection_models "opc_ua_service/internal/domain/models/connection_models"
+	"opc_ua_service/internal/middleware/logging"
+	"opc_ua_service/internal/services/opc_service/cert_manager"
+	"testing"
+	"time"
+)
+
+// testClientCredentials выпускает самоподписанный сертификат клиента и возвращает его и ключ в PEM
+func testClientCredentials(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func TestPrepareCredentials(t *testing.T) {
+	logger := logging.NewLogger(&logging.Config{Level: "ERROR"}, "", "test")
+	oc := &OpcConnector{
+		certManager: cert_manager.NewCertificateManager(logger, config.PKIConfig{Dir: t.TempDir()}),
+		logger:      logger,
+	}
+	cert, key := testClientCredentials(t)
+	_, otherKey := testClientCredentials(t)
+
+	tests := []struct {
+		name    string
+		config  *connection_models.CertificateConnection
+		parsed  bool
+		wantErr bool
+	}{
+		{name: "сертификат клиента", config: &connection_models.CertificateConnection{Certificate: cert, Key: key}, parsed: true},
+		{name: "сертификат приложения", config: &connection_models.CertificateConnection{}},
+		{name: "ключ от другого сертификата", config: &connection_models.CertificateConnection{Certificate: cert, Key: otherKey}, wantErr: true},
+		{name: "повреждённый сертификат", config: &connection_models.CertificateConnection{Certificate: []byte("bad"), Key: key}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := oc.prepareCredentials(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepareCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (tt.config.Credentials != nil) != tt.parsed {
+				t.Fatalf("Credentials = %v, ожидался разбор: %v", tt.config.Credentials, tt.parsed)
+			}
+			if !tt.parsed {
+				return
+			}
+
+			// Переподключение берёт уже разобранную пару, не разбирая Certificate и Key заново
+			parsed := *tt.config.Credentials
+			dialConfig := *tt.config
+			dialConfig.Certificate, dialConfig.Key = []byte("bad"), []byte("bad")
+			gotCert, gotKey, err := oc.clientCredentials(dialConfig)
+			if err != nil {
+				t.Fatalf("clientCredentials() error = %v", err)
+			}
+			if string(gotCert) != string(parsed.Certificate) || gotKey != parsed.Key {
+				t.Fatal("clientCredentials() разобрал сертификат заново")
+			}
+		})
+	}
+}
@@ -6,17 +6,21 @@ import (
 	"github.com/awcullen/opcua/client"
 	"github.com/awcullen/opcua/ua"
 	"github.com/google/uuid"
-	"log"
 	"opc_ua_service/internal/domain/models"
 	connection_models "opc_ua_service/internal/domain/models/connection_models"
 	"opc_ua_service/internal/interfaces"
 	"opc_ua_service/internal/middleware/logging"
+	"opc_ua_service/pkg/errors"
 	_ "opc_ua_service/pkg/opc_custom"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// dialTimeout ограничивает время установки соединения с OPC UA сервером.
+// Timeout из конфигурации подключения задаёт интервал опроса и здесь не используется.
+const dialTimeout = 5 * time.Second
+
 type OpcConnector struct {
 	mu          sync.RWMutex
 	connections map[uuid.UUID]*models.ConnectionInfo // UUID -> connection info
@@ -46,92 +50,74 @@ func GenerateUUID() uuid.UUID {
 
 // FindOpenConnection ищет уже существующее соединение по UUID
 func (oc *OpcConnector) FindOpenConnection(id uuid.UUID) *models.ConnectionInfo {
-	oc.mu.Lock()
+	oc.mu.RLock()
 	info, exists := oc.connections[id]
+	oc.mu.RUnlock()
 	if !exists {
-		oc.mu.Unlock()
 		return nil
 	}
-	oc.mu.Unlock()
-
-	info.Mu.Lock()
-	defer info.Mu.Unlock()
-
-	if !oc.checkConnectionHealth(info.Conn) {
-		oc.closeConnectionInternal(id)
 
-		oc.mu.Lock()
-		delete(oc.connections, id)
-		oc.mu.Unlock()
+	info.Mu.RLock()
+	usable := info.IsHealthy()
+	conn := info.Conn
+	info.Mu.RUnlock()
+	if !usable {
+		return nil
+	}
 
-		atomic.AddInt64(&oc.stats.ActiveConnections, -1)
-		atomic.AddInt64(&oc.stats.PoolSize, -1)
+	// Неотвечающая сессия остаётся в пуле: её переподключает фоновый цикл с сохранением UUID
+	if err := oc.checkConnectionHealth(conn); err != nil {
+		oc.startReconnect(id, info, conn, err)
 		return nil
 	}
 
+	info.Mu.Lock()
 	info.LastUsed = time.Now()
+	info.Mu.Unlock()
 	atomic.AddInt64(&info.UseCount, 1)
 
 	return info
 }
 
+// CreateAnonymousConnection создаёт анонимное подключение и сохраняет его по UUID
 func (oc *OpcConnector) CreateAnonymousConnection(config connection_models.AnonymousConnection) (*uuid.UUID, error) {
-	return nil, nil
-}
+	conn, err := oc.ConnectAnonymous(config)
+	if err != nil {
+		atomic.AddInt64(&oc.stats.FailedConnections, 1)
+		oc.logger.Error("Failed to create anonymous connection", "error", err)
+		return nil, err
+	}
 
-func (oc *OpcConnector) CreatePasswordConnection(config connection_models.PasswordConnection) (*uuid.UUID, error) {
-	return nil, nil
+	return oc.registerConnection(conn, &config), nil
 }
 
-// CreateCertificateConnection создаёт новое подключение и сохраняет его по UUID
-func (oc *OpcConnector) CreateCertificateConnection(config connection_models.CertificateConnection) (*uuid.UUID, error) {
-	// Создаем новое подключение
-	conn, err := oc.ConnectWithCertificate(config)
+// CreatePasswordConnection создаёт подключение по логину и паролю и сохраняет его по UUID
+func (oc *OpcConnector) CreatePasswordConnection(config connection_models.PasswordConnection) (*uuid.UUID, error) {
+	conn, err := oc.ConnectWithPassword(config)
 	if err != nil {
 		atomic.AddInt64(&oc.stats.FailedConnections, 1)
-		log.Println(err)
+		oc.logger.Error("Failed to create password connection", "error", err)
 		return nil, err
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	sessionID := conn.SessionID().(ua.NodeIDNumeric).String()
-
-	cfg := connection_models.ConnectionConfig{
-		Config: &config,
-	}
+	return oc.registerConnection(conn, &config), nil
+}
 
-	info := &models.ConnectionInfo{
-		Conn:         conn,
-		Ctx:          ctx,
-		Cancel:       cancel,
-		SessionID:    sessionID,
-		Config:       cfg,
-		CreatedAt:    time.Now(),
-		LastUsed:     time.Now(),
-		IsHealthy:    true,
-		UseCount:     1,
-		Mu:           sync.RWMutex{},
-		Manufacturer: cfg.Config.GetManufacturer(), // <-- берем из конфига
-		Model:        cfg.Config.GetModel(),
+// CreateCertificateConnection создаёт новое подключение и сохраняет его по UUID
+func (oc *OpcConnector) CreateCertificateConnection(config connection_models.CertificateConnection) (*uuid.UUID, error) {
+	// Создаем новое подключение. Сертификат и ключ разбираются один раз и сохраняются в конфигурации пула
+	err := oc.prepareCredentials(&config)
+	var conn *client.Client
+	if err == nil {
+		conn, err = oc.ConnectWithCertificate(config)
 	}
-
-	oc.mu.Lock()
-	defer oc.mu.Unlock()
-
-	id := GenerateUUID()
-	if _, exists := oc.connections[id]; exists {
-		// Если по какой-то причине соединение уже есть, закрываем новое
-		info.Cancel()
-		_ = conn.Close(ctx)
-		return &id, nil
+	if err != nil {
+		atomic.AddInt64(&oc.stats.FailedConnections, 1)
+		oc.logger.Error("Failed to create certificate connection", "error", err)
+		return nil, err
 	}
 
-	oc.connections[id] = info
-	atomic.AddInt64(&oc.stats.TotalConnections, 1)
-	atomic.AddInt64(&oc.stats.PoolSize, 1)
-	atomic.AddInt64(&oc.stats.ActiveConnections, 1)
-
-	return &id, nil
+	return oc.registerConnection(conn, &config), nil
 }
 
 // GetConnection получает подключение по конфигу
@@ -141,7 +127,7 @@ func (oc *OpcConnector) GetConnection(config connection_models.CertificateConnec
 
 	for _, info := range oc.connections {
 		info.Mu.Lock()
-		if info.IsHealthy {
+		if info.IsHealthy() {
 			info.LastUsed = time.Now()
 			info.UseCount++
 			conn := info.Conn
@@ -199,12 +185,15 @@ func (oc *OpcConnector) closeConnectionInternal(id uuid.UUID) error {
 	ctxClose, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	if err := info.Conn.Close(ctxClose); err != nil {
-		return fmt.Errorf("failed to close connection %s: %v", info.SessionID, err)
+	if info.Conn != nil {
+		if err := info.Conn.Close(ctxClose); err != nil {
+			return fmt.Errorf("failed to close connection %s: %v", info.SessionID, err)
+		}
 	}
 
 	// Отменяем контекст
 	info.Cancel()
+	info.SetState(models.StateClosed, nil)
 
 	// Удаляем из пула
 	oc.mu.Lock()
@@ -217,8 +206,101 @@ func (oc *OpcConnector) closeConnectionInternal(id uuid.UUID) error {
 func (oc *OpcConnector) createConnection(ctx context.Context, endpoint string, opts ...client.Option) (*client.Client, error) {
 	conn, err := client.Dial(ctx, endpoint, opts...)
 	if err != nil {
+		if isServerCertificateError(err) {
+			return nil, fmt.Errorf("failed to connect: %w: %w", errors.ErrServerCertificateRejected, err)
+		}
+		if isIdentityError(err) {
+			return nil, fmt.Errorf("failed to connect: %w: %w", errors.ErrIdentityRejected, err)
+		}
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
-	log.Printf("Successfully connected to OPC UA server: %s", endpoint)
+	oc.logger.Info("Successfully connected to OPC UA server", "endpoint", endpoint)
 	return conn, nil
 }
+
+// isServerCertificateError проверяет, что подключение отклонено из-за сертификата сервера
+func isServerCertificateError(err error) bool {
+	for _, code := range []ua.StatusCode{
+		ua.BadSecurityChecksFailed,
+		ua.BadCertificateInvalid,
+		ua.BadCertificateUntrusted,
+		ua.BadCertificateTimeInvalid,
+		ua.BadCertificateHostNameInvalid,
+		ua.BadCertificateUseNotAllowed,
+		ua.BadCertificateIssuerUseNotAllowed,
+		ua.BadCertificateRevoked,
+		ua.BadCertificateIssuerRevoked,
+		ua.BadCertificateRevocationUnknown,
+	} {
+		if errors.Is(err, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdentityError проверяет, что сервер отклонил учётные данные пользователя
+func isIdentityError(err error) bool {
+	for _, code := range []ua.StatusCode{
+		ua.BadIdentityTokenInvalid,
+		ua.BadIdentityTokenRejected,
+		ua.BadUserAccessDenied,
+		ua.BadUserSignatureInvalid,
+	} {
+		if errors.Is(err, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// newConnectionInfo оборачивает соединение в ConnectionInfo. conn может быть nil, если сессию ещё предстоит установить
+func newConnectionInfo(conn *client.Client, config connection_models.ConnectionConfigImpl) *models.ConnectionInfo {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	info := &models.ConnectionInfo{
+		Conn:         conn,
+		Ctx:          ctx,
+		Cancel:       cancel,
+		Config:       connection_models.ConnectionConfig{Config: config},
+		CreatedAt:    time.Now(),
+		LastUsed:     time.Now(),
+		UseCount:     1,
+		Mu:           sync.RWMutex{},
+		Manufacturer: config.GetManufacturer(),
+		Model:        config.GetModel(),
+	}
+	info.SetState(models.StateConnecting, nil)
+	if conn != nil {
+		info.SessionID = fmt.Sprint(conn.SessionID())
+		info.SetState(models.StateConnected, nil)
+	}
+	return info
+}
+
+// registerConnection добавляет установленное соединение в пул под новым UUID
+func (oc *OpcConnector) registerConnection(conn *client.Client, config connection_models.ConnectionConfigImpl) *uuid.UUID {
+	info := newConnectionInfo(conn, config)
+
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	id := GenerateUUID()
+	for {
+		if _, exists := oc.connections[id]; !exists {
+			break
+		}
+		id = GenerateUUID()
+	}
+
+	oc.addConnectionLocked(id, info)
+	return &id
+}
+
+// addConnectionLocked кладёт ConnectionInfo в пул и обновляет статистику. Вызывается под oc.mu
+func (oc *OpcConnector) addConnectionLocked(id uuid.UUID, info *models.ConnectionInfo) {
+	oc.connections[id] = info
+	atomic.AddInt64(&oc.stats.TotalConnections, 1)
+	atomic.AddInt64(&oc.stats.PoolSize, 1)
+	atomic.AddInt64(&oc.stats.ActiveConnections, 1)
+}
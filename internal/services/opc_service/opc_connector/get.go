@@ -19,8 +19,8 @@ func (oc *OpcConnector) GetConnectionByUUID(id uuid.UUID) (*client.Client, error
 
 	info.Mu.RLock()
 	defer info.Mu.RUnlock()
-	if !info.IsHealthy {
-		return nil, fmt.Errorf("connection with UUID %s is unhealthy", id)
+	if !info.IsHealthy() {
+		return nil, fmt.Errorf("%w: connection with UUID %s is unhealthy: state %s", errors.ErrConnectionUnavailable, id, info.State())
 	}
 
 	return info.Conn, nil
@@ -37,8 +37,32 @@ func (oc *OpcConnector) GetConnectionInfoByUUID(id uuid.UUID) (*models.Connectio
 
 	info.Mu.RLock()
 	defer info.Mu.RUnlock()
-	if !info.IsHealthy {
-		return nil, fmt.Errorf("connection with UUID %s is unhealthy", id)
+	if !info.IsHealthy() {
+		return nil, fmt.Errorf("%w: connection with UUID %s is unhealthy: state %s", errors.ErrConnectionUnavailable, id, info.State())
 	}
 	return info, nil
 }
+
+// FindConnectionInfo возвращает ConnectionInfo по UUID независимо от состояния соединения
+func (oc *OpcConnector) FindConnectionInfo(id uuid.UUID) (*models.ConnectionInfo, error) {
+	oc.mu.RLock()
+	info, exists := oc.connections[id]
+	oc.mu.RUnlock()
+	if !exists {
+		return nil, errors.NewNotFoundError("connection not found")
+	}
+	return info, nil
+}
+
+// SetMachineModel задаёт производителя и модель станка соединения, например определённые по сведениям сервера.
+// Новые опросы и подписки выбирают модель данных по этим значениям
+func (oc *OpcConnector) SetMachineModel(id uuid.UUID, manufacturer, model string) error {
+	info, err := oc.FindConnectionInfo(id)
+	if err != nil {
+		return err
+	}
+	info.Mu.Lock()
+	info.Manufacturer, info.Model = manufacturer, model
+	info.Mu.Unlock()
+	return nil
+}
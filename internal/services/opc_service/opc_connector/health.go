@@ -2,8 +2,10 @@ package opc_connector
 
 import (
 	"context"
+	"fmt"
 	"github.com/awcullen/opcua/client"
 	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
 	"opc_ua_service/internal/domain/models"
 	"time"
 )
@@ -29,34 +31,50 @@ func (oc *OpcConnector) healthCheckWorker() {
 }
 
 func (oc *OpcConnector) checkAllConnectionsHealth() {
-	oc.mu.Lock()
-	defer oc.mu.Unlock()
+	// Снимок пула, чтобы не держать общий Lock во время сетевых запросов
+	oc.mu.RLock()
+	snapshot := make(map[uuid.UUID]*models.ConnectionInfo, len(oc.connections))
+	for id, info := range oc.connections {
+		snapshot[id] = info
+	}
+	oc.mu.RUnlock()
 
-	for _, info := range oc.connections {
-		info.Mu.Lock()
-		if info.IsPolled {
-			info.IsHealthy = oc.checkConnectionHealth(info.Conn)
-			if !info.IsHealthy {
-				/*
-					oc.logger.Warn("Connection is unhealthy. Attempting to reconnect...")
-					// Попытка переподключиться
-					newConn, err := oc.reconnect(info)
-					if err != nil {
-						oc.logger.Error("Failed to reconnect", "UUID", uuid, "error", err)
-					} else {
-						info.Conn = newConn
-						info.IsHealthy = true
-						oc.logger.Info("Reconnection successful", "UUID", uuid)
-					}
-				
-				*/
-			}
+	for id, info := range snapshot {
+		info.Mu.RLock()
+		skip := info.IsReconnecting() || info.State() == models.StateClosed
+		conn := info.Conn
+		info.Mu.RUnlock()
+		if skip {
+			continue
+		}
+		// Сетевое чтение идёт без блокировки: опрос и смена состояния не ждут ответа сервера
+		if err := oc.checkConnectionHealth(conn); err != nil {
+			oc.startReconnect(id, info, conn, err)
 		}
+	}
+}
+
+// startReconnect переводит соединение в Reconnecting и запускает фоновое переподключение, если за время проверки
+// сессию не закрыли, не заменили и переподключение ещё не идёт
+func (oc *OpcConnector) startReconnect(id uuid.UUID, info *models.ConnectionInfo, conn *client.Client, err error) {
+	info.Mu.Lock()
+	if info.Conn != conn || info.Ctx.Err() != nil || info.IsReconnecting() || info.State() == models.StateClosed {
 		info.Mu.Unlock()
+		return
 	}
+	info.SetState(models.StateReconnecting, err)
+	info.Mu.Unlock()
+
+	oc.logger.Warn("Connection is unhealthy. Attempting to reconnect...", "UUID", id, "error", err)
+	go oc.reconnectWithBackoff(id, info, conn)
 }
 
-func (oc *OpcConnector) checkConnectionHealth(conn *client.Client) bool {
+// checkConnectionHealth читает атрибут корневого узла и возвращает ошибку, если сессия не отвечает
+func (oc *OpcConnector) checkConnectionHealth(conn *client.Client) error {
+	if conn == nil {
+		return fmt.Errorf("no active session")
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -65,18 +83,5 @@ func (oc *OpcConnector) checkConnectionHealth(conn *client.Client) bool {
 			{NodeID: ua.ObjectIDRootFolder, AttributeID: ua.AttributeIDNodeID},
 		},
 	})
-	return err == nil
-}
-
-// recreateConnection создаёт новое подключение по информации из info
-func (oc *OpcConnector) recreateConnection(info *models.ConnectionInfo) (*client.Client, error) {
-	/*
-		newClient := client.NewClient(info.EndpointURL, info.Options...)
-		err := newClient.Connect(context.Background())
-		if err != nil {
-			return nil, err
-		}
-
-	*/
-	return nil, nil
+	return err
 }
@@ -4,19 +4,41 @@ import (
 	"context"
 	"fmt"
 	"github.com/awcullen/opcua/client"
+	"github.com/awcullen/opcua/ua"
+	"opc_ua_service/internal/domain/models"
 	connection_models "opc_ua_service/internal/domain/models/connection_models"
+	"opc_ua_service/pkg/errors"
 )
 
 // ConnectWithPassword Подключение с логином и паролем
 func (oc *OpcConnector) ConnectWithPassword(config connection_models.PasswordConnection) (*client.Client, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
 	defer cancel()
 
-	//TODO: Дописать параметры подключения
-	clientOpts := []client.Option{
-		client.WithUserNameIdentity(config.Username, config.Password),
-		// Можно добавить SecurityPolicy и Mode через настройки
+	endpoint, _, err := oc.certManager.SelectEndpoint(ctx, config.EndpointURL, models.EndpointRequirements{
+		Policy:    models.SecurityPolicyEnum(config.Policy),
+		Mode:      models.MessageSecurityModeEnum(config.Mode),
+		TokenType: ua.UserTokenTypeUserName,
+		// Защищённый канал строится на сертификате приложения сервиса
+		HasClientCertificate: oc.certManager.HasApplicationCertificate(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ConnectWithPassword failed: %w", err)
+	}
+	// По каналу без шифрования пароль уходит открытым текстом: только по явному разрешению
+	if endpoint.SecurityMode == ua.MessageSecurityModeNone && !config.AllowInsecurePassword {
+		return nil, fmt.Errorf("ConnectWithPassword failed: %w: password would be sent over an unencrypted channel of %s, "+
+			"use a Sign or SignAndEncrypt policy or set allowInsecurePassword", errors.ErrSecurityNotOffered, config.EndpointURL)
+	}
+	if err := oc.certManager.PrepareServerTrust(endpoint, config.TrustOnFirstUse); err != nil {
+		return nil, fmt.Errorf("ConnectWithPassword failed: %w", err)
+	}
+
+	clientOpts, err := oc.certManager.SecureChannelOptions(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("ConnectWithPassword failed: %w", err)
 	}
+	clientOpts = append(clientOpts, client.WithUserNameIdentity(config.Username, config.Password))
 
 	conn, err := oc.createConnection(ctx, config.EndpointURL, clientOpts...)
 	if err != nil {
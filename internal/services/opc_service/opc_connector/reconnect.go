@@ -0,0 +1,147 @@
+package opc_connector
+
+import (
+	"context"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/google/uuid"
+	"math/rand"
+	"opc_ua_service/internal/domain/models"
+	connection_models "opc_ua_service/internal/domain/models/connection_models"
+	"opc_ua_service/pkg/errors"
+	"time"
+)
+
+// Параметры экспоненциальной задержки между попытками переподключения
+const (
+	reconnectInitialDelay = 2 * time.Second
+	reconnectMaxDelay     = 2 * time.Minute
+	reconnectJitter       = 0.2 // ±20% от текущей задержки
+)
+
+// reconnectWithBackoff переподключает сессию conn в фоне, пока не получится,
+// либо пока соединение не будет закрыто или сервис не остановится.
+// Новый клиент подменяется в существующем ConnectionInfo, поэтому UUID и опрос сохраняются.
+// Если сессию conn тем временем заменили (смена сертификата или другой цикл), цикл завершается,
+// не трогая состояние и клиента новой сессии
+func (oc *OpcConnector) reconnectWithBackoff(id uuid.UUID, info *models.ConnectionInfo, conn *client.Client) {
+	delay := reconnectInitialDelay
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-time.After(withJitter(delay)):
+		case <-info.Ctx.Done():
+			return
+		case <-oc.shutdown:
+			return
+		}
+
+		info.Mu.RLock()
+		replaced := info.Conn != conn
+		info.Mu.RUnlock()
+		if replaced {
+			oc.logger.Info("Reconnect stopped, session was replaced", "UUID", id, "attempt", attempt)
+			return
+		}
+
+		newConn, err := oc.recreateConnection(info)
+		if err != nil {
+			state := stateForDialError(err)
+			if !setStateIfCurrent(info, conn, state, err) {
+				oc.logger.Info("Reconnect stopped, session was closed or replaced", "UUID", id, "attempt", attempt)
+				return
+			}
+
+			oc.logger.Warn("Reconnect attempt failed", "UUID", id, "attempt", attempt, "state", state, "error", err)
+			delay = nextReconnectDelay(delay, state)
+			continue
+		}
+
+		info.Mu.Lock()
+		// Соединение могли закрыть или заменить, пока шёл Dial
+		if info.Ctx.Err() != nil || info.Conn != conn {
+			info.Mu.Unlock()
+			_ = oc.abort(newConn)
+			return
+		}
+		info.Conn = newConn
+		sessionID := fmt.Sprint(newConn.SessionID())
+		info.SessionID = sessionID
+		info.SetState(models.StateConnected, nil)
+		info.LastUsed = time.Now()
+		info.Mu.Unlock()
+
+		if conn != nil {
+			_ = oc.abort(conn)
+		}
+
+		oc.logger.Info("Reconnection successful", "UUID", id, "attempt", attempt, "sessionID", sessionID)
+		return
+	}
+}
+
+// setStateIfCurrent меняет состояние соединения, только если в нём всё ещё сессия conn и его не закрыли.
+// Возвращает false, если сессию заменили или соединение закрыто
+func setStateIfCurrent(info *models.ConnectionInfo, conn *client.Client, state models.ConnectionStateEnum, err error) bool {
+	info.Mu.Lock()
+	defer info.Mu.Unlock()
+	if info.Ctx.Err() != nil || info.Conn != conn {
+		return false
+	}
+	info.SetState(state, err)
+	return true
+}
+
+// recreateConnection создаёт новое подключение по исходной конфигурации из info
+func (oc *OpcConnector) recreateConnection(info *models.ConnectionInfo) (*client.Client, error) {
+	info.Mu.RLock()
+	cfg := info.Config.Config
+	info.Mu.RUnlock()
+
+	return oc.dialByConfig(cfg)
+}
+
+// dialByConfig устанавливает соединение способом, соответствующим типу конфигурации
+func (oc *OpcConnector) dialByConfig(cfg connection_models.ConnectionConfigImpl) (*client.Client, error) {
+	switch c := cfg.(type) {
+	case *connection_models.CertificateConnection:
+		return oc.ConnectWithCertificate(*c)
+	case *connection_models.PasswordConnection:
+		return oc.ConnectWithPassword(*c)
+	case *connection_models.AnonymousConnection:
+		return oc.ConnectAnonymous(*c)
+	default:
+		return nil, fmt.Errorf("unsupported connection config type: %T", cfg)
+	}
+}
+
+// stateForDialError определяет состояние соединения по ошибке подключения:
+// отказ в учётных данных или сертификате отличается от недоступности станка
+func stateForDialError(err error) models.ConnectionStateEnum {
+	if errors.Is(err, errors.ErrIdentityRejected) || errors.Is(err, errors.ErrServerCertificateRejected) {
+		return models.StateAuthFailed
+	}
+	return models.StateReconnecting
+}
+
+// nextReconnectDelay возвращает задержку перед следующей попыткой: удвоенную, но не больше reconnectMaxDelay.
+// Отклонённые учётные данные сами не исправятся, поэтому после AuthFailed сервер не нагружается частыми попытками входа
+func nextReconnectDelay(delay time.Duration, state models.ConnectionStateEnum) time.Duration {
+	delay *= 2
+	if delay > reconnectMaxDelay || state == models.StateAuthFailed {
+		return reconnectMaxDelay
+	}
+	return delay
+}
+
+// withJitter добавляет к задержке случайное отклонение, чтобы станки не переподключались одновременно
+func withJitter(d time.Duration) time.Duration {
+	spread := float64(d) * reconnectJitter
+	return d + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// abort обрывает соединение без корректного закрытия сессии
+func (oc *OpcConnector) abort(conn *client.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return conn.Abort(ctx)
+}
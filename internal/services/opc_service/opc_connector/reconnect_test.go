@@ -0,0 +1,103 @@
+package opc_connector
+
+import (
+	"context"
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/errors"
+	"testing"
+	"time"
+)
+
+func TestNextReconnectDelay(t *testing.T) {
+	tests := []struct {
+		name  string
+		delay time.Duration
+		state models.ConnectionStateEnum
+		want  time.Duration
+	}{
+		{name: "первая попытка", delay: reconnectInitialDelay, state: models.StateReconnecting, want: 4 * time.Second},
+		{name: "удвоение", delay: 16 * time.Second, state: models.StateReconnecting, want: 32 * time.Second},
+		{name: "ровно максимум", delay: reconnectMaxDelay / 2, state: models.StateReconnecting, want: reconnectMaxDelay},
+		{name: "ограничение максимумом", delay: 90 * time.Second, state: models.StateReconnecting, want: reconnectMaxDelay},
+		{name: "учётные данные отклонены", delay: reconnectInitialDelay, state: models.StateAuthFailed, want: reconnectMaxDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextReconnectDelay(tt.delay, tt.state); got != tt.want {
+				t.Fatalf("nextReconnectDelay(%v, %s) = %v, want %v", tt.delay, tt.state, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateForDialError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want models.ConnectionStateEnum
+	}{
+		{name: "станок недоступен", err: fmt.Errorf("failed to connect: dial tcp: connection refused"), want: models.StateReconnecting},
+		{name: "учётные данные", err: fmt.Errorf("failed to connect: %w", errors.ErrIdentityRejected), want: models.StateAuthFailed},
+		{name: "сертификат сервера", err: fmt.Errorf("failed to connect: %w", errors.ErrServerCertificateRejected), want: models.StateAuthFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stateForDialError(tt.err); got != tt.want {
+				t.Fatalf("stateForDialError() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	delay := 10 * time.Second
+	spread := time.Duration(float64(delay) * reconnectJitter)
+	for i := 0; i < 1000; i++ {
+		if got := withJitter(delay); got < delay-spread || got > delay+spread {
+			t.Fatalf("withJitter(%v) = %v, вне ±%v", delay, got, spread)
+		}
+	}
+}
+
+func TestSetStateIfCurrent(t *testing.T) {
+	reconnecting, rotated := &client.Client{}, &client.Client{}
+	errDial := fmt.Errorf("failed to connect: %w", errors.ErrIdentityRejected)
+
+	tests := []struct {
+		name    string
+		current *client.Client
+		closed  bool
+		want    bool
+		state   models.ConnectionStateEnum
+	}{
+		{name: "та же сессия", current: reconnecting, want: true, state: models.StateAuthFailed},
+		{name: "сессию заменила смена сертификата", current: rotated, state: models.StateConnected},
+		{name: "соединение закрыто", current: reconnecting, closed: true, state: models.StateConnected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			info := &models.ConnectionInfo{Conn: tt.current, Ctx: ctx, Cancel: cancel}
+			info.SetState(models.StateConnected, nil)
+			if tt.closed {
+				cancel()
+			}
+
+			if got := setStateIfCurrent(info, reconnecting, models.StateAuthFailed, errDial); got != tt.want {
+				t.Fatalf("setStateIfCurrent() = %v, want %v", got, tt.want)
+			}
+			if got := info.State(); got != tt.state {
+				t.Fatalf("состояние %s, ожидалось %s", got, tt.state)
+			}
+			if info.Conn != tt.current {
+				t.Fatal("клиент сессии заменён")
+			}
+		})
+	}
+}
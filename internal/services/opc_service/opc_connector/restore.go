@@ -1,58 +1,55 @@
 package opc_connector
 
-/*
-// RestoreConnection восстанавливает подключение из БД в пул памяти.
-// Эта функция теперь всегда успешна, даже если эндпоинт недоступен,
-// помечая такое соединение как IsHealthy: false.
-func (oc *OpcConnector) RestoreConnection(machine entities.CncMachine) (*models.ConnectionInfo, error) {
-	req := models.ConnectionRequest{
-		EndpointURL:  machine.EndpointURL,
-		Model:        machine.Model,
-		Manufacturer: machine.Manufacturer,
-	}
+import (
+	"fmt"
+	"github.com/awcullen/opcua/client"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	connection_models "opc_ua_service/internal/domain/models/connection_models"
+	"sync/atomic"
+)
 
-	if machine.ConnectionType == "certificate" {
-		connection, err := oc.CreateCertificateConnection()
-		if err != nil {
-			return nil, err
-		}
+// RestoreConnection восстанавливает подключение в пул под заданным UUID.
+// Если сервер сейчас недоступен, соединение всё равно добавляется в пул как нездоровое
+// и переподключается в фоне, чтобы UUID станка не менялся между перезапусками.
+func (oc *OpcConnector) RestoreConnection(id uuid.UUID, config connection_models.ConnectionConfigImpl) (*models.ConnectionInfo, error) {
+	oc.mu.RLock()
+	_, exists := oc.connections[id]
+	oc.mu.RUnlock()
+	if exists {
+		return nil, fmt.Errorf("connection with UUID %s already exists", id)
 	}
 
-	// Создаем базовый объект подключения, по умолчанию нездоровый
-	connInfo := createConnectionInfo(machine.UUID, "unknown", req, machine.Manufacturer)
-	connInfo.IsHealthy = false
+	err := oc.prepareCredentials(config)
+	var conn *client.Client
+	if err == nil {
+		conn, err = oc.dialByConfig(config)
+	}
+	if err != nil {
+		atomic.AddInt64(&oc.stats.FailedConnections, 1)
+		oc.logger.Warn("Failed to restore connection. It will be retried in background.", "UUID", id, "error", err)
+	}
 
-	probeURL := strings.TrimSuffix(machine.EndpointURL, "/") + "/probe"
-	xmlData, err := client.FetchXML(probeURL)
+	info := newConnectionInfo(conn, config)
 	if err != nil {
-		oc.logger.Warn("Failed to get /probe for session. Connection will be restored as unhealthy.", "sessionID", machine.UUID, "error", err)
-	} else {
-		var devices models.MTConnectDevices
-		if err := xml.Unmarshal(xmlData, &devices); err != nil {
-			s.logger.Warn("Failed to parse /probe for session.", "sessionID", machine.UUID, "error", err)
-		} else if len(devices.Devices) == 0 {
-			s.logger.Warn("No devices found in /probe for session.", "sessionID", machine.UUID)
-		} else {
-			targetDevice, err := findTargetDevice(&devices, machine.Model, machine.Manufacturer, machine.EndpointURL)
-			if err != nil {
-				s.logger.Warn("Target device not found for session.", "sessionID", machine.UUID, "error", err)
-			} else {
-				// Все успешно, обновляем информацию и статус
-				connInfo.MachineID = targetDevice.Name
-				connInfo.Config.Manufacturer = targetDevice.Description.Manufacturer
-				connInfo.IsHealthy = true
-				if err := s.pollingMgr.LoadMetadataForEndpoint(machine.EndpointURL); err != nil {
-					s.logger.Warn("Failed to load metadata for endpoint.", "endpoint", machine.EndpointURL, "error", err)
-				}
-			}
-		}
+		info.SetState(stateForDialError(err), err)
 	}
 
 	oc.mu.Lock()
-	defer oc.mu.Unlock()
-	oc.connections[(machine.UUID).toString()] = connInfo
+	if _, exists := oc.connections[id]; exists {
+		oc.mu.Unlock()
+		info.Cancel()
+		if conn != nil {
+			_ = oc.abort(conn)
+		}
+		return nil, fmt.Errorf("connection with UUID %s already exists", id)
+	}
+	oc.addConnectionLocked(id, info)
+	oc.mu.Unlock()
 
-	return connInfo, nil
-}
+	if conn == nil {
+		go oc.reconnectWithBackoff(id, info, nil)
+	}
 
-*/
+	return info, nil
+}
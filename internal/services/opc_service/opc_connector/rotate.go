@@ -0,0 +1,49 @@
+package opc_connector
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	connection_models "opc_ua_service/internal/domain/models/connection_models"
+	"time"
+)
+
+// UpdateConnectionConfig переустанавливает сессию с новой конфигурацией (например, после смены сертификата).
+// Старая сессия закрывается только после успешного подключения с новыми данными,
+// UUID и опрос станка при этом сохраняются. Фоновое переподключение старой сессии (например, после AuthFailed
+// из-за истёкшего сертификата) завершается само, увидев новую сессию, и её не трогает.
+func (oc *OpcConnector) UpdateConnectionConfig(id uuid.UUID, config connection_models.ConnectionConfigImpl) error {
+	info, err := oc.FindConnectionInfo(id)
+	if err != nil {
+		return err
+	}
+
+	if err := oc.prepareCredentials(config); err != nil {
+		return fmt.Errorf("failed to connect with new configuration: %w", err)
+	}
+	newConn, err := oc.dialByConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to connect with new configuration: %w", err)
+	}
+
+	info.Mu.Lock()
+	if info.Ctx.Err() != nil {
+		info.Mu.Unlock()
+		_ = oc.abort(newConn)
+		return fmt.Errorf("connection %s was closed during update", id)
+	}
+	oldConn := info.Conn
+	info.Conn = newConn
+	info.Config = connection_models.ConnectionConfig{Config: config}
+	info.SessionID = fmt.Sprint(newConn.SessionID())
+	info.SetState(models.StateConnected, nil)
+	info.LastUsed = time.Now()
+	info.Mu.Unlock()
+
+	if oldConn != nil {
+		_ = oc.abort(oldConn)
+	}
+
+	oc.logger.Info("Connection configuration updated", "UUID", id, "sessionID", info.SessionID)
+	return nil
+}
@@ -54,11 +54,14 @@ func (oc *OpcConnector) CloseAll() {
 	for _, info := range conns {
 		info.Mu.Lock()
 		ctxClose, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-		if err := info.Conn.Close(ctxClose); err != nil {
-			oc.logger.Error("Failed to close connection %s: %v", info.SessionID, err)
+		if info.Conn != nil {
+			if err := info.Conn.Close(ctxClose); err != nil {
+				oc.logger.Error("Failed to close connection %s: %v", info.SessionID, err)
+			}
 		}
 		cancel()
 		info.Cancel()
+		info.SetState(models.StateClosed, nil)
 		info.Mu.Unlock()
 	}
 }
@@ -83,11 +86,15 @@ func (oc *OpcConnector) CloseConnection(id uuid.UUID) error {
 	ctxClose, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	if err := info.Conn.Close(ctxClose); err != nil {
-		return fmt.Errorf("failed to close connection %s: %v", info.SessionID, err)
-	}
-
+	// Отменяем контекст до закрытия, чтобы остановить фоновое переподключение
 	info.Cancel()
+	info.SetState(models.StateClosed, nil)
+
+	if info.Conn != nil {
+		if err := info.Conn.Close(ctxClose); err != nil {
+			return fmt.Errorf("failed to close connection %s: %v", info.SessionID, err)
+		}
+	}
 
 	oc.logger.Info("Connection with UUID %s closed successfully", id)
 	return nil
@@ -0,0 +1,32 @@
+package opc_connector
+
+import (
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+)
+
+// ReportReadResult учитывает результат чтения данных станка в состоянии соединения.
+// Ошибки чтения при живой сессии переводят её в Degraded, успешное чтение возвращает в Connected.
+// Остальные состояния управляются проверкой здоровья и переподключением и здесь не меняются.
+func (oc *OpcConnector) ReportReadResult(id uuid.UUID, err error) {
+	oc.mu.RLock()
+	info, exists := oc.connections[id]
+	oc.mu.RUnlock()
+	if !exists {
+		return
+	}
+
+	info.Mu.Lock()
+	defer info.Mu.Unlock()
+
+	switch {
+	case err != nil && info.State() == models.StateConnected:
+		oc.logger.Warn("Connection is degraded", "UUID", id, "error", err)
+		info.SetState(models.StateDegraded, err)
+	case err != nil && info.State() == models.StateDegraded:
+		info.SetState(models.StateDegraded, err)
+	case err == nil && info.State() == models.StateDegraded:
+		oc.logger.Info("Connection recovered from degraded state", "UUID", id)
+		info.SetState(models.StateConnected, nil)
+	}
+}
@@ -0,0 +1,57 @@
+package opc_connector
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/internal/middleware/logging"
+	"testing"
+)
+
+func TestReportReadResult(t *testing.T) {
+	errRead := fmt.Errorf("read: bad node")
+
+	tests := []struct {
+		name  string
+		state models.ConnectionStateEnum
+		err   error
+		want  models.ConnectionStateEnum
+	}{
+		{name: "ошибка чтения живой сессии", state: models.StateConnected, err: errRead, want: models.StateDegraded},
+		{name: "повторная ошибка", state: models.StateDegraded, err: errRead, want: models.StateDegraded},
+		{name: "восстановление чтения", state: models.StateDegraded, want: models.StateConnected},
+		{name: "успешное чтение", state: models.StateConnected, want: models.StateConnected},
+		{name: "переподключение не меняется ошибкой", state: models.StateReconnecting, err: errRead, want: models.StateReconnecting},
+		{name: "переподключение не меняется успехом", state: models.StateReconnecting, want: models.StateReconnecting},
+		{name: "отказ авторизации", state: models.StateAuthFailed, want: models.StateAuthFailed},
+	}
+
+	logger := logging.NewLogger(&logging.Config{Level: "ERROR"}, "", "test")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := uuid.New()
+			info := &models.ConnectionInfo{}
+			info.SetState(tt.state, nil)
+			oc := &OpcConnector{connections: map[uuid.UUID]*models.ConnectionInfo{id: info}, logger: logger}
+
+			oc.ReportReadResult(id, tt.err)
+			if got := info.State(); got != tt.want {
+				t.Fatalf("состояние %s, ожидалось %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportReadResultUnknownConnection(t *testing.T) {
+	id := uuid.New()
+	info := &models.ConnectionInfo{}
+	info.SetState(models.StateConnected, nil)
+	oc := &OpcConnector{connections: map[uuid.UUID]*models.ConnectionInfo{id: info},
+		logger: logging.NewLogger(&logging.Config{Level: "ERROR"}, "", "test")}
+
+	// Результат чтения по закрытому соединению не должен влиять на другие
+	oc.ReportReadResult(uuid.New(), fmt.Errorf("read: bad node"))
+	if got := info.State(); got != models.StateConnected {
+		t.Fatalf("состояние %s, ожидалось %s", got, models.StateConnected)
+	}
+}
@@ -0,0 +1,166 @@
+package usecases
+
+import (
+	"encoding/pem"
+	"fmt"
+	"opc_ua_service/internal/domain/models"
+	connection_models "opc_ua_service/internal/domain/models/connection_models"
+	"opc_ua_service/internal/interfaces"
+	"opc_ua_service/internal/services/opc_service/cert_manager"
+	"opc_ua_service/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+// thumbprintPattern - SHA-1 отпечаток сертификата в hex
+var thumbprintPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+type CertificateUsecase struct {
+	OpcService interfaces.OpcService
+	Repo       interfaces.CncMachineRepository
+	warnDays   int
+}
+
+func NewCertificateUsecase(s interfaces.OpcService, r interfaces.CncMachineRepository, warnDays int) *CertificateUsecase {
+	return &CertificateUsecase{
+		OpcService: s,
+		Repo:       r,
+		warnDays:   warnDays,
+	}
+}
+
+// ListServerCertificates возвращает сертификаты серверов из хранилища.
+// Если раздел не указан, возвращаются доверенные и отклонённые сертификаты.
+func (u *CertificateUsecase) ListServerCertificates(store models.CertificateStoreEnum) (models.ServerCertificateListResponse, *errors.AppError) {
+	resp := models.ServerCertificateListResponse{Certificates: []models.ServerCertificateInfo{}}
+
+	stores := []models.CertificateStoreEnum{models.StoreTrusted, models.StoreRejected}
+	if store != "" {
+		if err := store.Validate(); err != nil {
+			return resp, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+		}
+		stores = []models.CertificateStoreEnum{store}
+	}
+
+	for _, s := range stores {
+		list, err := u.OpcService.ListServerCertificates(s)
+		if err != nil {
+			return resp, errors.NewAppError(errors.InternalServerErrorCode, "failed to read certificate store", err, false)
+		}
+		resp.Certificates = append(resp.Certificates, list...)
+	}
+	return resp, nil
+}
+
+// TrustServerCertificate переносит сертификат сервера из карантина в доверенные
+func (u *CertificateUsecase) TrustServerCertificate(thumbprint string) (*models.ServerCertificateInfo, *errors.AppError) {
+	return u.changeTrust(thumbprint, u.OpcService.TrustServerCertificate, "failed to trust certificate")
+}
+
+// RevokeServerCertificate отзывает доверие к сертификату сервера
+func (u *CertificateUsecase) RevokeServerCertificate(thumbprint string) (*models.ServerCertificateInfo, *errors.AppError) {
+	return u.changeTrust(thumbprint, u.OpcService.RevokeServerCertificate, "failed to revoke certificate")
+}
+
+func (u *CertificateUsecase) changeTrust(thumbprint string, action func(string) (*models.ServerCertificateInfo, error), failMsg string) (*models.ServerCertificateInfo, *errors.AppError) {
+	thumbprint = strings.ToLower(strings.TrimSpace(thumbprint))
+	if !thumbprintPattern.MatchString(thumbprint) {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", fmt.Errorf("invalid SHA-1 thumbprint: %s", thumbprint), true)
+	}
+
+	info, err := action(thumbprint)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, errors.NewAppError(errors.NotFoundErrorCode, "certificate not found", err, true)
+		}
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, failMsg, err, false)
+	}
+	return info, nil
+}
+
+// GetApplicationCertificate возвращает сведения о сертификате приложения сервиса
+func (u *CertificateUsecase) GetApplicationCertificate() (*models.ApplicationCertificateInfo, *errors.AppError) {
+	info, err := u.OpcService.ApplicationCertificateInfo()
+	if err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to read application certificate", err, false)
+	}
+	return info, nil
+}
+
+// DownloadApplicationCertificate возвращает сертификат приложения в формате DER или PEM
+// для добавления в список доверенных на стороне ЧПУ
+func (u *CertificateUsecase) DownloadApplicationCertificate(format string) ([]byte, *errors.AppError) {
+	der, _, err := u.OpcService.ApplicationCertificate()
+	if err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to read application certificate", err, false)
+	}
+
+	switch strings.ToLower(format) {
+	case "", "der":
+		return der, nil
+	case "pem":
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+	default:
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", fmt.Errorf("unsupported certificate format: %s", format), true)
+	}
+}
+
+// RenewApplicationCertificate перевыпускает сертификат приложения сервиса
+func (u *CertificateUsecase) RenewApplicationCertificate() (*models.ApplicationCertificateInfo, *errors.AppError) {
+	info, err := u.OpcService.RenewApplicationCertificate()
+	if err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to renew application certificate", err, false)
+	}
+	return info, nil
+}
+
+// CheckCertificateExpiry возвращает сроки действия клиентских сертификатов всех станков с подключением по сертификату.
+// Для станков, подключённых от имени сервиса, возвращается срок действия сертификата приложения
+func (u *CertificateUsecase) CheckCertificateExpiry() (models.CertificateExpiryResponse, *errors.AppError) {
+	resp := models.CertificateExpiryResponse{WarnDays: u.warnDays, Certificates: []models.CertificateExpiryInfo{}}
+
+	machines, err := u.Repo.GetAllCncMachines()
+	if err != nil {
+		return resp, errors.NewAppError(errors.InternalServerErrorCode, "failed to get machines", err, false)
+	}
+
+	for _, machine := range machines {
+		if machine.ConnectionType != connection_models.ConnectionCertificate {
+			continue
+		}
+		info := models.CertificateExpiryInfo{
+			UUID:         machine.UUID,
+			Manufacturer: machine.Manufacturer,
+			Model:        machine.Model,
+			EndpointURL:  machine.EndpointURL,
+			Source:       "machine",
+		}
+
+		if machine.CertificateConnection == nil || len(machine.CertificateConnection.Certificate) == 0 {
+			info.Source = "application"
+			appCert, err := u.OpcService.ApplicationCertificateInfo()
+			if err != nil {
+				info.Status, info.Error = models.ExpiryInvalid, err.Error()
+			} else {
+				info.Thumbprint, info.Subject, info.NotAfter = appCert.Thumbprint, appCert.Subject, appCert.NotAfter
+				info.DaysToExpiry, info.Status = models.CertificateExpiryStatus(appCert.NotAfter, u.warnDays)
+			}
+			resp.Certificates = append(resp.Certificates, info)
+			continue
+		}
+
+		cert, err := u.OpcService.DecodeCertificate(machine.CertificateConnection.Certificate)
+		if err != nil {
+			info.Status, info.Error = models.ExpiryInvalid, err.Error()
+			resp.Certificates = append(resp.Certificates, info)
+			continue
+		}
+		info.Thumbprint = cert_manager.Thumbprint(cert)
+		info.Subject = cert.Subject.String()
+		info.NotAfter = cert.NotAfter
+		info.DaysToExpiry, info.Status = models.CertificateExpiryStatus(cert.NotAfter, u.warnDays)
+		resp.Certificates = append(resp.Certificates, info)
+	}
+
+	return resp, nil
+}
@@ -16,7 +16,10 @@ func (u *ConnectionUsecase) validateAnonymousRequest(request models.ConnectionRe
 	if strings.TrimSpace(request.EndpointURL) == "" {
 		return fmt.Errorf("endpoint URL is required")
 	}
-	return nil
+	if err := validateTimeout(request); err != nil {
+		return err
+	}
+	return validateSecurity(request)
 }
 
 // ----------------------------------------------------------------------------------------------------------------
@@ -36,10 +39,6 @@ func (u *ConnectionUsecase) ConnectAnonymous(request models.ConnectionRequest) (
 		return empty, errors.NewAppError(errors.InternalServerErrorCode, "endpoint is not reachable", err, false)
 	}
 
-	if err := u.handleExistingMachine(connReq.EndpointURL); err != nil {
-		return empty, err
-	}
-
 	machineUUID, err := u.createNewAnonymousConnection(connReq)
 	if err != nil {
 		return empty, err
@@ -54,30 +53,43 @@ func (u *ConnectionUsecase) createNewAnonymousConnection(connReq *connection_mod
 
 	connID, err := u.OpcService.CreateAnonymousConnection(*connReq)
 	if err != nil {
-		return "", errors.NewAppError(errors.InternalServerErrorCode, "failed to create anonymous connection for machine", err, false)
+		return "", newConnectError("failed to create anonymous connection for machine", err)
+	}
+
+	detection, eerr := u.detectMachineModel(*connID, connReq.Manufacturer, connReq.Model)
+	if eerr != nil {
+		return "", eerr
+	}
+
+	// Прежний станок с тем же endpoint заменяется, только когда новое подключение прошло проверку модели
+	if eerr := u.handleExistingMachine(connReq.EndpointURL); eerr != nil {
+		u.abortConnection(*connID)
+		return "", eerr
 	}
 
 	newAnon := entities.AnonymousConnection{
-		Policy: connReq.EndpointURL,
-		Mode:   connReq.Manufacturer,
+		Policy: connReq.Policy,
+		Mode:   connReq.Mode,
 	}
 	anonID, eerr := u.CreateAnonRecord(newAnon)
 	if eerr != nil {
+		u.abortConnection(*connID)
 		return "", eerr
 	}
 
 	newMachine := entities.CncMachine{
 		UUID:                  connID.String(),
 		EndpointURL:           connReq.EndpointURL,
-		Model:                 connReq.Model,
-		Manufacturer:          connReq.Manufacturer,
 		Status:                connection_models.ConnectionStatusConnected,
 		Interval:              int(connReq.Timeout.Seconds()),
 		ConnectionType:        connection_models.ConnectionAnonymous,
+		TrustOnFirstUse:       connReq.TrustOnFirstUse,
 		AnonymousConnectionID: &anonID,
 	}
+	detection.apply(&newMachine)
 	machineUUID, eerr := u.CreateMachineRecord(newMachine)
 	if eerr != nil {
+		u.abortConnection(*connID)
 		return "", eerr
 	}
 
@@ -89,9 +101,12 @@ func (u *ConnectionUsecase) createNewAnonymousConnection(connReq *connection_mod
 // NewAnonymousConnectionFromRequest Конструктор из ConnectionRequest
 func NewAnonymousConnectionFromRequest(req *models.ConnectionRequest) *connection_models.AnonymousConnection {
 	return &connection_models.AnonymousConnection{
-		EndpointURL:  req.EndpointURL,
-		Timeout:      time.Duration(req.Timeout) * time.Second,
-		Manufacturer: req.Manufacturer,
-		Model:        req.Model,
+		EndpointURL:     req.EndpointURL,
+		Policy:          string(req.Policy),
+		Mode:            string(req.Mode),
+		TrustOnFirstUse: req.TrustOnFirstUse,
+		Timeout:         time.Duration(req.Timeout) * time.Second,
+		Manufacturer:    req.Manufacturer,
+		Model:           req.Model,
 	}
 }
@@ -2,6 +2,7 @@ package connection_usecase
 
 import (
 	"fmt"
+	"github.com/google/uuid"
 	"log"
 	"opc_ua_service/internal/domain/entities"
 	"opc_ua_service/internal/domain/models"
@@ -16,13 +17,19 @@ func (u *ConnectionUsecase) validateCertificateRequest(request models.Connection
 	if strings.TrimSpace(request.EndpointURL) == "" {
 		return fmt.Errorf("endpoint URL is required")
 	}
-	if strings.TrimSpace(request.Certificate) == "" {
+	// Без сертификата и ключа используется сертификат приложения сервиса
+	hasCert := strings.TrimSpace(request.Certificate) != ""
+	hasKey := strings.TrimSpace(request.Key) != ""
+	if hasCert && !hasKey {
+		return fmt.Errorf("private key is required")
+	}
+	if hasKey && !hasCert {
 		return fmt.Errorf("certificate is required")
 	}
-	if strings.TrimSpace(request.Key) == "" {
-		return fmt.Errorf("private key is required")
+	if err := validateTimeout(request); err != nil {
+		return err
 	}
-	return nil
+	return validateSecurity(request)
 }
 
 // ----------------------------------------------------------------------------------------------------------------
@@ -47,19 +54,15 @@ func (u *ConnectionUsecase) ConnectWithCertificate(request models.ConnectionRequ
 	}
 
 	config := connection_models.CertificateConnection{
-		EndpointURL:  connReq.EndpointURL,
-		Certificate:  connReq.Certificate,
-		Key:          connReq.Key,
-		Policy:       connReq.Policy,
-		Mode:         connReq.Mode,
-		Timeout:      time.Duration(request.Timeout) * time.Second,
-		Manufacturer: connReq.Manufacturer,
-		Model:        connReq.Model,
-	}
-
-	// Проверка существующей машины и закрытие старого соединения
-	if err := u.handleExistingMachine(config.EndpointURL); err != nil {
-		return empty, err
+		EndpointURL:     connReq.EndpointURL,
+		Certificate:     connReq.Certificate,
+		Key:             connReq.Key,
+		Policy:          connReq.Policy,
+		Mode:            connReq.Mode,
+		TrustOnFirstUse: connReq.TrustOnFirstUse,
+		Timeout:         time.Duration(request.Timeout) * time.Second,
+		Manufacturer:    connReq.Manufacturer,
+		Model:           connReq.Model,
 	}
 
 	// Создание нового соединения и запись в БД
@@ -77,7 +80,17 @@ func (u *ConnectionUsecase) createNewCertificateConnection(config connection_mod
 
 	connID, err := u.OpcService.CreateCertificateConnection(config)
 	if err != nil {
-		return "", errors.NewAppError(errors.InternalServerErrorCode, "failed to create connection for machine", err, false)
+		return "", newConnectError("failed to create connection for machine", err)
+	}
+
+	detection, eerr := u.detectMachineModel(*connID, config.Manufacturer, config.Model)
+	if eerr != nil {
+		return "", eerr
+	}
+
+	if eerr := u.handleExistingMachine(config.EndpointURL); eerr != nil {
+		u.abortConnection(*connID)
+		return "", eerr
 	}
 
 	newCert := entities.CertificateConnection{
@@ -88,22 +101,24 @@ func (u *ConnectionUsecase) createNewCertificateConnection(config connection_mod
 	}
 	certID, eerr := u.CreateCertRecord(newCert)
 	if eerr != nil {
+		u.abortConnection(*connID)
 		return "", eerr
 	}
 
 	newMachine := entities.CncMachine{
 		UUID:                    connID.String(),
 		EndpointURL:             config.EndpointURL,
-		Model:                   config.Model,
-		Manufacturer:            config.Manufacturer,
 		Status:                  connection_models.ConnectionStatusConnected,
 		Interval:                int(config.Timeout.Seconds()),
 		ConnectionType:          "certificate",
+		TrustOnFirstUse:         config.TrustOnFirstUse,
 		CertificateConnectionID: &certID,
 	}
 
+	detection.apply(&newMachine)
 	machineUUID, eerr := u.CreateMachineRecord(newMachine)
 	if eerr != nil {
+		u.abortConnection(*connID)
 		return "", eerr
 	}
 
@@ -116,6 +131,21 @@ func (u *ConnectionUsecase) createNewCertificateConnection(config connection_mod
 func (u *ConnectionUsecase) NewCertificateConnectionFromRequest(req *models.ConnectionRequest) (connection_models.CertificateConnection, error) {
 	var empty connection_models.CertificateConnection
 
+	// Сертификат не передан - подключение от имени сервиса с текущим сертификатом приложения
+	if strings.TrimSpace(req.Certificate) == "" && strings.TrimSpace(req.Key) == "" {
+		return connection_models.CertificateConnection{
+			EndpointURL:     req.EndpointURL,
+			Certificate:     []byte{},
+			Key:             []byte{},
+			Policy:          string(req.Policy),
+			Mode:            string(req.Mode),
+			TrustOnFirstUse: req.TrustOnFirstUse,
+			Timeout:         time.Duration(req.Timeout) * time.Second,
+			Manufacturer:    req.Manufacturer,
+			Model:           req.Model,
+		}, nil
+	}
+
 	// Попытка распарсить сертификат (Base64 -> []byte)
 	parsedCert, err := u.OpcService.Base64ToBytes(req.Certificate)
 	if err != nil {
@@ -133,16 +163,103 @@ func (u *ConnectionUsecase) NewCertificateConnectionFromRequest(req *models.Conn
 	if cert == nil || key == nil {
 		return empty, errors.NewAppError(errors.InternalServerErrorCode, "invalid certificate or key content", nil, false)
 	}
+	if err := validateClientKey(key); err != nil {
+		return empty, err
+	}
 
 	// Успешное создание подключения
 	return connection_models.CertificateConnection{
-		EndpointURL:  req.EndpointURL,
-		Certificate:  parsedCert,
-		Key:          parsedKey,
-		Policy:       string(req.Policy),
-		Mode:         string(req.Mode),
-		Timeout:      time.Duration(req.Timeout) * time.Second,
-		Manufacturer: req.Manufacturer,
-		Model:        req.Model,
+		EndpointURL:     req.EndpointURL,
+		Certificate:     parsedCert,
+		Key:             parsedKey,
+		Policy:          string(req.Policy),
+		Mode:            string(req.Mode),
+		TrustOnFirstUse: req.TrustOnFirstUse,
+		Timeout:         time.Duration(req.Timeout) * time.Second,
+		Manufacturer:    req.Manufacturer,
+		Model:           req.Model,
+	}, nil
+}
+
+// ----------------------------------------------------------------------------------------------------------------
+
+// RotateCertificate заменяет клиентский сертификат станка и переустанавливает сессию с новыми данными.
+// UUID и состояние опроса станка сохраняются, запись в БД обновляется только после успешного подключения
+func (u *ConnectionUsecase) RotateCertificate(request models.RotateCertificateRequest) (*models.CertificateExpiryInfo, *errors.AppError) {
+	id, err := uuid.Parse(request.UUID)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "invalid machine UUID", err, true)
+	}
+
+	machine, err := u.MachineRepo.GetCncMachineByUUID(id.String())
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, errors.NewAppError(errors.NotFoundErrorCode, "machine not found", err, false)
+		}
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to get machine", err, false)
+	}
+	if machine.ConnectionType != connection_models.ConnectionCertificate || machine.CertificateConnectionID == nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed",
+			fmt.Errorf("machine %s does not use certificate authentication", id), true)
+	}
+
+	certBytes, err := u.OpcService.Base64ToBytes(request.Certificate)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "invalid certificate encoding", err, true)
+	}
+	keyBytes, err := u.OpcService.Base64ToBytes(request.Key)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "invalid key encoding", err, true)
+	}
+	_, cert, key := u.OpcService.DecodeClientCredentials(certBytes, keyBytes)
+	if cert == nil || key == nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "invalid certificate or key content", nil, true)
+	}
+	if err := validateClientKey(key); err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+	}
+	if err := u.OpcService.VerifyKeyMatchesCert(cert, key); err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+	}
+	if time.Now().After(cert.NotAfter) {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed",
+			fmt.Errorf("certificate expired at %s", cert.NotAfter.Format(time.RFC3339)), true)
+	}
+
+	cfg, err := connectionConfigFromMachine(machine)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "invalid machine record", err, false)
+	}
+	certCfg := cfg.(*connection_models.CertificateConnection)
+	certCfg.Certificate = certBytes
+	certCfg.Key = keyBytes
+
+	if err := u.OpcService.UpdateConnectionConfig(id, certCfg); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, errors.NewAppError(errors.NotFoundErrorCode, "connection not found", err, false)
+		}
+		return nil, newConnectError("failed to reconnect with new certificate", err)
+	}
+
+	updateMap := map[string]interface{}{
+		"certificate": certBytes,
+		"key":         keyBytes,
+	}
+	if _, err := u.CertRepo.UpdateCertificateConnection(*machine.CertificateConnectionID, updateMap); err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to update certificate record", err, false)
+	}
+
+	log.Printf("✅ Certificate rotated for machine %s, valid until %s", id, cert.NotAfter.Format(time.RFC3339))
+	days, status := models.CertificateExpiryStatus(cert.NotAfter, u.CertWarnDays)
+	return &models.CertificateExpiryInfo{
+		UUID:         machine.UUID,
+		Manufacturer: machine.Manufacturer,
+		Model:        machine.Model,
+		EndpointURL:  machine.EndpointURL,
+		Source:       "machine",
+		Subject:      cert.Subject.String(),
+		NotAfter:     cert.NotAfter,
+		DaysToExpiry: days,
+		Status:       status,
 	}, nil
 }
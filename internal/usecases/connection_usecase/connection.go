@@ -1,6 +1,8 @@
 package connection_usecase
 
 import (
+	"crypto"
+	"crypto/rsa"
 	"fmt"
 	"github.com/google/uuid"
 	"log"
@@ -18,16 +20,20 @@ type ConnectionUsecase struct {
 	CertRepo     interfaces.CertificateConnectionRepository
 	PasswordRepo interfaces.PasswordConnectionRepository
 	AnonRepo     interfaces.AnonymousConnectionRepository
+
+	// CertWarnDays - за сколько дней до истечения сертификат считается истекающим
+	CertWarnDays int
 }
 
 func NewConnectionUsecase(s interfaces.OpcService, r interfaces.CncMachineRepository, cr interfaces.CertificateConnectionRepository, pr interfaces.PasswordConnectionRepository, ar interfaces.AnonymousConnectionRepository) *ConnectionUsecase {
-	return &ConnectionUsecase{s, r, cr, pr, ar}
+	return &ConnectionUsecase{OpcService: s, MachineRepo: r, CertRepo: cr, PasswordRepo: pr, AnonRepo: ar}
 }
 
 // DisconnectByUUID закрывает соединение по UUID
 func (u *ConnectionUsecase) DisconnectByUUID(id uuid.UUID) (*bool, *errors.AppError) {
 	var state = false
-	info, err := u.OpcService.GetConnectionInfoByUUID(id)
+	// Закрыть можно и соединение, которое сейчас переподключается
+	info, err := u.OpcService.FindConnectionInfo(id)
 	if err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			return nil, errors.NewAppError(errors.NotFoundErrorCode, "failed to find connection", err, false)
@@ -35,7 +41,10 @@ func (u *ConnectionUsecase) DisconnectByUUID(id uuid.UUID) (*bool, *errors.AppEr
 			return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to get connection", err, false)
 		}
 	}
-	if info.IsPolled {
+	info.Mu.RLock()
+	polled := info.IsPolled
+	info.Mu.RUnlock()
+	if polled {
 		if err := u.OpcService.StopPollingForMachine(id); err != nil {
 			log.Printf("Warning: failed to stop polling for machine %s: %v", id, err)
 		}
@@ -133,9 +142,9 @@ func (u *ConnectionUsecase) GetActiveConnections() models.ConnectionPoolResponse
 	}
 }
 
-// GetConnectionState получает состояние подключения
+// GetConnectionState получает состояние подключения, в том числе потерянного или отклонённого сервером
 func (u *ConnectionUsecase) GetConnectionState(id uuid.UUID) (*models.ConnectionInfoResponse, *errors.AppError) {
-	connInfo, err := u.OpcService.GetConnectionInfoByUUID(id)
+	connInfo, err := u.OpcService.FindConnectionInfo(id)
 	if err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			return nil, errors.NewAppError(errors.NotFoundErrorCode, "failed to find connection", errors.ErrNotFound, false)
@@ -163,19 +172,16 @@ func (u *ConnectionUsecase) сonvertConnectionInfoToResponse(id uuid.UUID, connI
 	connInfo.Mu.RLock()
 	defer connInfo.Mu.RUnlock()
 
-	// Определяем статус на основе IsHealthy
-	var status models.ConnectionStatusEnum
-	if connInfo.IsHealthy {
-		status = models.StatusHealthy
-	} else {
-		status = models.StatusUnhealthy
-	}
+	// Статус выводится из состояния жизненного цикла и последней ошибки
+	state := connInfo.StateSnapshot()
+	status := state.Status()
 
 	return models.ConnectionInfoResponse{
 		UUID:        id,
 		SessionID:   connInfo.SessionID,
 		Status:      status,
 		Description: status.GetDescription(),
+		State:       state,
 		Config:      connInfo.Config,
 		CreatedAt:   connInfo.CreatedAt,
 		LastUsed:    connInfo.LastUsed,
@@ -183,6 +189,64 @@ func (u *ConnectionUsecase) сonvertConnectionInfoToResponse(id uuid.UUID, connI
 	}
 }
 
+// validateTimeout проверяет интервал опроса станка. Не заданный timeout заменяется интервалом по умолчанию
+func validateTimeout(request models.ConnectionRequest) error {
+	if request.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative, got %d", request.Timeout)
+	}
+	return nil
+}
+
+// validateSecurity проверяет запрошенные политику безопасности и режим сообщений.
+// Пустые значения допустимы: в этом случае выбирается самый защищённый endpoint сервера.
+func validateSecurity(request models.ConnectionRequest) error {
+	if request.Policy != "" {
+		if err := request.Policy.Validate(); err != nil {
+			return err
+		}
+	}
+	if request.Mode != "" {
+		if err := request.Mode.Validate(); err != nil {
+			return err
+		}
+	}
+	// Используемый OPC UA стек не реализует ECC политики: отклоняем запрос сразу, а не при подключении
+	if request.Policy.IsECC() {
+		return fmt.Errorf("%w: policy %s", errors.ErrECCNotSupported, request.Policy)
+	}
+	if request.Policy == models.PolicyNone && request.Mode != "" && request.Mode != models.ModeNone {
+		return fmt.Errorf("security policy None requires mode None, got %s", request.Mode)
+	}
+	if request.Mode == models.ModeNone && request.Policy != "" && request.Policy != models.PolicyNone {
+		return fmt.Errorf("mode None requires security policy None, got %s", request.Policy)
+	}
+	return nil
+}
+
+// validateClientKey проверяет, что клиентским ключом можно подписать канал: стек принимает только RSA ключи
+func validateClientKey(key crypto.Signer) error {
+	if _, ok := key.(*rsa.PrivateKey); !ok {
+		return fmt.Errorf("%w: client key %T, only RSA keys are supported", errors.ErrECCNotSupported, key)
+	}
+	return nil
+}
+
+// newConnectError формирует ошибку создания соединения.
+// Отсутствие запрошенной политики/режима на сервере или неподдерживаемая ECC политика считаются ошибкой запроса,
+// отклонённые сервером учётные данные - ошибкой авторизации.
+func newConnectError(message string, err error) *errors.AppError {
+	if errors.Is(err, errors.ErrSecurityNotOffered) || errors.Is(err, errors.ErrECCNotSupported) {
+		return errors.NewAppError(errors.BadRequestErrorCode, message, err, true)
+	}
+	if errors.Is(err, errors.ErrServerCertificateRejected) {
+		return errors.NewAppError(errors.ForbiddenErrorCode, message, err, true)
+	}
+	if errors.Is(err, errors.ErrIdentityRejected) {
+		return errors.NewAppError(errors.UnauthorizedErrorCode, message, err, true)
+	}
+	return errors.NewAppError(errors.InternalServerErrorCode, message, err, false)
+}
+
 // isEndpointReachable проверяет, доступен ли TCP порт указанного endpoint за заданный таймаут.
 func isEndpointReachable(endpoint string, timeout time.Duration) error {
 	u, err := url.Parse(endpoint)
@@ -190,7 +254,7 @@ func isEndpointReachable(endpoint string, timeout time.Duration) error {
 		return fmt.Errorf("invalid endpoint URL: %w", err)
 	}
 
-	hostPort := fmt.Sprintf("%s:%s", u.Hostname(), u.Port())
+	hostPort := net.JoinHostPort(u.Hostname(), u.Port())
 	conn, err := net.DialTimeout("tcp", hostPort, timeout)
 	if err != nil {
 		return fmt.Errorf("endpoint %s is not reachable: %w", hostPort, err)
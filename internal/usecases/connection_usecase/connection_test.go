@@ -0,0 +1,64 @@
+package connection_usecase
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/errors"
+	"testing"
+)
+
+func TestValidateSecurity(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  models.SecurityPolicyEnum
+		mode    models.MessageSecurityModeEnum
+		wantErr bool
+		wantIs  error
+	}{
+		{name: "не заданы", wantErr: false},
+		{name: "политика и режим", policy: models.PolicyBasic256Sha256, mode: models.ModeSignAndEncrypt},
+		{name: "только политика", policy: models.PolicyBasic256},
+		{name: "современная RSA политика", policy: models.PolicyAes256Sha256RsaPss, mode: models.ModeSign},
+		{name: "None и None", policy: models.PolicyNone, mode: models.ModeNone},
+		{name: "неизвестная политика", policy: "Basic512", wantErr: true},
+		{name: "неизвестный режим", mode: "Encrypt", wantErr: true},
+		{name: "ECC nistP256", policy: models.PolicyEccNistP256, wantErr: true, wantIs: errors.ErrECCNotSupported},
+		{name: "ECC nistP384 с режимом", policy: models.PolicyEccNistP384, mode: models.ModeSignAndEncrypt,
+			wantErr: true, wantIs: errors.ErrECCNotSupported},
+		{name: "None с шифрованием", policy: models.PolicyNone, mode: models.ModeSign, wantErr: true},
+		{name: "режим None с политикой", policy: models.PolicyBasic256, mode: models.ModeNone, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSecurity(models.ConnectionRequest{Policy: tt.policy, Mode: tt.mode})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateSecurity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Fatalf("ожидалась ошибка %v, получено %v", tt.wantIs, err)
+			}
+		})
+	}
+}
+
+func TestValidateClientKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateClientKey(rsaKey); err != nil {
+		t.Fatalf("RSA ключ отклонён: %v", err)
+	}
+	if err := validateClientKey(ecKey); !errors.Is(err, errors.ErrECCNotSupported) {
+		t.Fatalf("ECC ключ: ожидалась ошибка %v, получено %v", errors.ErrECCNotSupported, err)
+	}
+}
@@ -0,0 +1,140 @@
+package connection_usecase
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"opc_ua_service/internal/domain/entities"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/errors"
+	"opc_ua_service/pkg/machine_models"
+	"strings"
+	"time"
+)
+
+// machineDetection - производитель и модель станка, выбранные при подключении, и сведения сервера, по которым
+// они определены
+type machineDetection struct {
+	Manufacturer string
+	Model        string
+
+	identity *models.ServerIdentity  // nil - сведения сервера не прочитаны
+	detected *models.DetectedMachine // nil - модель по сведениям сервера не определена
+}
+
+// apply записывает выбранную и определённую модели в запись станка
+func (d machineDetection) apply(machine *entities.CncMachine) {
+	machine.Manufacturer, machine.Model = d.Manufacturer, d.Model
+	if d.identity == nil {
+		return
+	}
+	now := time.Now()
+	machine.ServerProductName = d.identity.ProductName
+	machine.ServerSoftwareVersion = d.identity.SoftwareVersion
+	machine.DetectedAt = &now
+	if d.detected != nil {
+		machine.DetectedManufacturer = d.detected.Manufacturer
+		machine.DetectedModel = d.detected.Model
+		machine.DetectionSource = string(d.detected.Source)
+	}
+}
+
+// detectMachineModel определяет модель станка по сведениям сервера и сверяет её с заявленной в запросе.
+// Незаполненные производитель и модель берутся из определённой модели, написание заявленных приводится к ней.
+// Регистрация отклоняется, только если заявленная модель противоречит определённой; соединение при этом закрывается
+func (u *ConnectionUsecase) detectMachineModel(id uuid.UUID, manufacturer, model string) (machineDetection, *errors.AppError) {
+	manufacturer, model = strings.TrimSpace(manufacturer), strings.TrimSpace(model)
+	result := machineDetection{Manufacturer: manufacturer, Model: model}
+	declared := manufacturer != "" && model != ""
+
+	identity, err := u.OpcService.IdentifyServer(id)
+	if err != nil {
+		if declared {
+			log.Printf("Warning: failed to identify server of machine %s, using declared model: %v", id, err)
+			return result, nil
+		}
+		return u.rejectConnection(id, errors.NewAppError(errors.InternalServerErrorCode, "failed to detect machine model", err, false))
+	}
+	result.identity = identity
+
+	candidates := machine_models.DetectMachineModels(*identity)
+	detected, ok := matchDetectedModel(candidates, manufacturer, model)
+	switch {
+	case ok:
+		result.detected = &detected
+	case len(candidates) == 0:
+		if !declared {
+			return u.rejectConnection(id, errors.NewAppError(errors.InvalidDataCode, "validation failed",
+				fmt.Errorf("machine model of server %q (%s) is not detected, specify manufacturer and model",
+					identity.ProductName, identity.ManufacturerName), true))
+		}
+		// Модель не определена: остаётся заявленная, профиль для неё может быть загружен позже
+		return result, nil
+	case isSpecificDetection(candidates):
+		return u.rejectConnection(id, errors.NewAppError(errors.ConflictErrorCode, "declared machine model conflicts with the server",
+			fmt.Errorf("declared %s %s, server is %s %s", manufacturer, model, candidates[0].Manufacturer, candidates[0].Model), true))
+	default:
+		// Сервер публикует только стандартную модель OPC 40501: заявленная модель производителя ей не противоречит
+		detected = candidates[0]
+		result.detected = &detected
+		if declared {
+			return result, nil
+		}
+	}
+
+	if manufacturer == "" || machine_models.SameName(manufacturer, detected.Manufacturer) {
+		result.Manufacturer = detected.Manufacturer
+	}
+	if model == "" || machine_models.SameName(model, detected.Model) {
+		result.Model = detected.Model
+	}
+	if result.Manufacturer != manufacturer || result.Model != model {
+		if err := u.OpcService.SetMachineModel(id, result.Manufacturer, result.Model); err != nil {
+			return u.rejectConnection(id, errors.NewAppError(errors.InternalServerErrorCode, "failed to set machine model", err, false))
+		}
+	}
+	log.Printf("Machine %s detected as %s %s (%s)", id, detected.Manufacturer, detected.Model, detected.Source)
+	return result, nil
+}
+
+// matchDetectedModel возвращает определённую модель, которая совпадает с заявленной. Пустые производитель или
+// модель совпадают с любыми, производитель стандартной модели OPC 40501 не сравнивается. Прежние названия
+// производителей (ACME) сравниваются как производитель встроенных моделей
+func matchDetectedModel(candidates []models.DetectedMachine, manufacturer, model string) (models.DetectedMachine, bool) {
+	manufacturer = machine_models.CanonicalManufacturer(manufacturer)
+	for _, candidate := range candidates {
+		if model != "" && !machine_models.SameName(model, candidate.Model) {
+			continue
+		}
+		if manufacturer != "" && candidate.Model != machine_models.MachineToolModel &&
+			!machine_models.SameName(manufacturer, candidate.Manufacturer) {
+			continue
+		}
+		return candidate, true
+	}
+	return models.DetectedMachine{}, false
+}
+
+// isSpecificDetection сообщает, что сервер опознан как конкретная модель производителя: по профилю или
+// пространству имён ЧПУ, а не только по стандартной модели OPC 40501
+func isSpecificDetection(candidates []models.DetectedMachine) bool {
+	for _, candidate := range candidates {
+		if candidate.Source != models.DetectionSourceMachineTool {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectConnection закрывает соединение, регистрация которого отклонена
+func (u *ConnectionUsecase) rejectConnection(id uuid.UUID, eerr *errors.AppError) (machineDetection, *errors.AppError) {
+	u.abortConnection(id)
+	return machineDetection{}, eerr
+}
+
+// abortConnection убирает из пула новое соединение, регистрация которого не завершена
+func (u *ConnectionUsecase) abortConnection(id uuid.UUID) {
+	if err := u.OpcService.CloseConnection(id); err != nil {
+		log.Printf("Warning: failed to close unregistered connection %s: %v", id, err)
+	}
+}
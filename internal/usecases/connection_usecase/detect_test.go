@@ -0,0 +1,118 @@
+package connection_usecase
+
+import (
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/machine_models"
+	"testing"
+)
+
+func TestMatchDetectedModel(t *testing.T) {
+	sinumerik := models.DetectedMachine{Manufacturer: "Siemens", Model: "840D sl", Source: models.DetectionSourceNamespace}
+	machineTool := models.DetectedMachine{Manufacturer: "Siemens AG", Model: machine_models.MachineToolModel, Source: models.DetectionSourceMachineTool}
+	candidates := []models.DetectedMachine{sinumerik, machineTool}
+
+	tests := []struct {
+		name         string
+		manufacturer string
+		model        string
+		want         models.DetectedMachine
+		wantOK       bool
+	}{
+		{name: "не заявлены", want: sinumerik, wantOK: true},
+		{name: "другое написание", manufacturer: "SIEMENS", model: "840Dsl", want: sinumerik, wantOK: true},
+		{name: "только производитель", manufacturer: "Siemens", want: sinumerik, wantOK: true},
+		{name: "только модель", model: "840D sl", want: sinumerik, wantOK: true},
+		{name: "стандартная модель без сравнения производителя", manufacturer: "DMG MORI", model: machine_models.MachineToolModel,
+			want: machineTool, wantOK: true},
+		{name: "другая модель", manufacturer: "Siemens", model: "828D"},
+		{name: "другой производитель", manufacturer: "Heidenhain", model: "840D sl"},
+		{name: "прежнее название производителя не совпадает с другим", manufacturer: "ACME", model: "840D sl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchDetectedModel(candidates, tt.manufacturer, tt.model)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("matchDetectedModel(%q, %q) = %+v, %v; want %+v, %v", tt.manufacturer, tt.model, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsSpecificDetection(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []models.DetectedMachine
+		want       bool
+	}{
+		{name: "ничего не определено", want: false},
+		{name: "только OPC 40501", candidates: []models.DetectedMachine{
+			{Manufacturer: "Grob", Model: machine_models.MachineToolModel, Source: models.DetectionSourceMachineTool}}, want: false},
+		{name: "пространство имён ЧПУ", candidates: []models.DetectedMachine{
+			{Manufacturer: "Heidenhain", Model: "TNC640", Source: models.DetectionSourceNamespace}}, want: true},
+		{name: "профиль и OPC 40501", candidates: []models.DetectedMachine{
+			{Manufacturer: "Grob", Model: machine_models.MachineToolModel, Source: models.DetectionSourceMachineTool},
+			{Manufacturer: "Fanuc", Model: "30i-B", Source: models.DetectionSourceProfile}}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSpecificDetection(tt.candidates); got != tt.want {
+				t.Fatalf("isSpecificDetection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDeclaredModelAgainstServer сверяет заявленную модель с моделями, определёнными по сведениям сервера,
+// так же, как detectMachineModel: совпадение, допустимое расхождение или конфликт с кодом 409
+func TestDeclaredModelAgainstServer(t *testing.T) {
+	sinumerikNS := []string{machine_models.SinumerikNamespaceURI}
+	heidenhainNS := []string{machine_models.HeidenhainNamespaceURI}
+
+	tests := []struct {
+		name         string
+		identity     models.ServerIdentity
+		manufacturer string
+		model        string
+		wantModel    string // модель, с которой сопоставлена заявленная; пусто - не сопоставлена
+		wantConflict bool
+	}{
+		{name: "828D без ProductName", identity: models.ServerIdentity{Namespaces: sinumerikNS},
+			manufacturer: "Siemens", model: "828D", wantModel: "828D"},
+		{name: "840D sl без ProductName", identity: models.ServerIdentity{Namespaces: sinumerikNS},
+			manufacturer: "Siemens", model: "840D sl", wantModel: "840D sl"},
+		{name: "TNC620 без номера в ProductName", identity: models.ServerIdentity{ProductName: "HEIDENHAIN NC", Namespaces: heidenhainNS},
+			manufacturer: "Heidenhain", model: "TNC620", wantModel: "TNC620"},
+		{name: "прежняя регистрация ACME", identity: models.ServerIdentity{ProductName: "TNC 640", Namespaces: heidenhainNS},
+			manufacturer: "ACME", model: "TNC640", wantModel: "TNC640"},
+		{name: "ACME без номера в ProductName", identity: models.ServerIdentity{Namespaces: heidenhainNS},
+			manufacturer: "ACME", model: "TNC620", wantModel: "TNC620"},
+		{name: "ProductName называет другую модель", identity: models.ServerIdentity{ProductName: "SINUMERIK 828D", Namespaces: sinumerikNS},
+			manufacturer: "Siemens", model: "840D sl", wantConflict: true},
+		{name: "другой производитель", identity: models.ServerIdentity{Namespaces: sinumerikNS},
+			manufacturer: "Heidenhain", model: "TNC640", wantConflict: true},
+		{name: "ACME на сервере SINUMERIK", identity: models.ServerIdentity{Namespaces: sinumerikNS},
+			manufacturer: "ACME", model: "TNC640", wantConflict: true},
+		{name: "неизвестная модель производителя", identity: models.ServerIdentity{Namespaces: heidenhainNS},
+			manufacturer: "Heidenhain", model: "iTNC530", wantConflict: true},
+		{name: "только OPC 40501", identity: models.ServerIdentity{ManufacturerName: "Grob",
+			Namespaces: []string{machine_models.MachineToolNamespaceURI}},
+			manufacturer: "Grob", model: "G350"},
+		{name: "сервер не опознан", identity: models.ServerIdentity{ProductName: "Generic OPC UA Server"},
+			manufacturer: "Fanuc", model: "30i-B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := machine_models.DetectMachineModels(tt.identity)
+			detected, ok := matchDetectedModel(candidates, tt.manufacturer, tt.model)
+			if ok != (tt.wantModel != "") || detected.Model != tt.wantModel {
+				t.Fatalf("сопоставлено %q (%v), ожидалось %q; кандидаты %+v", detected.Model, ok, tt.wantModel, candidates)
+			}
+			if conflict := !ok && isSpecificDetection(candidates); conflict != tt.wantConflict {
+				t.Fatalf("конфликт %v, ожидался %v; кандидаты %+v", conflict, tt.wantConflict, candidates)
+			}
+		})
+	}
+}
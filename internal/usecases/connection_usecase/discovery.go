@@ -0,0 +1,36 @@
+package connection_usecase
+
+import (
+	"context"
+	"fmt"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/errors"
+	"strings"
+	"time"
+)
+
+// discoveryTimeout ограничивает время запроса GetEndpoints
+const discoveryTimeout = 10 * time.Second
+
+// DiscoverEndpoints возвращает endpoint'ы OPC UA сервера: политики, режимы, типы токенов и сертификат сервера
+func (u *ConnectionUsecase) DiscoverEndpoints(request models.EndpointsRequest) (models.EndpointsResponse, *errors.AppError) {
+	var empty models.EndpointsResponse
+
+	if strings.TrimSpace(request.EndpointURL) == "" {
+		return empty, errors.NewAppError(errors.InvalidDataCode, "validation failed", fmt.Errorf("endpoint URL is required"), true)
+	}
+
+	if err := isEndpointReachable(request.EndpointURL, 5*time.Second); err != nil {
+		return empty, errors.NewAppError(errors.InternalServerErrorCode, "endpoint is not reachable", err, false)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+
+	endpoints, err := u.OpcService.DescribeEndpoints(ctx, request.EndpointURL)
+	if err != nil {
+		return empty, errors.NewAppError(errors.InternalServerErrorCode, "failed to get endpoints", err, true)
+	}
+
+	return models.EndpointsResponse{Endpoints: endpoints}, nil
+}
@@ -19,7 +19,10 @@ func (u *ConnectionUsecase) validatePasswordRequest(request models.ConnectionReq
 	if strings.TrimSpace(request.Password) == "" {
 		return fmt.Errorf("password is required")
 	}
-	return nil
+	if err := validateTimeout(request); err != nil {
+		return err
+	}
+	return validateSecurity(request)
 }
 
 // ----------------------------------------------------------------------------------------------------------------
@@ -40,10 +43,6 @@ func (u *ConnectionUsecase) ConnectWithPassword(request models.ConnectionRequest
 		return empty, errors.NewAppError(errors.InternalServerErrorCode, "endpoint is not reachable", err, false)
 	}
 
-	if err := u.handleExistingMachine(connReq.EndpointURL); err != nil {
-		return empty, err
-	}
-
 	machineUUID, err := u.createNewPasswordConnection(connReq)
 	if err != nil {
 		return empty, err
@@ -57,7 +56,17 @@ func (u *ConnectionUsecase) ConnectWithPassword(request models.ConnectionRequest
 func (u *ConnectionUsecase) createNewPasswordConnection(connReq *connection_models.PasswordConnection) (string, *errors.AppError) {
 	connID, err := u.OpcService.CreatePasswordConnection(*connReq)
 	if err != nil {
-		return "", errors.NewAppError(errors.InternalServerErrorCode, "failed to create password connection for machine", err, false)
+		return "", newConnectError("failed to create password connection for machine", err)
+	}
+
+	detection, eerr := u.detectMachineModel(*connID, connReq.Manufacturer, connReq.Model)
+	if eerr != nil {
+		return "", eerr
+	}
+
+	if eerr := u.handleExistingMachine(connReq.EndpointURL); eerr != nil {
+		u.abortConnection(*connID)
+		return "", eerr
 	}
 
 	newPass := entities.PasswordConnection{
@@ -65,24 +74,28 @@ func (u *ConnectionUsecase) createNewPasswordConnection(connReq *connection_mode
 		Password: connReq.Password,
 		Policy:   connReq.Policy,
 		Mode:     connReq.Mode,
+
+		AllowInsecure: connReq.AllowInsecurePassword,
 	}
 	passID, eerr := u.CreatePasswordRecord(newPass)
 	if eerr != nil {
+		u.abortConnection(*connID)
 		return "", eerr
 	}
 
 	newMachine := entities.CncMachine{
 		UUID:                 connID.String(),
 		EndpointURL:          connReq.EndpointURL,
-		Model:                connReq.Model,
-		Manufacturer:         connReq.Manufacturer,
 		Status:               connection_models.ConnectionStatusConnected,
 		Interval:             int(connReq.Timeout.Seconds()),
 		ConnectionType:       connection_models.ConnectionPassword,
+		TrustOnFirstUse:      connReq.TrustOnFirstUse,
 		PasswordConnectionID: &passID,
 	}
+	detection.apply(&newMachine)
 	machineUUID, eerr := u.CreateMachineRecord(newMachine)
 	if eerr != nil {
+		u.abortConnection(*connID)
 		return "", eerr
 	}
 
@@ -94,13 +107,15 @@ func (u *ConnectionUsecase) createNewPasswordConnection(connReq *connection_mode
 // NewPasswordConnectionFromRequest Конструктор из ConnectionRequest
 func NewPasswordConnectionFromRequest(req *models.ConnectionRequest) *connection_models.PasswordConnection {
 	return &connection_models.PasswordConnection{
-		EndpointURL:  req.EndpointURL,
-		Username:     req.Username,
-		Password:     req.Password,
-		Policy:       string(req.Policy),
-		Mode:         string(req.Mode),
-		Timeout:      time.Duration(req.Timeout) * time.Second,
-		Manufacturer: req.Manufacturer,
-		Model:        req.Model,
+		EndpointURL:           req.EndpointURL,
+		Username:              req.Username,
+		Password:              req.Password,
+		Policy:                string(req.Policy),
+		Mode:                  string(req.Mode),
+		TrustOnFirstUse:       req.TrustOnFirstUse,
+		AllowInsecurePassword: req.AllowInsecurePassword,
+		Timeout:               time.Duration(req.Timeout) * time.Second,
+		Manufacturer:          req.Manufacturer,
+		Model:                 req.Model,
 	}
 }
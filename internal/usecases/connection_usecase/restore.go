@@ -1,6 +1,7 @@
 package connection_usecase
 
 import (
+	"fmt"
 	"github.com/google/uuid"
 	"opc_ua_service/internal/domain/entities"
 	"opc_ua_service/internal/domain/models"
@@ -10,77 +11,98 @@ import (
 )
 
 // RestoreConnection восстанавливает подключение из БД в пул памяти.
+// Соединение регистрируется под UUID из записи CncMachine, поэтому идентификатор станка не меняется между перезапусками.
 func (u *ConnectionUsecase) RestoreConnection(machine entities.CncMachine) (*models.ConnectionInfo, *errors.AppError) {
-	var connID *uuid.UUID
-	var err error
+	id, err := uuid.Parse(machine.UUID)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "invalid machine UUID", err, true)
+	}
+
+	config, err := connectionConfigFromMachine(machine)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "invalid connection type", err, true)
+	}
+
+	// Соединение попадает в пул даже при недоступном сервере и переподключается в фоне
+	connInfo, err := u.OpcService.RestoreConnection(id, config)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to restore connection", err, true)
+	}
+
+	// Запускаем опрос или подписку, если машина была в состоянии "polled"
+	if machine.Status == connection_models.ConnectionStatusPolled {
+		if machine.AcquisitionMode == connection_models.AcquisitionSubscription {
+			settings := connection_models.SubscriptionSettings{}
+			if machine.SubscriptionSettings != nil {
+				settings = *machine.SubscriptionSettings
+			}
+			err = u.OpcService.StartSubscriptionForMachine(id, settings)
+		} else {
+			err = u.OpcService.StartPollingForMachine(id)
+		}
+		if err != nil {
+			return connInfo, errors.NewAppError(errors.InternalServerErrorCode, "failed to start polling for machine", err, true)
+		}
+	}
+
+	return connInfo, nil
+}
+
+// connectionConfigFromMachine собирает конфигурацию подключения из записи станка и связанной записи аутентификации
+func connectionConfigFromMachine(machine entities.CncMachine) (connection_models.ConnectionConfigImpl, error) {
+	timeout := time.Duration(machine.Interval) * time.Second
+	tofu := machine.TrustOnFirstUse
 
 	switch machine.ConnectionType {
 	case connection_models.ConnectionCertificate:
-		req := connection_models.CertificateConnection{
-			EndpointURL:  machine.EndpointURL,
-			Model:        machine.Model,
-			Manufacturer: machine.Manufacturer,
-			Timeout:      time.Duration(machine.Interval) * time.Second,
-			Policy:       machine.CertificateConnection.Policy,
-			Mode:         machine.CertificateConnection.Mode,
-			Certificate:  machine.CertificateConnection.Certificate,
-			Key:          machine.CertificateConnection.Key,
+		if machine.CertificateConnection == nil {
+			return nil, fmt.Errorf("certificate connection record is missing for machine %s", machine.UUID)
 		}
-		connID, err = u.OpcService.CreateCertificateConnection(req)
+		return &connection_models.CertificateConnection{
+			EndpointURL:     machine.EndpointURL,
+			Model:           machine.Model,
+			Manufacturer:    machine.Manufacturer,
+			Timeout:         timeout,
+			TrustOnFirstUse: tofu,
+			Policy:          machine.CertificateConnection.Policy,
+			Mode:            machine.CertificateConnection.Mode,
+			Certificate:     machine.CertificateConnection.Certificate,
+			Key:             machine.CertificateConnection.Key,
+		}, nil
 
 	case connection_models.ConnectionPassword:
-		req := connection_models.PasswordConnection{
-			EndpointURL:  machine.EndpointURL,
-			Model:        machine.Model,
-			Manufacturer: machine.Manufacturer,
-			Timeout:      time.Duration(machine.Interval) * time.Second,
-			Username:     machine.PasswordConnection.Username,
-			Password:     machine.PasswordConnection.Password,
-			Policy:       machine.PasswordConnection.Policy,
-			Mode:         machine.PasswordConnection.Mode,
+		if machine.PasswordConnection == nil {
+			return nil, fmt.Errorf("password connection record is missing for machine %s", machine.UUID)
 		}
-		connID, err = u.OpcService.CreatePasswordConnection(req)
+		return &connection_models.PasswordConnection{
+			EndpointURL:     machine.EndpointURL,
+			Model:           machine.Model,
+			Manufacturer:    machine.Manufacturer,
+			Timeout:         timeout,
+			TrustOnFirstUse: tofu,
+			Username:        machine.PasswordConnection.Username,
+			Password:        machine.PasswordConnection.Password,
+			Policy:          machine.PasswordConnection.Policy,
+			Mode:            machine.PasswordConnection.Mode,
+
+			AllowInsecurePassword: machine.PasswordConnection.AllowInsecure,
+		}, nil
 
 	case connection_models.ConnectionAnonymous:
-		req := connection_models.AnonymousConnection{
-			EndpointURL:  machine.EndpointURL,
-			Model:        machine.Model,
-			Manufacturer: machine.Manufacturer,
-			Timeout:      time.Duration(machine.Interval) * time.Second,
+		config := &connection_models.AnonymousConnection{
+			EndpointURL:     machine.EndpointURL,
+			Model:           machine.Model,
+			Manufacturer:    machine.Manufacturer,
+			Timeout:         timeout,
+			TrustOnFirstUse: tofu,
+		}
+		if machine.AnonymousConnection != nil {
+			config.Policy = machine.AnonymousConnection.Policy
+			config.Mode = machine.AnonymousConnection.Mode
 		}
-		connID, err = u.OpcService.CreateAnonymousConnection(req)
+		return config, nil
 
 	default:
-		return nil, errors.NewAppError(errors.InvalidDataCode, "invalid connection type", nil, true)
-	}
-
-	if err != nil {
-		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to create connection", err, true)
-	}
-	if connID == nil {
-		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to create connection", nil, true)
-	}
-
-	// Обновляем UUID машины в БД
-	updateMap := map[string]interface{}{
-		"UUID": connID.String(),
-	}
-	if _, eerr := u.MachineRepo.UpdateCncMachine(machine.UUID, updateMap); eerr != nil {
-		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to update machine UUID", err, true)
+		return nil, fmt.Errorf("unknown connection type: %s", machine.ConnectionType)
 	}
-
-	// Получаем информацию о соединении из пула
-	connInfo, err2 := u.OpcService.GetConnectionInfoByUUID(*connID)
-	if err2 != nil || connInfo == nil {
-		return nil, nil // соединение не удалось восстановить, но функция всегда успешна
-	}
-
-	// Запускаем опрос, если машина была в состоянии "polled"
-	if machine.Status == connection_models.ConnectionStatusPolled {
-		if err := u.OpcService.StartPollingForMachine(*connID); err != nil {
-			return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to start polling for machine", err, true)
-		}
-	}
-
-	return connInfo, nil
 }
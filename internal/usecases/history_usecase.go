@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/internal/interfaces"
+	"opc_ua_service/pkg/errors"
+)
+
+type HistoryUsecase struct {
+	OpcService interfaces.OpcService
+}
+
+func NewHistoryUsecase(s interfaces.OpcService) *HistoryUsecase {
+	return &HistoryUsecase{
+		OpcService: s,
+	}
+}
+
+// ReadHistory возвращает сырые исторические значения узлов станка за интервал времени
+func (u *HistoryUsecase) ReadHistory(id uuid.UUID, req models.HistoryReadRequest) (*models.HistoryReadResponse, *errors.AppError) {
+	if err := req.Normalize(); err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+	}
+
+	resp, err := u.OpcService.ReadHistory(id, req)
+	if err != nil {
+		return nil, newNodeError(err, "failed to read history")
+	}
+	return resp, nil
+}
+
+// StartBackfill запускает дозаполнение данных станка из истории сервера с отправкой в Kafka
+func (u *HistoryUsecase) StartBackfill(id uuid.UUID, req models.BackfillRequest) (*models.BackfillJob, *errors.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+	}
+
+	job, err := u.OpcService.StartBackfill(id, req)
+	if err != nil {
+		if errors.Is(err, errors.ErrBackfillRunning) {
+			return nil, errors.NewAppError(errors.ConflictErrorCode, "backfill is already running", err, true)
+		}
+		return nil, newNodeError(err, "failed to start backfill")
+	}
+	return job, nil
+}
+
+// GetBackfillJob возвращает состояние последней задачи дозаполнения станка
+func (u *HistoryUsecase) GetBackfillJob(id uuid.UUID) (*models.BackfillJob, *errors.AppError) {
+	job, err := u.OpcService.GetBackfillJob(id)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, errors.NewAppError(errors.NotFoundErrorCode, "backfill job not found", err, true)
+		}
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to get backfill job", err, false)
+	}
+	return job, nil
+}
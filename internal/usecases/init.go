@@ -11,13 +11,24 @@ import (
 type UseCases struct {
 	interfaces.ConnectionUsecase
 	interfaces.PollingUsecase
+	interfaces.CertificateUsecase
+	interfaces.NodeUsecase
+	interfaces.HistoryUsecase
+	interfaces.ProfileUsecase
 }
 
 func NewUsecases(r interfaces.Repository, s interfaces.OpcService, conf *config.Config) interfaces.Usecases {
 
+	connectionUsecase := connection_usecase.NewConnectionUsecase(s, r, r, r, r)
+	connectionUsecase.CertWarnDays = conf.PKI.ExpiryWarnDays
+
 	return &UseCases{
-		connection_usecase.NewConnectionUsecase(s, r, r, r, r),
+		connectionUsecase,
 		NewPollingUsecase(s, r),
+		NewCertificateUsecase(s, r, conf.PKI.ExpiryWarnDays),
+		NewNodeUsecase(s, r, r),
+		NewHistoryUsecase(s),
+		NewProfileUsecase(r, r),
 	}
 
 }
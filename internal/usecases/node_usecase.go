@@ -0,0 +1,293 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/entities"
+	"opc_ua_service/internal/domain/models"
+	connection_models "opc_ua_service/internal/domain/models/connection_models"
+	"opc_ua_service/internal/interfaces"
+	"opc_ua_service/pkg/errors"
+)
+
+// Ограничения выдачи журнала аудита записи
+const (
+	defaultWriteAuditLimit = 100
+	maxWriteAuditLimit     = 1000
+)
+
+type NodeUsecase struct {
+	OpcService interfaces.OpcService
+	Repo       interfaces.CncMachineRepository
+	AuditRepo  interfaces.NodeWriteAuditRepository
+}
+
+func NewNodeUsecase(s interfaces.OpcService, r interfaces.CncMachineRepository, a interfaces.NodeWriteAuditRepository) *NodeUsecase {
+	return &NodeUsecase{
+		OpcService: s,
+		Repo:       r,
+		AuditRepo:  a,
+	}
+}
+
+// BrowseNodes обходит адресное пространство подключённого станка
+func (u *NodeUsecase) BrowseNodes(id uuid.UUID, req models.BrowseRequest) (*models.BrowseResponse, *errors.AppError) {
+	if err := req.Normalize(); err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+	}
+
+	resp, err := u.OpcService.Browse(id, req)
+	if err != nil {
+		return nil, newNodeError(err, "failed to browse address space")
+	}
+	return resp, nil
+}
+
+// ReadNodes читает произвольные узлы станка по NodeID или пути просмотра
+func (u *NodeUsecase) ReadNodes(id uuid.UUID, req models.NodeReadRequest) (*models.NodeReadResponse, *errors.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+	}
+
+	results, err := u.OpcService.ReadNodes(id, req.Nodes)
+	if err != nil {
+		return nil, newNodeError(err, "failed to read nodes")
+	}
+	return &models.NodeReadResponse{Results: results}, nil
+}
+
+// WriteNodes записывает значения в узлы станка из списка разрешённых.
+// Каждая попытка записи, в том числе отклонённая, сохраняется в журнал аудита.
+func (u *NodeUsecase) WriteNodes(id uuid.UUID, req models.NodeWriteRequest, actor models.WriteActor) (*models.NodeWriteResponse, *errors.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+	}
+
+	machine, eerr := u.getMachine(id)
+	if eerr != nil {
+		return nil, eerr
+	}
+
+	results, err := u.OpcService.WriteNodes(id, req.Items, machine.WritableNodes.Contains)
+	if err != nil {
+		return nil, newNodeError(err, "failed to write nodes")
+	}
+
+	for _, result := range results {
+		record := entities.NodeWriteAudit{
+			MachineUUID:   id.String(),
+			NodeID:        result.NodeID,
+			BrowsePath:    result.BrowsePath,
+			PreviousValue: auditValue(result.PreviousValue),
+			Value:         auditValue(result.Value),
+			Allowed:       result.Allowed,
+			Written:       result.Written,
+			StatusCode:    result.StatusCode,
+			Error:         result.Error,
+			User:          actor.User,
+			ClientIP:      actor.ClientIP,
+		}
+		if _, err := u.AuditRepo.CreateNodeWriteAudit(record); err != nil {
+			return nil, errors.NewAppError(errors.InternalServerErrorCode, "nodes written, but failed to save audit record", err, false)
+		}
+	}
+	return &models.NodeWriteResponse{Results: results}, nil
+}
+
+// GetWritableNodes возвращает список узлов станка, в которые разрешена запись
+func (u *NodeUsecase) GetWritableNodes(id uuid.UUID) (*models.WritableNodesResponse, *errors.AppError) {
+	machine, eerr := u.getMachine(id)
+	if eerr != nil {
+		return nil, eerr
+	}
+
+	nodes := []string(machine.WritableNodes)
+	if nodes == nil {
+		nodes = []string{}
+	}
+	return &models.WritableNodesResponse{Nodes: nodes}, nil
+}
+
+// SetWritableNodes заменяет список узлов станка, в которые разрешена запись. NodeID приводятся к канонической форме
+func (u *NodeUsecase) SetWritableNodes(id uuid.UUID, req models.WritableNodesRequest) (*models.WritableNodesResponse, *errors.AppError) {
+	nodes, eerr := u.setNodeList(id, "writable_nodes", req.Nodes)
+	if eerr != nil {
+		return nil, eerr
+	}
+	return &models.WritableNodesResponse{Nodes: nodes}, nil
+}
+
+// GetWriteAudit возвращает последние записи журнала аудита записи в узлы станка
+func (u *NodeUsecase) GetWriteAudit(id uuid.UUID, limit int) (*models.NodeWriteAuditResponse, *errors.AppError) {
+	if limit == 0 {
+		limit = defaultWriteAuditLimit
+	}
+	if limit < 0 || limit > maxWriteAuditLimit {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", fmt.Errorf("limit must be in range 1..%d", maxWriteAuditLimit), true)
+	}
+
+	records, err := u.AuditRepo.GetNodeWriteAudits(id.String(), limit)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to read audit records", err, false)
+	}
+
+	resp := &models.NodeWriteAuditResponse{Records: make([]models.NodeWriteAuditRecord, 0, len(records))}
+	for _, r := range records {
+		resp.Records = append(resp.Records, models.NodeWriteAuditRecord{
+			ID:            r.ID,
+			MachineUUID:   r.MachineUUID,
+			NodeID:        r.NodeID,
+			BrowsePath:    r.BrowsePath,
+			PreviousValue: r.PreviousValue,
+			Value:         r.Value,
+			Allowed:       r.Allowed,
+			Written:       r.Written,
+			StatusCode:    r.StatusCode,
+			Error:         r.Error,
+			User:          r.User,
+			ClientIP:      r.ClientIP,
+			CreatedAt:     r.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// CallMethod вызывает метод на объекте станка, если метод входит в список разрешённых
+func (u *NodeUsecase) CallMethod(id uuid.UUID, req models.MethodCallRequest) (*models.MethodCallResponse, *errors.AppError) {
+	if err := req.Validate(); err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+	}
+
+	machine, eerr := u.getMachine(id)
+	if eerr != nil {
+		return nil, eerr
+	}
+	method, err := normalizeCallableMethod(connection_models.CallableMethod{ObjectID: req.ObjectID, MethodID: req.MethodID})
+	if err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+	}
+	if !machine.CallableMethods.Contains(method.ObjectID, method.MethodID) {
+		return nil, errors.NewAppError(errors.ForbiddenErrorCode, "method is not allowed",
+			fmt.Errorf("method %s on object %s is not in the callable methods list of the machine", method.MethodID, method.ObjectID), true)
+	}
+
+	resp, err := u.OpcService.CallMethod(id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errors.ErrInvalidArguments):
+			return nil, errors.NewAppError(errors.InvalidDataCode, "invalid method arguments", err, true)
+		case errors.Is(err, errors.ErrMethodCallFailed):
+			return nil, errors.NewAppError(errors.UnprocessableEntityCode, "method call failed", err, true)
+		}
+		return nil, newNodeError(err, "failed to call method")
+	}
+	return resp, nil
+}
+
+// GetCallableMethods возвращает список методов станка, которые разрешено вызывать
+func (u *NodeUsecase) GetCallableMethods(id uuid.UUID) (*models.CallableMethodsResponse, *errors.AppError) {
+	machine, eerr := u.getMachine(id)
+	if eerr != nil {
+		return nil, eerr
+	}
+
+	methods := []connection_models.CallableMethod(machine.CallableMethods)
+	if methods == nil {
+		methods = []connection_models.CallableMethod{}
+	}
+	return &models.CallableMethodsResponse{Methods: methods}, nil
+}
+
+// SetCallableMethods заменяет список пар объект/метод станка, которые разрешено вызывать
+func (u *NodeUsecase) SetCallableMethods(id uuid.UUID, req models.CallableMethodsRequest) (*models.CallableMethodsResponse, *errors.AppError) {
+	methods := connection_models.MethodList{}
+	for _, m := range req.Methods {
+		method, err := normalizeCallableMethod(m)
+		if err != nil {
+			return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+		}
+		if !methods.Contains(method.ObjectID, method.MethodID) {
+			methods = append(methods, method)
+		}
+	}
+
+	if _, eerr := u.getMachine(id); eerr != nil {
+		return nil, eerr
+	}
+	if _, err := u.Repo.UpdateCncMachine(id.String(), map[string]interface{}{"callable_methods": methods}); err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to update machine record", err, false)
+	}
+	return &models.CallableMethodsResponse{Methods: methods}, nil
+}
+
+// normalizeCallableMethod приводит NodeID объекта и метода к канонической форме
+func normalizeCallableMethod(m connection_models.CallableMethod) (connection_models.CallableMethod, error) {
+	objectID, err := models.NormalizeNodeID(m.ObjectID)
+	if err != nil {
+		return m, fmt.Errorf("objectID: %w", err)
+	}
+	methodID, err := models.NormalizeNodeID(m.MethodID)
+	if err != nil {
+		return m, fmt.Errorf("methodID: %w", err)
+	}
+	return connection_models.CallableMethod{ObjectID: objectID, MethodID: methodID}, nil
+}
+
+// setNodeList сохраняет список NodeID станка в колонку column, приводя NodeID к канонической форме
+func (u *NodeUsecase) setNodeList(id uuid.UUID, column string, list []string) (connection_models.NodeList, *errors.AppError) {
+	nodes := connection_models.NodeList{}
+	for _, node := range list {
+		nodeID, err := models.NormalizeNodeID(node)
+		if err != nil {
+			return nil, errors.NewAppError(errors.InvalidDataCode, "validation failed", err, true)
+		}
+		if !nodes.Contains(nodeID) {
+			nodes = append(nodes, nodeID)
+		}
+	}
+
+	if _, eerr := u.getMachine(id); eerr != nil {
+		return nil, eerr
+	}
+	if _, err := u.Repo.UpdateCncMachine(id.String(), map[string]interface{}{column: nodes}); err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to update machine record", err, false)
+	}
+	return nodes, nil
+}
+
+// getMachine возвращает сохранённую запись станка
+func (u *NodeUsecase) getMachine(id uuid.UUID) (entities.CncMachine, *errors.AppError) {
+	machine, err := u.Repo.GetCncMachineByUUID(id.String())
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return machine, errors.NewAppError(errors.NotFoundErrorCode, "machine not found", err, true)
+		}
+		return machine, errors.NewAppError(errors.InternalServerErrorCode, "failed to get machine record", err, false)
+	}
+	return machine, nil
+}
+
+// auditValue сериализует значение для журнала аудита
+func auditValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+// newNodeError сводит ошибку работы с узлами станка к AppError
+func newNodeError(err error, failMsg string) *errors.AppError {
+	switch {
+	case errors.Is(err, errors.ErrNotFound):
+		return errors.NewAppError(errors.NotFoundErrorCode, "connection not found", err, true)
+	case errors.Is(err, errors.ErrConnectionUnavailable):
+		return errors.NewAppError(errors.ServiceUnavailableCode, "connection is not available", err, true)
+	default:
+		return errors.NewAppError(errors.InternalServerErrorCode, failMsg, err, false)
+	}
+}
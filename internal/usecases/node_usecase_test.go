@@ -0,0 +1,72 @@
+package usecases
+
+import (
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/entities"
+	"opc_ua_service/internal/domain/models"
+	connection_models "opc_ua_service/internal/domain/models/connection_models"
+	"opc_ua_service/internal/interfaces"
+	"opc_ua_service/pkg/errors"
+	"testing"
+)
+
+// methodRepo отдаёт станок с заданным списком разрешённых методов
+type methodRepo struct {
+	interfaces.CncMachineRepository
+	machine entities.CncMachine
+}
+
+func (r *methodRepo) GetCncMachineByUUID(string) (entities.CncMachine, error) {
+	return r.machine, nil
+}
+
+// methodService запоминает вызванные методы
+type methodService struct {
+	interfaces.OpcService
+	calls []models.MethodCallRequest
+}
+
+func (s *methodService) CallMethod(_ uuid.UUID, req models.MethodCallRequest) (*models.MethodCallResponse, error) {
+	s.calls = append(s.calls, req)
+	return &models.MethodCallResponse{ObjectID: req.ObjectID, MethodID: req.MethodID, StatusCode: "Good"}, nil
+}
+
+func TestCallMethodAllowlist(t *testing.T) {
+	allowed := connection_models.MethodList{{ObjectID: "ns=2;s=/Methods", MethodID: "ns=2;s=/Methods/Select"}}
+	tests := []struct {
+		name     string
+		req      models.MethodCallRequest
+		wantCode int
+	}{
+		{name: "разрешённая пара", req: models.MethodCallRequest{ObjectID: "ns=2;s=/Methods", MethodID: "ns=2;s=/Methods/Select"}},
+		{name: "разрешённый метод на другом объекте",
+			req: models.MethodCallRequest{ObjectID: "ns=2;s=/Channel", MethodID: "ns=2;s=/Methods/Select"}, wantCode: errors.ForbiddenErrorCode},
+		{name: "другой метод на разрешённом объекте",
+			req: models.MethodCallRequest{ObjectID: "ns=2;s=/Methods", MethodID: "ns=2;s=/Methods/Delete"}, wantCode: errors.ForbiddenErrorCode},
+		{name: "неверный NodeID объекта",
+			req: models.MethodCallRequest{ObjectID: "ns=x;s=/Methods", MethodID: "ns=2;s=/Methods/Select"}, wantCode: errors.InvalidDataCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &methodService{}
+			u := NewNodeUsecase(service, &methodRepo{machine: entities.CncMachine{CallableMethods: allowed}}, nil)
+
+			_, eerr := u.CallMethod(uuid.New(), tt.req)
+			if tt.wantCode == 0 {
+				if eerr != nil {
+					t.Fatalf("CallMethod() error = %v", eerr)
+				}
+				if len(service.calls) != 1 {
+					t.Fatalf("метод вызван %d раз, ожидался 1", len(service.calls))
+				}
+				return
+			}
+			if eerr == nil || eerr.Code != tt.wantCode {
+				t.Fatalf("CallMethod() error = %v, ожидался код %d", eerr, tt.wantCode)
+			}
+			if len(service.calls) != 0 {
+				t.Fatal("запрещённый метод передан на сервер")
+			}
+		})
+	}
+}
@@ -48,9 +48,38 @@ func (u *PollingUsecase) GetControlProgram(req models.GetControlProgramRequest)
 	return resp, nil
 }
 
-// StartPollingMachine запускает сбор данных машины по UUID
-func (u *PollingUsecase) StartPollingMachine(machineID uuid.UUID) *errors.AppError {
-	err := u.OpcService.StartPollingForMachine(machineID)
+// StartPollingMachine запускает сбор данных машины по UUID опросом или подпиской
+func (u *PollingUsecase) StartPollingMachine(request models.StartPollingRequest) *errors.AppError {
+	machineID, err := uuid.Parse(request.UUID)
+	if err != nil {
+		return errors.NewAppError(http.StatusBadRequest, "invalid UUID format", err, true)
+	}
+
+	mode := request.Mode
+	if mode == "" {
+		mode = connection_models.AcquisitionPolling
+	}
+	if err := mode.Validate(); err != nil {
+		return errors.NewAppError(http.StatusBadRequest, "invalid acquisition mode", err, true)
+	}
+
+	var settings *connection_models.SubscriptionSettings
+	switch mode {
+	case connection_models.AcquisitionSubscription:
+		settings = &connection_models.SubscriptionSettings{}
+		if request.Subscription != nil {
+			settings = request.Subscription
+		}
+		if err := settings.Validate(); err != nil {
+			return errors.NewAppError(http.StatusBadRequest, "invalid subscription settings", err, true)
+		}
+		err = u.OpcService.StartSubscriptionForMachine(machineID, *settings)
+	default:
+		if request.Subscription != nil {
+			return errors.NewAppError(http.StatusBadRequest, "subscription settings require mode subscription", nil, true)
+		}
+		err = u.OpcService.StartPollingForMachine(machineID)
+	}
 	if err != nil {
 		if errors.Is(err, errors.ErrNotFound) {
 			return errors.NewAppError(http.StatusNotFound, "machine not found", err, false)
@@ -59,7 +88,9 @@ func (u *PollingUsecase) StartPollingMachine(machineID uuid.UUID) *errors.AppErr
 		}
 	}
 	updateMap := map[string]interface{}{
-		"status": connection_models.ConnectionStatusPolled,
+		"status":                connection_models.ConnectionStatusPolled,
+		"acquisition_mode":      mode,
+		"subscription_settings": settings,
 	}
 
 	_, err = u.Repo.UpdateCncMachine(machineID.String(), updateMap)
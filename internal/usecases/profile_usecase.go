@@ -0,0 +1,381 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"opc_ua_service/internal/domain/entities"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/internal/interfaces"
+	"opc_ua_service/pkg/errors"
+	"opc_ua_service/pkg/machine_models"
+	"slices"
+	"strings"
+)
+
+type ProfileUsecase struct {
+	Repo        interfaces.MachineProfileRepository
+	MachineRepo interfaces.CncMachineRepository
+}
+
+func NewProfileUsecase(r interfaces.MachineProfileRepository, m interfaces.CncMachineRepository) *ProfileUsecase {
+	return &ProfileUsecase{
+		Repo:        r,
+		MachineRepo: m,
+	}
+}
+
+// ListProfiles возвращает профили реестра с последними версиями
+func (u *ProfileUsecase) ListProfiles() (models.ProfileListResponse, *errors.AppError) {
+	list, err := u.Repo.GetAllMachineProfiles()
+	if err != nil {
+		return models.ProfileListResponse{}, errors.NewAppError(errors.InternalServerErrorCode, "failed to get machine profiles", err, false)
+	}
+
+	resp := models.ProfileListResponse{Profiles: []models.ProfileSummary{}}
+	// Версии отсортированы по имени и номеру: последняя версия профиля идёт последней
+	for _, record := range list {
+		definition, err := decodeProfileDefinition(record.Definition)
+		if err != nil {
+			return models.ProfileListResponse{}, errors.NewAppError(errors.InternalServerErrorCode, "failed to decode machine profile", err, false)
+		}
+		summary := models.ProfileSummary{
+			Name:          record.Name,
+			Manufacturer:  record.Manufacturer,
+			Models:        definition.Models,
+			LatestVersion: record.Version,
+			Versions:      1,
+			UpdatedAt:     record.CreatedAt,
+		}
+		if n := len(resp.Profiles); n > 0 && resp.Profiles[n-1].Name == record.Name {
+			summary.Versions += resp.Profiles[n-1].Versions
+			resp.Profiles[n-1] = summary
+			continue
+		}
+		resp.Profiles = append(resp.Profiles, summary)
+	}
+	return resp, nil
+}
+
+// GetProfile возвращает последнюю версию профиля со списком всех версий
+func (u *ProfileUsecase) GetProfile(name string) (*models.ProfileResponse, *errors.AppError) {
+	versions, err := u.Repo.GetMachineProfileVersions(name)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to get machine profile", err, false)
+	}
+	if len(versions) == 0 {
+		return nil, errors.NewAppError(errors.NotFoundErrorCode, "profile not found", fmt.Errorf("profile %s does not exist", name), true)
+	}
+
+	resp, eerr := u.profileResponse(versions[0])
+	if eerr != nil {
+		return nil, eerr
+	}
+	resp.Versions = make([]models.ProfileVersionInfo, 0, len(versions))
+	for _, record := range versions {
+		info, eerr := profileVersionInfo(record)
+		if eerr != nil {
+			return nil, eerr
+		}
+		resp.Versions = append(resp.Versions, info)
+	}
+	return resp, nil
+}
+
+// GetProfileVersion возвращает версию профиля
+func (u *ProfileUsecase) GetProfileVersion(name string, version int) (*models.ProfileResponse, *errors.AppError) {
+	record, err := u.Repo.GetMachineProfileVersion(name, version)
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return nil, errors.NewAppError(errors.NotFoundErrorCode, "profile version not found", fmt.Errorf("profile %s version %d does not exist", name, version), true)
+		}
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to get machine profile", err, false)
+	}
+	return u.profileResponse(record)
+}
+
+// ValidateProfile проверяет профиль без сохранения
+func (u *ProfileUsecase) ValidateProfile(req models.ProfileUploadRequest) models.ProfileValidationResponse {
+	if _, err := parseUploadedProfile(req); err != nil {
+		return models.ProfileValidationResponse{Errors: profileErrors(err)}
+	}
+	return models.ProfileValidationResponse{Valid: true}
+}
+
+// CreateProfileVersion проверяет профиль и сохраняет его новой версией. Новые опросы станков его моделей
+// используют новую версию без перезапуска сервиса, если за станком не закреплена другая версия
+func (u *ProfileUsecase) CreateProfileVersion(req models.ProfileUploadRequest) (*models.ProfileResponse, *errors.AppError) {
+	profile, err := parseUploadedProfile(req)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "invalid machine profile", err, true)
+	}
+
+	// Модель станка описывает один профиль реестра: иначе выбор профиля зависел бы от порядка загрузки
+	for _, model := range profile.Models {
+		if other, ok := machine_models.LatestStoredProfile(profile.Manufacturer, model); ok && other.Name != profile.Name {
+			return nil, errors.NewAppError(errors.ConflictErrorCode, "machine model is described by another profile",
+				fmt.Errorf("model %s %s is described by profile %s", profile.Manufacturer, model, other.Name), true)
+		}
+	}
+
+	definition, err := json.Marshal(profile)
+	if err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to encode machine profile", err, false)
+	}
+	record, err := u.Repo.CreateMachineProfileVersion(entities.MachineProfile{
+		Name:         profile.Name,
+		Manufacturer: profile.Manufacturer,
+		Definition:   string(definition),
+		Comment:      req.Comment,
+		CreatedBy:    req.CreatedBy,
+	})
+	if err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to save machine profile", err, false)
+	}
+
+	profile.Version = record.Version
+	profile.Source = storedProfileSource(record)
+	machine_models.RegisterStoredProfile(profile)
+
+	return u.profileResponse(record)
+}
+
+// DeleteProfile удаляет версию профиля, version 0 - все версии. Закреплённые за станками версии не удаляются
+func (u *ProfileUsecase) DeleteProfile(name string, version int) *errors.AppError {
+	machines, err := u.MachineRepo.GetAllCncMachines()
+	if err != nil {
+		return errors.NewAppError(errors.InternalServerErrorCode, "failed to get machine list", err, false)
+	}
+	var pinned []string
+	for _, machine := range machines {
+		if machine.ProfileName == name && (version == 0 || machine.ProfileVersion == version) {
+			pinned = append(pinned, machine.UUID)
+		}
+	}
+	if len(pinned) > 0 {
+		return errors.NewAppError(errors.ConflictErrorCode, "profile version is pinned to machines",
+			fmt.Errorf("unpin profile from machines first: %s", strings.Join(pinned, ", ")), true)
+	}
+
+	if err := u.Repo.DeleteMachineProfile(name, version); err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return errors.NewAppError(errors.NotFoundErrorCode, "profile not found", fmt.Errorf("profile %s does not exist", name), true)
+		}
+		return errors.NewAppError(errors.InternalServerErrorCode, "failed to delete machine profile", err, false)
+	}
+	machine_models.RemoveStoredProfile(name, version)
+	return nil
+}
+
+// GetMachineProfile возвращает модель, по которой собираются данные станка
+func (u *ProfileUsecase) GetMachineProfile(id uuid.UUID) (*models.MachineProfileResponse, *errors.AppError) {
+	machine, eerr := u.getMachine(id)
+	if eerr != nil {
+		return nil, eerr
+	}
+
+	resp := &models.MachineProfileResponse{
+		MachineUUID:  machine.UUID,
+		Manufacturer: machine.Manufacturer,
+		Model:        machine.Model,
+		Source:       models.ProfileSourceNone,
+	}
+	if machine.DetectedAt != nil {
+		resp.Detection = &models.MachineDetectionResponse{
+			Manufacturer:    machine.DetectedManufacturer,
+			Model:           machine.DetectedModel,
+			Source:          models.DetectionSourceEnum(machine.DetectionSource),
+			ProductName:     machine.ServerProductName,
+			SoftwareVersion: machine.ServerSoftwareVersion,
+			DetectedAt:      *machine.DetectedAt,
+		}
+	}
+	profile, ok := machine_models.FindMachineProfileFor(machine.UUID, machine.Manufacturer, machine.Model)
+	switch {
+	case ok && profile.Version == 0:
+		resp.Source, resp.ProfileFile = models.ProfileSourceFile, profile.Source
+	case ok:
+		resp.Source, resp.ProfileName, resp.ProfileVersion = models.ProfileSourceRegistry, profile.Name, profile.Version
+		if machine.ProfileName == profile.Name && machine.ProfileVersion == profile.Version {
+			resp.Source = models.ProfileSourcePinned
+		}
+	case interfaces.IsBuiltinMachineModel(machine.Manufacturer, machine.Model):
+		resp.Source = models.ProfileSourceBuiltin
+	}
+	return resp, nil
+}
+
+// PinMachineProfile закрепляет за станком версию профиля. Действует со следующего запуска опроса или подписки
+func (u *ProfileUsecase) PinMachineProfile(id uuid.UUID, req models.ProfilePinRequest) (*models.MachineProfileResponse, *errors.AppError) {
+	machine, eerr := u.getMachine(id)
+	if eerr != nil {
+		return nil, eerr
+	}
+
+	profile, ok := machine_models.StoredProfile(req.Name, req.Version)
+	if !ok {
+		return nil, errors.NewAppError(errors.NotFoundErrorCode, "profile version not found", fmt.Errorf("profile %s version %d does not exist", req.Name, req.Version), true)
+	}
+	if profile.Manufacturer != machine.Manufacturer || !slices.Contains(profile.Models, machine.Model) {
+		return nil, errors.NewAppError(errors.InvalidDataCode, "profile does not describe machine model",
+			fmt.Errorf("profile %s describes %s %v, machine is %s %s", profile.Name, profile.Manufacturer, profile.Models, machine.Manufacturer, machine.Model), true)
+	}
+
+	if _, err := u.MachineRepo.UpdateCncMachine(machine.UUID, map[string]interface{}{"profile_name": req.Name, "profile_version": req.Version}); err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to update machine record", err, false)
+	}
+	if err := machine_models.PinMachineProfile(machine.UUID, req.Name, req.Version); err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to pin machine profile", err, false)
+	}
+	return u.GetMachineProfile(id)
+}
+
+// UnpinMachineProfile снимает закрепление версии профиля: станок снова использует последнюю версию
+func (u *ProfileUsecase) UnpinMachineProfile(id uuid.UUID) (*models.MachineProfileResponse, *errors.AppError) {
+	machine, eerr := u.getMachine(id)
+	if eerr != nil {
+		return nil, eerr
+	}
+
+	if _, err := u.MachineRepo.UpdateCncMachine(machine.UUID, map[string]interface{}{"profile_name": "", "profile_version": 0}); err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to update machine record", err, false)
+	}
+	machine_models.UnpinMachineProfile(machine.UUID)
+	return u.GetMachineProfile(id)
+}
+
+// LoadStoredProfiles регистрирует версии профилей из БД и закрепления версий за станками.
+// Версия, которая не проходит проверку, пропускается, ошибки остальных версий объединяются
+func (u *ProfileUsecase) LoadStoredProfiles() (int, *errors.AppError) {
+	list, err := u.Repo.GetAllMachineProfiles()
+	if err != nil {
+		return 0, errors.NewAppError(errors.InternalServerErrorCode, "failed to get machine profiles", err, false)
+	}
+
+	var errs []error
+	loaded := 0
+	for _, record := range list {
+		source := storedProfileSource(record)
+		profile, err := machine_models.ParseMachineProfile(source+".json", []byte(record.Definition))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		profile.Name, profile.Version, profile.Source = record.Name, record.Version, source
+		machine_models.RegisterStoredProfile(profile)
+		loaded++
+	}
+
+	machines, err := u.MachineRepo.GetAllCncMachines()
+	if err != nil {
+		return loaded, errors.NewAppError(errors.InternalServerErrorCode, "failed to get machine list", err, false)
+	}
+	for _, machine := range machines {
+		if machine.ProfileName == "" {
+			continue
+		}
+		if err := machine_models.PinMachineProfile(machine.UUID, machine.ProfileName, machine.ProfileVersion); err != nil {
+			errs = append(errs, fmt.Errorf("machine %s: %w", machine.UUID, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return loaded, errors.NewAppError(errors.InternalServerErrorCode, "some machine profiles were not loaded", errors.Join(errs...), false)
+	}
+	return loaded, nil
+}
+
+// profileResponse собирает ответ по версии профиля со списком станков, за которыми она закреплена
+func (u *ProfileUsecase) profileResponse(record entities.MachineProfile) (*models.ProfileResponse, *errors.AppError) {
+	info, eerr := profileVersionInfo(record)
+	if eerr != nil {
+		return nil, eerr
+	}
+
+	machines, err := u.MachineRepo.GetAllCncMachines()
+	if err != nil {
+		return nil, errors.NewAppError(errors.InternalServerErrorCode, "failed to get machine list", err, false)
+	}
+	pinned := []string{}
+	for _, machine := range machines {
+		if machine.ProfileName == record.Name && machine.ProfileVersion == record.Version {
+			pinned = append(pinned, machine.UUID)
+		}
+	}
+
+	return &models.ProfileResponse{
+		Name:               record.Name,
+		Manufacturer:       record.Manufacturer,
+		ProfileVersionInfo: info,
+		Definition:         json.RawMessage(record.Definition),
+		PinnedMachines:     pinned,
+	}, nil
+}
+
+// getMachine возвращает сохранённую запись станка
+func (u *ProfileUsecase) getMachine(id uuid.UUID) (entities.CncMachine, *errors.AppError) {
+	machine, err := u.MachineRepo.GetCncMachineByUUID(id.String())
+	if err != nil {
+		if errors.Is(err, errors.ErrNotFound) {
+			return machine, errors.NewAppError(errors.NotFoundErrorCode, "machine not found", err, true)
+		}
+		return machine, errors.NewAppError(errors.InternalServerErrorCode, "failed to get machine record", err, false)
+	}
+	return machine, nil
+}
+
+// parseUploadedProfile разбирает и проверяет загруженный профиль. Формат определяется по Content-Type
+func parseUploadedProfile(req models.ProfileUploadRequest) (*machine_models.MachineProfile, error) {
+	name := "request.json"
+	if strings.Contains(req.ContentType, "yaml") {
+		name = "request.yaml"
+	}
+	profile, err := machine_models.ParseMachineProfile(name, req.Definition)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.ValidateProfileName(profile.Name); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// profileVersionInfo возвращает описание версии профиля без определения
+func profileVersionInfo(record entities.MachineProfile) (models.ProfileVersionInfo, *errors.AppError) {
+	definition, err := decodeProfileDefinition(record.Definition)
+	if err != nil {
+		return models.ProfileVersionInfo{}, errors.NewAppError(errors.InternalServerErrorCode, "failed to decode machine profile", err, false)
+	}
+	return models.ProfileVersionInfo{
+		Version:   record.Version,
+		Models:    definition.Models,
+		Comment:   record.Comment,
+		CreatedBy: record.CreatedBy,
+		CreatedAt: record.CreatedAt,
+	}, nil
+}
+
+// decodeProfileDefinition разбирает сохранённое определение профиля без проверки
+func decodeProfileDefinition(definition string) (machine_models.MachineProfile, error) {
+	var profile machine_models.MachineProfile
+	if err := json.Unmarshal([]byte(definition), &profile); err != nil {
+		return profile, fmt.Errorf("decode profile definition: %w", err)
+	}
+	return profile, nil
+}
+
+// storedProfileSource - обозначение версии профиля из БД в логах и ошибках
+func storedProfileSource(record entities.MachineProfile) string {
+	return fmt.Sprintf("%s@v%d", record.Name, record.Version)
+}
+
+// profileErrors разбивает ошибку проверки профиля на отдельные сообщения
+func profileErrors(err error) []string {
+	var messages []string
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			messages = append(messages, line)
+		}
+	}
+	return messages
+}
@@ -12,9 +12,13 @@ const (
 
 	BadRequestErrorCode     = 400
 	InvalidDataCode         = 400
+	UnauthorizedErrorCode   = 401
 	ForbiddenErrorCode      = 403
 	InternalServerErrorCode = 500
 	NotFoundErrorCode       = 404
+	ConflictErrorCode       = 409
+	UnprocessableEntityCode = 422
+	ServiceUnavailableCode  = 503
 )
 
 var (
@@ -24,6 +28,17 @@ var (
 	ErrUnauthorized = errors.New("unauthorized")
 	ErrForbidden    = errors.New("forbidden")
 	ErrInternal     = errors.New("internal error")
+
+	ErrSecurityNotOffered        = errors.New("requested security is not offered by server")
+	ErrServerCertificateRejected = errors.New("server certificate rejected, trust it via /api/v1/certificates/server")
+	ErrECCNotSupported           = errors.New("ECC keys and security policies are not supported by the OPC UA client stack")
+	ErrCertificateKeyMismatch    = errors.New("client certificate and private key do not match")
+	ErrIdentityRejected          = errors.New("server rejected user identity")
+	ErrConnectionUnavailable     = errors.New("connection is not available")
+	ErrInvalidArguments          = errors.New("invalid method arguments")
+	ErrMethodCallFailed          = errors.New("method call failed")
+	ErrBackfillRunning           = errors.New("backfill is already running")
+	ErrUnsupportedForModel       = errors.New("operation is not supported for machine model")
 )
 
 func Is(err any, err2 error) bool {
@@ -33,6 +48,11 @@ func Is(err any, err2 error) bool {
 	return false
 }
 
+// Join объединяет ошибки, nil-ошибки пропускаются
+func Join(errs ...error) error {
+	return errors.Join(errs...)
+}
+
 var ErrNotFound = errors.New("not found")
 
 func NewNotFoundError(message string) error {
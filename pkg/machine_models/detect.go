@@ -0,0 +1,142 @@
+package machine_models
+
+import (
+	"opc_ua_service/internal/domain/models"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DetectMachineModels определяет возможные модели станка по сведениям сервера. Первыми идут профили, производитель
+// и модель которых названы в BuildInfo или Identification, затем встроенные модели по пространствам имён ЧПУ,
+// последней - стандартная модель OPC 40501. Сервер может подходить под несколько моделей: например, ЧПУ
+// SINUMERIK с экземпляром MachineTool. Пустой результат - модель по сведениям сервера не определена
+func DetectMachineModels(identity models.ServerIdentity) []models.DetectedMachine {
+	var detected []models.DetectedMachine
+	add := func(manufacturer, model string, source models.DetectionSourceEnum) {
+		for _, d := range detected {
+			if SameName(d.Manufacturer, manufacturer) && SameName(d.Model, model) {
+				return
+			}
+		}
+		detected = append(detected, models.DetectedMachine{Manufacturer: manufacturer, Model: model, Source: source})
+	}
+
+	for _, key := range detectProfileModels(identity) {
+		add(key[0], key[1], models.DetectionSourceProfile)
+	}
+
+	// Пространство имён ЧПУ определяет производителя. Модель берётся из ProductName или Identification.Model,
+	// а если они её не называют, подходят все встроенные модели производителя
+	product := normalizeName(identity.ProductName + " " + identity.MachineModel)
+	if slices.Contains(identity.Namespaces, SinumerikNamespaceURI) {
+		for _, model := range namespaceModels(product, sinumerikModels) {
+			add("Siemens", model, models.DetectionSourceNamespace)
+		}
+	}
+	if slices.Contains(identity.Namespaces, HeidenhainNamespaceURI) {
+		for _, model := range namespaceModels(product, heidenhainModels) {
+			add("Heidenhain", model, models.DetectionSourceNamespace)
+		}
+	}
+	if slices.Contains(identity.Namespaces, MachineToolNamespaceURI) {
+		manufacturer := identity.MachineManufacturer
+		if manufacturer == "" {
+			manufacturer = identity.ManufacturerName
+		}
+		add(manufacturer, MachineToolModel, models.DetectionSourceMachineTool)
+	}
+	return detected
+}
+
+// Встроенные модели производителей ЧПУ по пространству имён: номер модели в ProductName и её название.
+// Первой идёт модель, которая выбирается, если станок регистрируется без модели
+var (
+	sinumerikModels  = [][2]string{{"840", "840D sl"}, {"828", "828D"}}
+	heidenhainModels = [][2]string{{"640", "TNC640"}, {"620", "TNC620"}}
+)
+
+// namespaceModels возвращает модели производителя, номер которых назван в сведениях о продукте сервера,
+// или все его модели, если ни одна не названа
+func namespaceModels(product string, vendorModels [][2]string) []string {
+	all := make([]string, 0, len(vendorModels))
+	for _, model := range vendorModels {
+		if strings.Contains(product, model[0]) {
+			return []string{model[1]}
+		}
+		all = append(all, model[1])
+	}
+	return all
+}
+
+// detectProfileModels возвращает производителя и модель профилей реестра, которые названы в сведениях сервера:
+// производитель - в ManufacturerName или Identification.Manufacturer, модель - в ProductName или Identification.Model
+func detectProfileModels(identity models.ServerIdentity) [][2]string {
+	manufacturers := normalizeName(identity.ManufacturerName + " " + identity.MachineManufacturer + " " + identity.ProductName)
+	products := normalizeName(identity.ProductName + " " + identity.MachineModel)
+	if manufacturers == "" || products == "" {
+		return nil
+	}
+
+	profilesMu.RLock()
+	keys := make(map[[2]string]bool)
+	for _, profiles := range []map[string]*MachineProfile{latestProfiles, fileProfiles} {
+		for _, profile := range profiles {
+			manufacturer := normalizeName(profile.Manufacturer)
+			if manufacturer == "" || !strings.Contains(manufacturers, manufacturer) {
+				continue
+			}
+			for _, model := range profile.Models {
+				if name := normalizeName(model); name != "" && strings.Contains(products, name) {
+					keys[[2]string{profile.Manufacturer, model}] = true
+				}
+			}
+		}
+	}
+	profilesMu.RUnlock()
+
+	// Более длинное название модели точнее: TNC640 важнее TNC6
+	matched := make([][2]string, 0, len(keys))
+	for key := range keys {
+		matched = append(matched, key)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if len(matched[i][1]) != len(matched[j][1]) {
+			return len(matched[i][1]) > len(matched[j][1])
+		}
+		return matched[i][0]+matched[i][1] < matched[j][0]+matched[j][1]
+	})
+	return matched
+}
+
+// normalizeName приводит название к нижнему регистру без пробелов и разделителей: "TNC 640" и "tnc-640" совпадают
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_', '.', '/', '\t':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// manufacturerAliases - прежние названия производителей встроенных моделей. Станки Heidenhain регистрировались
+// с производителем "ACME", и такие регистрации должны по-прежнему находить модель и проходить сверку с сервером
+var manufacturerAliases = map[string]string{
+	"ACME": "Heidenhain",
+}
+
+// CanonicalManufacturer возвращает производителя встроенных моделей для прежнего названия или само название
+func CanonicalManufacturer(name string) string {
+	for alias, canonical := range manufacturerAliases {
+		if SameName(name, alias) {
+			return canonical
+		}
+	}
+	return name
+}
+
+// SameName сравнивает названия производителей или моделей без учёта регистра, пробелов и разделителей
+func SameName(a, b string) bool {
+	return normalizeName(a) == normalizeName(b)
+}
@@ -0,0 +1,143 @@
+package machine_models
+
+import (
+	"opc_ua_service/internal/domain/models"
+	"reflect"
+	"testing"
+)
+
+func TestDetectMachineModels(t *testing.T) {
+	RegisterStoredProfile(&MachineProfile{Name: "test-fanuc", Version: 1, Manufacturer: "Fanuc", Models: []string{"30i-B", "30i"}})
+	t.Cleanup(func() { RemoveStoredProfile("test-fanuc", 0) })
+
+	tests := []struct {
+		name     string
+		identity models.ServerIdentity
+		want     []models.DetectedMachine
+	}{
+		{
+			name:     "сведения сервера не подходят",
+			identity: models.ServerIdentity{ProductName: "Generic OPC UA Server", Namespaces: []string{"urn:vendor"}},
+			want:     nil,
+		},
+		{
+			name:     "SINUMERIK 840D sl по пространству имён",
+			identity: models.ServerIdentity{ProductName: "SINUMERIK 840D sl", Namespaces: []string{SinumerikNamespaceURI}},
+			want:     []models.DetectedMachine{{Manufacturer: "Siemens", Model: "840D sl", Source: models.DetectionSourceNamespace}},
+		},
+		{
+			name:     "SINUMERIK 828D",
+			identity: models.ServerIdentity{ProductName: "SINUMERIK 828D", Namespaces: []string{SinumerikNamespaceURI}},
+			want:     []models.DetectedMachine{{Manufacturer: "Siemens", Model: "828D", Source: models.DetectionSourceNamespace}},
+		},
+		{
+			name:     "Heidenhain TNC 620",
+			identity: models.ServerIdentity{ProductName: "TNC 620", Namespaces: []string{HeidenhainNamespaceURI}},
+			want:     []models.DetectedMachine{{Manufacturer: "Heidenhain", Model: "TNC620", Source: models.DetectionSourceNamespace}},
+		},
+		{
+			name:     "Heidenhain без модели в ProductName",
+			identity: models.ServerIdentity{ProductName: "HEIDENHAIN NC", Namespaces: []string{HeidenhainNamespaceURI}},
+			want: []models.DetectedMachine{
+				{Manufacturer: "Heidenhain", Model: "TNC640", Source: models.DetectionSourceNamespace},
+				{Manufacturer: "Heidenhain", Model: "TNC620", Source: models.DetectionSourceNamespace},
+			},
+		},
+		{
+			name:     "SINUMERIK без ProductName",
+			identity: models.ServerIdentity{Namespaces: []string{SinumerikNamespaceURI}},
+			want: []models.DetectedMachine{
+				{Manufacturer: "Siemens", Model: "840D sl", Source: models.DetectionSourceNamespace},
+				{Manufacturer: "Siemens", Model: "828D", Source: models.DetectionSourceNamespace},
+			},
+		},
+		{
+			name:     "модель из Identification",
+			identity: models.ServerIdentity{ProductName: "SINUMERIK", MachineModel: "828D", Namespaces: []string{SinumerikNamespaceURI}},
+			want:     []models.DetectedMachine{{Manufacturer: "Siemens", Model: "828D", Source: models.DetectionSourceNamespace}},
+		},
+		{
+			name: "MachineTool с производителем из Identification",
+			identity: models.ServerIdentity{ManufacturerName: "Unified Automation", MachineManufacturer: "DMG MORI",
+				Namespaces: []string{MachineToolNamespaceURI}},
+			want: []models.DetectedMachine{{Manufacturer: "DMG MORI", Model: MachineToolModel, Source: models.DetectionSourceMachineTool}},
+		},
+		{
+			name:     "MachineTool с производителем из BuildInfo",
+			identity: models.ServerIdentity{ManufacturerName: "Grob", Namespaces: []string{MachineToolNamespaceURI}},
+			want:     []models.DetectedMachine{{Manufacturer: "Grob", Model: MachineToolModel, Source: models.DetectionSourceMachineTool}},
+		},
+		{
+			name: "ЧПУ производителя и MachineTool",
+			identity: models.ServerIdentity{ProductName: "SINUMERIK 840D sl", ManufacturerName: "Siemens AG",
+				Namespaces: []string{SinumerikNamespaceURI, MachineToolNamespaceURI}},
+			want: []models.DetectedMachine{
+				{Manufacturer: "Siemens", Model: "840D sl", Source: models.DetectionSourceNamespace},
+				{Manufacturer: "Siemens AG", Model: MachineToolModel, Source: models.DetectionSourceMachineTool},
+			},
+		},
+		{
+			name:     "профиль по BuildInfo, более длинная модель первой",
+			identity: models.ServerIdentity{ProductName: "FANUC Series 30i-B", ManufacturerName: "FANUC CORPORATION"},
+			want: []models.DetectedMachine{
+				{Manufacturer: "Fanuc", Model: "30i-B", Source: models.DetectionSourceProfile},
+				{Manufacturer: "Fanuc", Model: "30i", Source: models.DetectionSourceProfile},
+			},
+		},
+		{
+			name:     "профиль не подходит по производителю",
+			identity: models.ServerIdentity{ProductName: "Series 30i-B", ManufacturerName: "Mitsubishi"},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectMachineModels(tt.identity); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("DetectMachineModels() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameName(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"TNC 640", "tnc-640", true},
+		{"840D sl", "840dsl", true},
+		{"DMG_MORI", "dmg mori", true},
+		{"TNC640", "TNC620", false},
+		{"Siemens", "Siemens AG", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := SameName(tt.a, tt.b); got != tt.want {
+				t.Fatalf("SameName(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalManufacturer(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ACME", "Heidenhain"},
+		{"acme", "Heidenhain"},
+		{"Heidenhain", "Heidenhain"},
+		{"Siemens", "Siemens"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalManufacturer(tt.name); got != tt.want {
+				t.Fatalf("CanonicalManufacturer(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
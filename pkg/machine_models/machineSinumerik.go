@@ -0,0 +1,355 @@
+package machine_models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"log"
+	"math"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/opc_custom"
+	"sync"
+)
+
+// SinumerikNamespaceURI - URI пространства имён переменных NCK в OPC UA сервере SINUMERIK Operate
+const SinumerikNamespaceURI = "SinumerikVarProvider"
+
+// Переменные конфигурации SINUMERIK, по которым определяется число осей и шпинделей модели
+var (
+	sinumerikNumMachAxes = SinumerikNode("/Channel/Configuration/numMachAxes")
+	sinumerikNumSpindles = SinumerikNode("/Nck/Configuration/numSpindles")
+)
+
+// sinumerikSerialNumber - машинные данные MD18030 $MN_HW_SERIAL_NUMBER, серийный номер карты CF/NCU.
+// NCK заполняет их при запуске, поэтому они однозначно определяют станок
+var sinumerikSerialNumber = SinumerikNode("$MN_HW_SERIAL_NUMBER")
+
+// sinumerikMaxCount ограничивает число осей и шпинделей из конфигурации: большее значение - ошибка чтения
+const sinumerikMaxCount = 32
+
+// Значения перечислений SINUMERIK
+var (
+	sinumerikOpModes    = map[int64]string{0: "JOG", 1: "MDA", 2: "AUTO"}
+	sinumerikChanStatus = map[int64]string{0: "RESET", 1: "INTERRUPTED", 2: "ACTIVE"}
+	sinumerikProgStatus = map[int64]string{1: "INTERRUPTED", 2: "STOPPED", 3: "RUNNING", 4: "WAITING", 5: "ABORTED"}
+)
+
+// SinumerikNode возвращает переменную NCK по пути, например /Channel/State/progStatus или
+// /Channel/MachineAxis/actToolBasePos[u1,1]
+func SinumerikNode(path string) ua.ExpandedNodeID {
+	return ua.ExpandedNodeID{NamespaceURI: SinumerikNamespaceURI, NodeID: ua.NewNodeIDString(0, path)}
+}
+
+// sinumerikNode - переменная SINUMERIK и запись её значения в модель
+type sinumerikNode struct {
+	nodeID ua.ExpandedNodeID
+	name   string // имя значения в quality
+	set    func(m *SinumerikData, v any) error
+}
+
+// sinumerikNodeList - переменные, которые читает модель SINUMERIK с заданным числом осей и шпинделей.
+// Канал и BAG - первые (u1). Все переменные оси адресуются индексом машинной оси канала, чтобы имя,
+// положение и нагрузка относились к одной оси
+func sinumerikNodeList(axes, spindles int) []sinumerikNode {
+	nodes := []sinumerikNode{
+		{sinumerikSerialNumber, "SerialNumber", func(m *SinumerikData, v any) error {
+			serial := scalarString(v)
+			if serial == "" {
+				return fmt.Errorf("unexpected serial number value %v", v)
+			}
+			m.resp.MachineId = serial
+			return nil
+		}},
+		{SinumerikNode("/Bag/State/opMode[u1]"), "OpMode", sinumerikEnum(sinumerikOpModes, func(m *SinumerikData, s string) {
+			m.resp.ProgramMode = s
+			m.resp.ChannelInfos[0].Mode = s
+		})},
+		{SinumerikNode("/Channel/State/chanStatus"), "ChanStatus", sinumerikEnum(sinumerikChanStatus, func(m *SinumerikData, s string) {
+			m.resp.ChannelInfos[0].State = s
+		})},
+		{SinumerikNode("/Channel/State/progStatus"), "ProgStatus", sinumerikEnum(sinumerikProgStatus, func(m *SinumerikData, s string) {
+			m.resp.MachineState = s
+		})},
+		{SinumerikNode("/Channel/State/feedRateIpoOvr"), "FeedOverride", sinumerikNumber(func(m *SinumerikData, f float64) {
+			m.resp.ChannelInfos[0].FeedOverride = f
+			m.resp.FeedOverride = uint32(math.Round(math.Max(f, 0)))
+		})},
+		{SinumerikNode("/Channel/State/rapFeedRateOvr"), "RapidOverride", sinumerikNumber(func(m *SinumerikData, f float64) {
+			m.resp.ChannelInfos[0].RapidOverride = f
+		})},
+		{SinumerikNode("/Channel/State/actFeedRateIpo"), "FeedRate", sinumerikNumber(func(m *SinumerikData, f float64) {
+			m.resp.FeedRate = f
+		})},
+		{SinumerikNode("/Channel/State/actParts"), "PartsCount", sinumerikNumber(func(m *SinumerikData, f float64) {
+			m.resp.PartsCount = f
+		})},
+		{SinumerikNode("/Channel/ProgramInfo/progName"), "ProgramName", sinumerikString(func(m *SinumerikData, s string) {
+			m.resp.CurrentProgram.ProgramName = s
+		})},
+		{SinumerikNode("/Channel/ProgramInfo/actLineNumber"), "LineNumber", sinumerikNumber(func(m *SinumerikData, f float64) {
+			m.resp.CurrentProgram.ProgramNumber = int(f)
+		})},
+		{SinumerikNode("/Channel/ProgramInfo/actBlock"), "ActBlock", sinumerikString(func(m *SinumerikData, s string) {
+			m.resp.CurrentProgram.GCodeLine = s
+		})},
+		// Тревоги NCK: используются, если сервер не публикует события Alarms & Conditions
+		{SinumerikNode("/Nck/State/numAlarms"), "AlarmCount", sinumerikNumber(func(m *SinumerikData, f float64) {
+			m.resp.HasAlarms = f > 0
+		})},
+		{SinumerikNode("/Nck/TopPrioAlarm/textIndex"), "TopAlarm", sinumerikNumber(func(m *SinumerikData, f float64) {
+			m.resp.AlarmStatus = ""
+			if f > 0 {
+				m.resp.AlarmStatus = fmt.Sprintf("Alarm %d", int64(f))
+			}
+		})},
+		// Счётчики времени: poweronTime в минутах, остальные в секундах
+		{SinumerikNode("/Channel/ChannelDiagnose/poweronTime"), "PowerOnTime", sinumerikDuration(60*1000, func(m *SinumerikData, s string) {
+			m.resp.PowerOnTime = s
+		})},
+		{SinumerikNode("/Channel/ChannelDiagnose/operatingTime"), "OperatingTime", sinumerikDuration(1000, func(m *SinumerikData, s string) {
+			m.resp.OperatingTime = s
+		})},
+		{SinumerikNode("/Channel/ChannelDiagnose/cycleTime"), "CycleTime", sinumerikDuration(1000, func(m *SinumerikData, s string) {
+			m.resp.CycleTime = s
+		})},
+		{SinumerikNode("/Channel/ChannelDiagnose/cuttingTime"), "CuttingTime", sinumerikDuration(1000, func(m *SinumerikData, s string) {
+			m.resp.CuttingTime = s
+		})},
+	}
+
+	for i := 0; i < axes; i++ {
+		axis := i
+		nodes = append(nodes,
+			sinumerikNode{SinumerikNode(fmt.Sprintf("/Channel/MachineAxis/name[u1,%d]", i+1)), fmt.Sprintf("Axis%d.Name", i+1), sinumerikString(func(m *SinumerikData, s string) {
+				m.resp.AxisInfos[axis].Name = s
+			})},
+			sinumerikNode{SinumerikNode(fmt.Sprintf("/Channel/MachineAxis/actToolBasePos[u1,%d]", i+1)), fmt.Sprintf("Axis%d.Position", i+1), sinumerikNumber(func(m *SinumerikData, f float64) {
+				m.resp.AxisInfos[axis].Position = f
+			})},
+			sinumerikNode{SinumerikNode(fmt.Sprintf("/Channel/MachineAxis/aaLoad[u1,%d]", i+1)), fmt.Sprintf("Axis%d.Load", i+1), sinumerikNumber(func(m *SinumerikData, f float64) {
+				m.resp.AxisInfos[axis].LoadPercent = f
+			})},
+		)
+	}
+
+	for i := 0; i < spindles; i++ {
+		spindle := i
+		nodes = append(nodes,
+			sinumerikNode{SinumerikNode(fmt.Sprintf("/Channel/Spindle/actSpeed[u1,%d]", i+1)), fmt.Sprintf("Spindle%d.Speed", i+1), sinumerikNumber(func(m *SinumerikData, f float64) {
+				m.resp.SpindleInfos[spindle].Speed = f
+				m.resp.SpindleInfos[spindle].IsRotating = f != 0
+			})},
+			sinumerikNode{SinumerikNode(fmt.Sprintf("/Channel/Spindle/driveLoad[u1,%d]", i+1)), fmt.Sprintf("Spindle%d.Load", i+1), sinumerikNumber(func(m *SinumerikData, f float64) {
+				m.resp.SpindleInfos[spindle].LoadPercent = f
+			})},
+			sinumerikNode{SinumerikNode(fmt.Sprintf("/Channel/Spindle/speedOvr[u1,%d]", i+1)), fmt.Sprintf("Spindle%d.Override", i+1), sinumerikNumber(func(m *SinumerikData, f float64) {
+				m.resp.SpindleInfos[spindle].Override = f
+			})},
+		)
+	}
+	return nodes
+}
+
+// sinumerikLayout - переменные модели SINUMERIK для конфигурации станка по ключу nsu=..., их NodeID и имена
+// для quality
+type sinumerikLayout struct {
+	nodes   map[string]sinumerikNode
+	nodeIDs []ua.ExpandedNodeID
+	names   map[string]string
+}
+
+// sinumerikLayouts - переменные модели по числу осей и шпинделей: модель создаётся на каждый цикл опроса
+var sinumerikLayouts sync.Map // [2]int -> *sinumerikLayout
+
+// sinumerikLayoutFor возвращает переменные модели SINUMERIK с заданным числом осей и шпинделей
+func sinumerikLayoutFor(axes, spindles int) *sinumerikLayout {
+	key := [2]int{axes, spindles}
+	if layout, ok := sinumerikLayouts.Load(key); ok {
+		return layout.(*sinumerikLayout)
+	}
+	layout := &sinumerikLayout{nodes: make(map[string]sinumerikNode), names: make(map[string]string)}
+	for _, node := range sinumerikNodeList(axes, spindles) {
+		key := node.nodeID.String()
+		layout.nodes[key] = node
+		layout.nodeIDs = append(layout.nodeIDs, node.nodeID)
+		layout.names[key] = node.name
+	}
+	actual, _ := sinumerikLayouts.LoadOrStore(key, layout)
+	return actual.(*sinumerikLayout)
+}
+
+// readSinumerikCount читает число осей или шпинделей из переменной конфигурации
+func readSinumerikCount(b NodeBrowser, node ua.ExpandedNodeID) (int, error) {
+	v, err := b.Value(node)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", node.NodeID, err)
+	}
+	f, ok := toFloat(v)
+	if !ok || f < 0 || f > sinumerikMaxCount {
+		return 0, fmt.Errorf("unexpected %s value %v", node.NodeID, v)
+	}
+	return int(f), nil
+}
+
+// sinumerikNumber записывает числовое значение
+func sinumerikNumber(set func(m *SinumerikData, f float64)) func(m *SinumerikData, v any) error {
+	return func(m *SinumerikData, v any) error {
+		f, ok := toFloat(v)
+		if !ok {
+			return fmt.Errorf("unexpected type %T, expected number", v)
+		}
+		set(m, f)
+		return nil
+	}
+}
+
+// sinumerikString записывает строковое значение
+func sinumerikString(set func(m *SinumerikData, s string)) func(m *SinumerikData, v any) error {
+	return func(m *SinumerikData, v any) error {
+		set(m, scalarString(v))
+		return nil
+	}
+}
+
+// sinumerikEnum записывает имя значения перечисления, неизвестное значение - числом
+func sinumerikEnum(names map[int64]string, set func(m *SinumerikData, s string)) func(m *SinumerikData, v any) error {
+	return func(m *SinumerikData, v any) error {
+		f, ok := toFloat(v)
+		if !ok {
+			return fmt.Errorf("unexpected type %T, expected number", v)
+		}
+		name, ok := names[int64(f)]
+		if !ok {
+			name = fmt.Sprint(v)
+		}
+		set(m, name)
+		return nil
+	}
+}
+
+// sinumerikDuration записывает счётчик времени в формате ЧЧ:ММ:СС, unitMs - миллисекунд в единице счётчика
+func sinumerikDuration(unitMs float64, set func(m *SinumerikData, s string)) func(m *SinumerikData, v any) error {
+	return sinumerikNumber(func(m *SinumerikData, f float64) {
+		ms := f * unitMs
+		set(m, formatTime(&ms))
+	})
+}
+
+// SinumerikData - модель ЧПУ Siemens SINUMERIK 840D sl и 828D (SINUMERIK Operate с опцией OPC UA).
+// Переменные NCK адресуются строковыми NodeID в пространстве имён SinumerikVarProvider. Число осей и шпинделей
+// читается из конфигурации NCK при обходе сервера
+type SinumerikData struct {
+	nodes  *DiscoveredNodes
+	layout *sinumerikLayout
+	resp   models.MachineDataResponse
+
+	SampleQuality
+}
+
+// NewSinumerikData создаёт модель SINUMERIK без прочитанной конфигурации станка
+func NewSinumerikData() *SinumerikData {
+	return &SinumerikData{}
+}
+
+// DiscoverNodes читает число машинных осей первого канала и шпинделей NCK
+func (m *SinumerikData) DiscoverNodes(b NodeBrowser) (*DiscoveredNodes, error) {
+	axes, err := readSinumerikCount(b, sinumerikNumMachAxes)
+	if err != nil {
+		return nil, err
+	}
+	spindles, err := readSinumerikCount(b, sinumerikNumSpindles)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := sinumerikLayoutFor(axes, spindles)
+	d := &DiscoveredNodes{
+		Root:     SinumerikNamespaceURI,
+		nodeIDs:  layout.nodeIDs,
+		names:    layout.names,
+		channels: []string{"CHAN1"},
+		axes:     axes,
+		spindles: make([]string, spindles),
+	}
+	for i := range d.spindles {
+		d.spindles[i] = fmt.Sprintf("S%d", i+1)
+	}
+	return d, nil
+}
+
+func (m *SinumerikData) UseDiscoveredNodes(nodes *DiscoveredNodes) {
+	if m.nodes == nodes {
+		return
+	}
+	m.nodes = nodes
+	m.layout = sinumerikLayoutFor(nodes.axes, len(nodes.spindles))
+	m.resp = models.MachineDataResponse{
+		AxisInfos:    make([]models.AxisInfosResponse, nodes.axes),
+		SpindleInfos: make([]models.SpindleInfosResponse, len(nodes.spindles)),
+		ChannelInfos: make([]models.ChannelInfosResponse, len(nodes.channels)),
+	}
+	for i, name := range nodes.spindles {
+		m.resp.SpindleInfos[i].Name = name
+	}
+	for i, name := range nodes.channels {
+		m.resp.ChannelInfos[i].Name = name
+	}
+}
+
+func (m *SinumerikData) GetRelevantNodeIDs() []ua.ExpandedNodeID {
+	if m.layout == nil {
+		return nil
+	}
+	return m.layout.nodeIDs
+}
+
+func (m *SinumerikData) ConvertNodeToMachineData(nodeID string, v any) error {
+	if m.layout == nil {
+		return errors.New("SINUMERIK configuration is not read")
+	}
+	node, ok := m.layout.nodes[nodeID]
+	if !ok {
+		return fmt.Errorf("unsupported NodeID: %s", nodeID)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := node.set(m, v); err != nil {
+		return fmt.Errorf("%s: %w", node.name, err)
+	}
+	return nil
+}
+
+func (m *SinumerikData) GetExecutionStack() ([]opc_custom.ProgramPositionDataType, error) {
+	return nil, errors.New("execution stack is not supported by SINUMERIK model")
+}
+
+func (m *SinumerikData) GetMachineID() (*string, error) {
+	if m.resp.MachineId == "" {
+		return nil, nil
+	}
+	id := m.resp.MachineId
+	return &id, nil
+}
+
+func (m *SinumerikData) ToResponse() models.MachineDataResponse {
+	resp := m.resp
+	resp.Timestamp = m.SampleTimestamp()
+	resp.AxisInfos = append([]models.AxisInfosResponse(nil), m.resp.AxisInfos...)
+	resp.SpindleInfos = append([]models.SpindleInfosResponse(nil), m.resp.SpindleInfos...)
+	resp.ChannelInfos = append([]models.ChannelInfosResponse(nil), m.resp.ChannelInfos...)
+	if m.layout != nil {
+		resp.Quality = m.QualityByName(m.layout.names)
+	}
+	return resp
+}
+
+func (m *SinumerikData) ToJSON() string {
+	bytes, err := json.MarshalIndent(m.ToResponse(), "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal SinumerikData to JSON: %v", err)
+		return ""
+	}
+	return string(bytes)
+}
@@ -0,0 +1,109 @@
+package machine_models
+
+import (
+	"errors"
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"strings"
+	"testing"
+)
+
+// sinumerikBrowser отдаёт значения переменных конфигурации NCK
+type sinumerikBrowser map[string]any
+
+func (b sinumerikBrowser) Children(ua.ExpandedNodeID) ([]BrowsedNode, error) { return nil, nil }
+
+func (b sinumerikBrowser) EnumNames(ua.ExpandedNodeID) (map[int64]string, error) { return nil, nil }
+
+func (b sinumerikBrowser) Value(variable ua.ExpandedNodeID) (any, error) {
+	v, ok := b[variable.String()]
+	if !ok {
+		return nil, errors.New("BadNodeIdUnknown")
+	}
+	return v, nil
+}
+
+func newSinumerik(t *testing.T, axes, spindles int32) *SinumerikData {
+	t.Helper()
+	m := NewSinumerikData()
+	nodes, err := m.DiscoverNodes(sinumerikBrowser{
+		sinumerikNumMachAxes.String(): axes,
+		sinumerikNumSpindles.String(): spindles,
+	})
+	if err != nil {
+		t.Fatalf("DiscoverNodes() error = %v", err)
+	}
+	m.UseDiscoveredNodes(nodes)
+	return m
+}
+
+func TestSinumerikAxisNodes(t *testing.T) {
+	m := newSinumerik(t, 3, 1)
+
+	// Имя, положение и нагрузка оси читаются по одному индексу машинной оси
+	for axis := 1; axis <= 3; axis++ {
+		for _, variable := range []string{"name", "actToolBasePos", "aaLoad"} {
+			key := SinumerikNode(fmt.Sprintf("/Channel/MachineAxis/%s[u1,%d]", variable, axis)).String()
+			if _, ok := m.layout.nodes[key]; !ok {
+				t.Fatalf("нет переменной %s", key)
+			}
+		}
+	}
+	for _, nodeID := range m.GetRelevantNodeIDs() {
+		if strings.Contains(nodeID.String(), "/GeometricAxis/") {
+			t.Fatalf("переменная %s вне индексов машинных осей", nodeID)
+		}
+	}
+
+	values := map[string]any{"name": "X1", "actToolBasePos": 12.5, "aaLoad": 40.0}
+	for variable, v := range values {
+		if err := m.ConvertNodeToMachineData(SinumerikNode(fmt.Sprintf("/Channel/MachineAxis/%s[u1,2]", variable)).String(), v); err != nil {
+			t.Fatalf("ConvertNodeToMachineData(%s) error = %v", variable, err)
+		}
+	}
+	if got := m.resp.AxisInfos[1]; got.Name != "X1" || got.Position != 12.5 || got.LoadPercent != 40 {
+		t.Fatalf("ось 2 = %+v", got)
+	}
+}
+
+func TestSinumerikMachineID(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    string
+		wantErr bool
+	}{
+		{name: "серийный номер NCU", value: "T-M92018234", want: "T-M92018234"},
+		{name: "пустое значение", value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newSinumerik(t, 1, 1)
+			err := m.ConvertNodeToMachineData(sinumerikSerialNumber.String(), tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertNodeToMachineData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			id, _ := m.GetMachineID()
+			if tt.wantErr {
+				if id != nil {
+					t.Fatalf("GetMachineID() = %s, ожидался nil", *id)
+				}
+				return
+			}
+			if id == nil || *id != tt.want {
+				t.Fatalf("GetMachineID() = %v, ожидалось %s", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestSinumerikDiscoverNodesInvalidCount(t *testing.T) {
+	m := NewSinumerikData()
+	_, err := m.DiscoverNodes(sinumerikBrowser{
+		sinumerikNumMachAxes.String(): int32(sinumerikMaxCount + 1),
+		sinumerikNumSpindles.String(): int32(1),
+	})
+	if err == nil {
+		t.Fatal("ожидалась ошибка для числа осей больше sinumerikMaxCount")
+	}
+}
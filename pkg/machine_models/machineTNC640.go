@@ -108,11 +108,60 @@ type HeidenhainTNC640Data struct {
 	Machine        MachineData                           `json:"machine_data"`
 	ExecutionStack *[]opc_custom.ProgramPositionDataType `json:"execution_stack"`
 	Timestamp      time.Time                             `json:"timestamp"`
+
+	SampleQuality `json:"-"`
+}
+
+// HeidenhainNamespaceURI - URI пространства имён узлов ЧПУ Heidenhain. Индекс пространства имён на конкретном
+// сервере определяется по его NamespaceArray
+const HeidenhainNamespaceURI = "http://heidenhain.de/NC/"
+
+// tnc640NS - префикс идентификаторов узлов Heidenhain в форме ExpandedNodeID.String()
+const tnc640NS = "nsu=" + HeidenhainNamespaceURI + ";"
+
+// HeidenhainNode возвращает узел Heidenhain с числовым идентификатором в пространстве имён HeidenhainNamespaceURI
+func HeidenhainNode(id uint32) ua.ExpandedNodeID {
+	return ua.ExpandedNodeID{NamespaceURI: HeidenhainNamespaceURI, NodeID: ua.NewNodeIDNumeric(0, id)}
+}
+
+// tnc640NodeNames - имена значений Heidenhain TNC640 для поля quality в payload
+var tnc640NodeNames = map[string]string{
+	tnc640NS + "i=56004":  "SerialNumber",
+	tnc640NS + "i=100024": "OperatingMode",
+	tnc640NS + "i=100039": "CurrentToolName",
+	tnc640NS + "i=100003": "CutterLocation",
+	tnc640NS + "i=100025": "FeedOverride",
+	tnc640NS + "i=100026": "FeedOverrideEURange",
+	tnc640NS + "i=300002": "FeedOverrideEngineeringUnits",
+	tnc640NS + "i=100029": "RapidOverride",
+	tnc640NS + "i=100030": "RapidOverrideEURange",
+	tnc640NS + "i=300004": "RapidOverrideEngineeringUnits",
+	tnc640NS + "i=100031": "RapidTraverseActive",
+	tnc640NS + "i=100027": "SpeedOverride",
+	tnc640NS + "i=100028": "SpeedOverrideEURange",
+	tnc640NS + "i=300003": "SpeedOverrideEngineeringUnits",
+	tnc640NS + "i=56031":  "ControlUpTime",
+	tnc640NS + "i=56033":  "MachineUpTime",
+	tnc640NS + "i=56032":  "ProgramExecutionTime",
+	tnc640NS + "i=51002":  "CurrentState",
+	tnc640NS + "i=100005": "CurrentCall",
+	tnc640NS + "i=100006": "ExecutionStack",
+	tnc640NS + "i=100022": "ActiveProgramName",
+	tnc640NS + "i=100010": "ExecutionStateCurrentState",
+	tnc640NS + "i=100008": "ExecutionStateLastTransition",
 }
 
 func (m *HeidenhainTNC640Data) GetExecutionStack() ([]opc_custom.ProgramPositionDataType, error) {
+	if m.ExecutionStack == nil {
+		return nil, nil
+	}
 	return *m.ExecutionStack, nil
 }
+
+// ExecutionStackNode возвращает узел стека выполнения программы
+func (m *HeidenhainTNC640Data) ExecutionStackNode() ua.ExpandedNodeID {
+	return HeidenhainNode(100006)
+}
 func (m *HeidenhainTNC640Data) GetMachineID() (*string, error) {
 	return m.Machine.SerialNumber, nil
 }
@@ -120,24 +169,24 @@ func (m *HeidenhainTNC640Data) GetMachineID() (*string, error) {
 func (m *HeidenhainTNC640Data) ConvertNodeToMachineData(nodeID string, v any) error {
 	//ua.ObjectIDReadRequestEncodingDefaultXML
 	switch nodeID {
-	case "ns=1;i=56004": // SerialNumber
+	case tnc640NS + "i=56004": // SerialNumber
 		if val, ok := v.(string); ok {
 			m.Machine.SerialNumber = &val
 			return nil
 		}
 	// -------------------------- SPEED OVERRIDE --------------------------
-	case "ns=1;i=100027": // SpeedOverride
+	case tnc640NS + "i=100027": // SpeedOverride
 		if val, ok := v.(uint32); ok {
 			m.Machine.SpeedOverride.Value = val
 			return nil
 		}
-	case "ns=1;i=100028": // SpeedOverrideEURange
+	case tnc640NS + "i=100028": // SpeedOverrideEURange
 		if r, ok := v.(ua.Range); ok {
 			m.Machine.SpeedOverride.EURange = []float64{r.Low, r.High}
 			return nil
 		}
 		return fmt.Errorf("unexpected type for SpeedOverrideEURange: %T", v)
-	case "ns=1;i=300003": // SpeedOverrideEngineeringUnits
+	case tnc640NS + "i=300003": // SpeedOverrideEngineeringUnits
 		if v != nil {
 			if r, ok := v.(ua.EUInformation); ok {
 				m.Machine.SpeedOverride.EngineeringUnits = r
@@ -147,7 +196,7 @@ func (m *HeidenhainTNC640Data) ConvertNodeToMachineData(nodeID string, v any) er
 		}
 		return nil
 	// -------------------------- CUTTER --------------------------
-	case "ns=1;i=100039": // CurrentToolName
+	case tnc640NS + "i=100039": // CurrentToolName
 		if v != nil {
 			if val, ok := v.(string); ok {
 				m.CurrentTool.Name = val
@@ -156,7 +205,7 @@ func (m *HeidenhainTNC640Data) ConvertNodeToMachineData(nodeID string, v any) er
 			return fmt.Errorf("unexpected type for CurrentToolName: %T", v)
 		}
 		return nil
-	case "ns=1;i=100003": // CutterLocation
+	case tnc640NS + "i=100003": // CutterLocation
 		if v != nil {
 			eoSlice, ok := v.([]ua.ExtensionObject)
 			if !ok {
@@ -180,83 +229,83 @@ func (m *HeidenhainTNC640Data) ConvertNodeToMachineData(nodeID string, v any) er
 		}
 		return nil
 	// -------------------------- FEED OVERRIDE --------------------------
-	case "ns=1;i=100025": // FeedOverride
+	case tnc640NS + "i=100025": // FeedOverride
 		if val, ok := v.(uint32); ok {
 			m.Machine.FeedOverride.Value = val
 			return nil
 		}
-	case "ns=1;i=100026": // FeedOverrideEURange
+	case tnc640NS + "i=100026": // FeedOverrideEURange
 		if r, ok := v.(ua.Range); ok {
 			m.Machine.FeedOverride.EURange = []float64{r.Low, r.High}
 			return nil
 		}
 		return fmt.Errorf("unexpected type for FeedOverrideEURange: %T", v)
-	case "ns=1;i=300002": // FeedOverrideEngineeringUnits
+	case tnc640NS + "i=300002": // FeedOverrideEngineeringUnits
 		if r, ok := v.(ua.EUInformation); ok {
 			m.Machine.FeedOverride.EngineeringUnits = r
 			return nil
 		}
 		return fmt.Errorf("unexpected type for FeedOverrideEngineeringUnits: %T", v)
 
-	case "ns=1;i=100024": // OperatingMode
+	case tnc640NS + "i=100024": // OperatingMode
 		if val, ok := v.(int32); ok {
 			m.Machine.OperatingMode = &val
 			return nil
 		}
 
 	// -------------------------- RAPID --------------------------
-	case "ns=1;i=100029": // RapidOverrideValue
+	case tnc640NS + "i=100029": // RapidOverrideValue
 		if val, ok := v.(uint32); ok {
 			m.Machine.RapidOverride.Value = val
 			return nil
 		}
-	case "ns=1;i=100030": // RapidOverrideEURange
+	case tnc640NS + "i=100030": // RapidOverrideEURange
 		if r, ok := v.(ua.Range); ok {
 			m.Machine.RapidOverride.EURange = []float64{r.Low, r.High}
 			return nil
 		}
 		return fmt.Errorf("unexpected type for RapidOverrideEURange: %T", v)
-	case "ns=1;i=300004": // RapidOverrideEngineeringUnits
+	case tnc640NS + "i=300004": // RapidOverrideEngineeringUnits
 		if r, ok := v.(ua.EUInformation); ok {
 			m.Machine.RapidOverride.EngineeringUnits = r
 			return nil
 		}
 		return fmt.Errorf("unexpected type for RapidOverrideEngineeringUnits: %T", v)
-	case "ns=1;i=100031": // RapidTraverseActive
+	case tnc640NS + "i=100031": // RapidTraverseActive
 		if val, ok := v.(bool); ok {
 			m.Machine.RapidTraverseActive = &val
 			return nil
 		}
 
-	case "ns=1;i=56031": // ControlUpTime
+	case tnc640NS + "i=56031": // ControlUpTime
 		if val, ok := v.(float64); ok {
 			m.Machine.ControlUpTime = &val
 			return nil
 		}
-	case "ns=1;i=56033": // MachineUpTime
+	case tnc640NS + "i=56033": // MachineUpTime
 		if val, ok := v.(float64); ok {
 			m.Machine.MachineUpTime = &val
 			return nil
 		}
-	case "ns=1;i=56032": // ProgramExecutionTime
+	case tnc640NS + "i=56032": // ProgramExecutionTime
 		if val, ok := v.(float64); ok {
 			m.Machine.ProgramExecutionTime = &val
 			return nil
 		}
 
 	// -------------------------- STATE --------------------------
-	case "ns=1;i=51002": // StateCurrentState
+	case tnc640NS + "i=51002": // StateCurrentState
 		if val, ok := v.(ua.LocalizedText); ok {
 			m.Machine.ExecutionState.CurrentState = val
 			return nil
 		}
 
-	case "ns=1;i=100005": // CurrentCall
+	case tnc640NS + "i=100005": // CurrentCall
 		if val, ok := v.(string); ok {
 			m.Machine.CurrentCall = &val
 			return nil
 		}
-	case "ns=1;i=100006": // ExecutionStack
+	case tnc640NS + "i=100006": // ExecutionStack
 		if v != nil {
 			eoSlice, ok := v.([]ua.ExtensionObject)
 			if !ok {
@@ -279,20 +328,20 @@ func (m *HeidenhainTNC640Data) ConvertNodeToMachineData(nodeID string, v any) er
 			m.ExecutionStack = &result
 		}
 		return nil
-	case "ns=1;i=100022": // ActiveProgramName
+	case tnc640NS + "i=100022": // ActiveProgramName
 		if val, ok := v.(string); ok {
 			m.Machine.ActiveProgramName = &val
 			return nil
 		}
 
 	// -------------------------- EXECUTION STATE --------------------------
-	case "ns=1;i=100010": // ExecutionStateCurrentState
+	case tnc640NS + "i=100010": // ExecutionStateCurrentState
 		if val, ok := v.(ua.LocalizedText); ok {
 			m.Machine.ExecutionState.CurrentState = val
 			return nil
 		}
 
-	case "ns=1;i=100008": // ExecutionStateLastTransition
+	case tnc640NS + "i=100008": // ExecutionStateLastTransition
 		if v != nil {
 			if val, ok := v.(ua.LocalizedText); ok {
 				m.Machine.ExecutionState.LastTransition = val
@@ -311,7 +360,7 @@ func (m *HeidenhainTNC640Data) ConvertNodeToMachineData(nodeID string, v any) er
 func (m *HeidenhainTNC640Data) ToResponse() models.MachineDataResponse {
 	resp := models.MachineDataResponse{
 		MachineId: getStringOrDefault(m.Machine.SerialNumber, ""),
-		Timestamp: 0,
+		Timestamp: m.SampleTimestamp(),
 		//IsEnabled:          false,
 		//IsEmergency:        false,
 		MachineState: m.Machine.ExecutionState.LastTransition.String(),
@@ -355,6 +404,7 @@ func (m *HeidenhainTNC640Data) ToResponse() models.MachineDataResponse {
 		}
 	}
 	resp.AxisInfos = axisInfos
+	resp.Quality = m.QualityByName(tnc640NodeNames)
 	return resp
 }
 
@@ -367,40 +417,40 @@ func (m *HeidenhainTNC640Data) ToJSON() string {
 	return string(bytes)
 }
 
-func (ci *HeidenhainTNC640Data) GetRelevantNodeIDs() []ua.NodeIDNumeric {
-	// Здесь перечисляем NodeID, которые нам нужны для Heidenhain TNC640
-	return []ua.NodeIDNumeric{
-		ua.NewNodeIDNumeric(1, 56004), // SerialNumber
+func (ci *HeidenhainTNC640Data) GetRelevantNodeIDs() []ua.ExpandedNodeID {
+	// Здесь перечисляем узлы, которые нам нужны для Heidenhain TNC640
+	return []ua.ExpandedNodeID{
+		HeidenhainNode(56004), // SerialNumber
 
-		ua.NewNodeIDNumeric(1, 100024), // OperatingMode
+		HeidenhainNode(100024), // OperatingMode
 		// ------------------------ TOOL ------------------------
-		ua.NewNodeIDNumeric(1, 100039), // CurrentToolName
-		ua.NewNodeIDNumeric(1, 100003), // CutterLocation
+		HeidenhainNode(100039), // CurrentToolName
+		HeidenhainNode(100003), // CutterLocation
 		// ------------------------ FEED ------------------------
-		ua.NewNodeIDNumeric(1, 100025), // FeedOverride
-		ua.NewNodeIDNumeric(1, 100026), // FeedOverrideEURange
-		ua.NewNodeIDNumeric(1, 300002), // FeedOverrideEngineeringUnits
+		HeidenhainNode(100025), // FeedOverride
+		HeidenhainNode(100026), // FeedOverrideEURange
+		HeidenhainNode(300002), // FeedOverrideEngineeringUnits
 		// ------------------------ RAPID ------------------------
-		ua.NewNodeIDNumeric(1, 100029), // RapidOverride
-		ua.NewNodeIDNumeric(1, 100030), // RapidOverrideEURange
-		ua.NewNodeIDNumeric(1, 300004), // RapidOverrideEngineeringUnits
-		ua.NewNodeIDNumeric(1, 100031), // RapidTraverseActive
+		HeidenhainNode(100029), // RapidOverride
+		HeidenhainNode(100030), // RapidOverrideEURange
+		HeidenhainNode(300004), // RapidOverrideEngineeringUnits
+		HeidenhainNode(100031), // RapidTraverseActive
 		// ------------------------ SPEED ------------------------
-		ua.NewNodeIDNumeric(1, 100027), // SpeedOverride
-		ua.NewNodeIDNumeric(1, 100028), // SpeedOverrideEURange
-		ua.NewNodeIDNumeric(1, 300003), // SpeedOverrideEngineeringUnits
+		HeidenhainNode(100027), // SpeedOverride
+		HeidenhainNode(100028), // SpeedOverrideEURange
+		HeidenhainNode(300003), // SpeedOverrideEngineeringUnits
 		// ------------------------ TIME ------------------------
-		ua.NewNodeIDNumeric(1, 56031), // ControlUpTime
-		ua.NewNodeIDNumeric(1, 56033), // MachineUpTime
-		ua.NewNodeIDNumeric(1, 56032), // ProgramExecutionTime
+		HeidenhainNode(56031), // ControlUpTime
+		HeidenhainNode(56033), // MachineUpTime
+		HeidenhainNode(56032), // ProgramExecutionTime
 		// ---------------  PROGRAM  -------------------
-		ua.NewNodeIDNumeric(1, 51002),  // CurrentState
-		ua.NewNodeIDNumeric(1, 100005), // CurrentCall
-		ua.NewNodeIDNumeric(1, 100006), // ExecutionStack
-		ua.NewNodeIDNumeric(1, 100022), // ActiveProgramName
+		HeidenhainNode(51002),  // CurrentState
+		HeidenhainNode(100005), // CurrentCall
+		HeidenhainNode(100006), // ExecutionStack
+		HeidenhainNode(100022), // ActiveProgramName
 		// ----------------- EXECUTION STATE -------------------------
-		ua.NewNodeIDNumeric(1, 100010), // ExecutionStateCurrentState
-		ua.NewNodeIDNumeric(1, 100008), // ExecutionStateLastTransition
+		HeidenhainNode(100010), // ExecutionStateCurrentState
+		HeidenhainNode(100008), // ExecutionStateLastTransition
 	}
 }
 
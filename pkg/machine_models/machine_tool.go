@@ -0,0 +1,471 @@
+package machine_models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"log"
+	"math"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/opc_custom"
+)
+
+// Пространства имён OPC UA for Machinery (OPC 40001-1) и OPC UA for Machine Tools (OPC 40501-1)
+const (
+	MachineryNamespaceURI   = "http://opcfoundation.org/UA/Machinery/"
+	MachineToolNamespaceURI = "http://opcfoundation.org/UA/MachineTool/"
+)
+
+// MachineToolModel - модель станка, реализующего OPC 40501. Данные собираются по стандартной структуре
+// экземпляра MachineTool, поэтому модель подходит для сервера любого производителя
+const MachineToolModel = "OPC40501"
+
+// maxMachineToolJobs - сколько заданий ProductionPlan читается для счётчика деталей
+const maxMachineToolJobs = 50
+
+// MachinesFolder - папка Machines, в которой сервер публикует экземпляры машин (OPC 40001-1)
+var MachinesFolder = ua.ExpandedNodeID{NamespaceURI: MachineryNamespaceURI, NodeID: ua.NewNodeIDNumeric(0, 1001)}
+
+// NodeBrowser - доступ к адресному пространству сервера для моделей, узлы которых находятся во время работы
+type NodeBrowser interface {
+	// Children возвращает узлы, на которые ссылаются иерархические ссылки узла
+	Children(node ua.ExpandedNodeID) ([]BrowsedNode, error)
+	// EnumNames возвращает имена значений перечисления - типа данных переменной
+	EnumNames(variable ua.ExpandedNodeID) (map[int64]string, error)
+	// Value читает значение переменной
+	Value(variable ua.ExpandedNodeID) (any, error)
+}
+
+// BrowsedNode - дочерний узел, найденный обходом
+type BrowsedNode struct {
+	NodeID     ua.ExpandedNodeID // nsu=<URI>;<идентификатор>, узлы пространства имён 0 без URI
+	BrowseName string            // имя без индекса пространства имён
+	NodeClass  ua.NodeClass
+}
+
+// machineToolRole - назначение узла экземпляра MachineTool
+type machineToolRole int
+
+const (
+	roleSerialNumber machineToolRole = iota
+	roleOperationMode
+	rolePowerOnDuration
+	roleChannelState
+	roleChannelMode
+	roleChannelFeedOverride
+	roleChannelRapidOverride
+	roleSpindleIsRotating
+	roleSpindleOverride
+	roleSpindleIsUsedAsAxis
+	roleProgramName
+	roleProgramNumber
+	roleProgramState
+	roleActiveJob
+	roleJobIdentifier
+	roleJobRunsCompleted
+)
+
+// machineToolNode - назначение узла и индекс канала, шпинделя или задания
+type machineToolNode struct {
+	role  machineToolRole
+	index int
+}
+
+// DiscoveredNodes - узлы модели станка, найденные обходом адресного пространства сервера
+type DiscoveredNodes struct {
+	Root string // экземпляр машины, от которого найдены узлы
+
+	nodeIDs  []ua.ExpandedNodeID
+	roles    map[string][]machineToolNode // ключ узла nsu=... -> назначения
+	names    map[string]string            // ключ узла -> имя значения в quality
+	enums    map[string]map[int64]string  // ключ узла -> имена значений перечисления
+	channels []string
+	spindles []string
+	axes     int // число осей модели SINUMERIK
+	jobs     int
+}
+
+// Len возвращает число найденных узлов
+func (d *DiscoveredNodes) Len() int {
+	return len(d.nodeIDs)
+}
+
+// add добавляет переменную name из children, если она есть у узла
+func (d *DiscoveredNodes) add(children map[string]BrowsedNode, name, qualityName string, role machineToolRole, index int) (BrowsedNode, bool) {
+	node, ok := children[name]
+	if !ok || node.NodeClass != ua.NodeClassVariable {
+		return BrowsedNode{}, false
+	}
+	key := node.NodeID.String()
+	if _, exists := d.roles[key]; !exists {
+		d.nodeIDs = append(d.nodeIDs, node.NodeID)
+		d.names[key] = qualityName
+	}
+	d.roles[key] = append(d.roles[key], machineToolNode{role: role, index: index})
+	return node, true
+}
+
+// addEnum добавляет переменную-перечисление и запоминает имена её значений. Без имён значение отдаётся числом
+func (d *DiscoveredNodes) addEnum(b NodeBrowser, children map[string]BrowsedNode, name, qualityName string, role machineToolRole, index int) {
+	node, ok := d.add(children, name, qualityName, role, index)
+	if !ok {
+		return
+	}
+	if names, err := b.EnumNames(node.NodeID); err == nil && len(names) > 0 {
+		d.enums[node.NodeID.String()] = names
+	}
+}
+
+// DiscoverMachineTool находит экземпляр MachineTool в папке Machines и его переменные: Identification,
+// Monitoring (станок, каналы, шпиндели) и Production (активная программа и задания). Экземпляр определяется
+// по составу компонентов, а каналы и шпиндели - по переменным, поэтому подтипы производителей тоже подходят
+func DiscoverMachineTool(b NodeBrowser) (*DiscoveredNodes, error) {
+	machine, components, err := findMachineTool(b)
+	if err != nil {
+		return nil, err
+	}
+	return discoverMachineToolNodes(b, machine, components)
+}
+
+// FindMachineToolIdentification возвращает переменные Identification экземпляра MachineTool по имени:
+// Manufacturer, Model, SerialNumber, SoftwareRevision и другие, которые публикует сервер
+func FindMachineToolIdentification(b NodeBrowser) (map[string]BrowsedNode, error) {
+	_, components, err := findMachineTool(b)
+	if err != nil {
+		return nil, err
+	}
+	return childrenByName(b, components["Identification"].NodeID)
+}
+
+// findMachineTool находит в папке Machines первый экземпляр MachineTool и возвращает его компоненты по имени
+func findMachineTool(b NodeBrowser) (BrowsedNode, map[string]BrowsedNode, error) {
+	machines, err := b.Children(MachinesFolder)
+	if err != nil {
+		return BrowsedNode{}, nil, fmt.Errorf("browse Machines folder: %w", err)
+	}
+
+	for _, machine := range machines {
+		if machine.NodeClass != ua.NodeClassObject {
+			continue
+		}
+		components, err := childrenByName(b, machine.NodeID)
+		if err != nil {
+			return BrowsedNode{}, nil, err
+		}
+		if !hasChild(components, "Identification") || !hasChild(components, "Monitoring") {
+			continue
+		}
+		return machine, components, nil
+	}
+	return BrowsedNode{}, nil, errors.New("no MachineTool instance in Machines folder")
+}
+
+// discoverMachineToolNodes собирает переменные найденного экземпляра MachineTool
+func discoverMachineToolNodes(b NodeBrowser, machine BrowsedNode, components map[string]BrowsedNode) (*DiscoveredNodes, error) {
+	d := &DiscoveredNodes{
+		Root:  machine.NodeID.String(),
+		roles: make(map[string][]machineToolNode),
+		names: make(map[string]string),
+		enums: make(map[string]map[int64]string),
+	}
+
+	identification, err := childrenByName(b, components["Identification"].NodeID)
+	if err != nil {
+		return nil, err
+	}
+	d.add(identification, "SerialNumber", "SerialNumber", roleSerialNumber, 0)
+
+	monitoring, err := b.Children(components["Monitoring"].NodeID)
+	if err != nil {
+		return nil, fmt.Errorf("browse Monitoring: %w", err)
+	}
+	for _, element := range monitoring {
+		if element.NodeClass != ua.NodeClassObject {
+			continue
+		}
+		vars, err := childrenByName(b, element.NodeID)
+		if err != nil {
+			return nil, err
+		}
+		name := element.BrowseName
+		switch {
+		case hasChild(vars, "OperationMode"):
+			d.addEnum(b, vars, "OperationMode", "OperationMode", roleOperationMode, 0)
+			d.add(vars, "PowerOnDuration", "PowerOnDuration", rolePowerOnDuration, 0)
+		case hasChild(vars, "ChannelState"):
+			i := len(d.channels)
+			d.channels = append(d.channels, name)
+			d.addEnum(b, vars, "ChannelState", name+".ChannelState", roleChannelState, i)
+			d.addEnum(b, vars, "ChannelMode", name+".ChannelMode", roleChannelMode, i)
+			d.addEnum(b, vars, "ChannelModus", name+".ChannelModus", roleChannelMode, i) // имя переменной в OPC 40501-1 v1.00
+			d.add(vars, "FeedOverride", name+".FeedOverride", roleChannelFeedOverride, i)
+			d.add(vars, "RapidOverride", name+".RapidOverride", roleChannelRapidOverride, i)
+		case hasChild(vars, "IsRotating"):
+			i := len(d.spindles)
+			d.spindles = append(d.spindles, name)
+			d.add(vars, "IsRotating", name+".IsRotating", roleSpindleIsRotating, i)
+			d.add(vars, "Override", name+".Override", roleSpindleOverride, i)
+			d.add(vars, "IsUsedAsAxis", name+".IsUsedAsAxis", roleSpindleIsUsedAsAxis, i)
+		}
+	}
+
+	if production, ok := components["Production"]; ok {
+		if err := d.discoverProduction(b, production); err != nil {
+			return nil, err
+		}
+	}
+
+	if d.Len() == 0 {
+		return nil, fmt.Errorf("MachineTool %s has no known variables", machine.BrowseName)
+	}
+	return d, nil
+}
+
+// discoverProduction собирает переменные активной программы и заданий ProductionPlan
+func (d *DiscoveredNodes) discoverProduction(b NodeBrowser, production BrowsedNode) error {
+	components, err := childrenByName(b, production.NodeID)
+	if err != nil {
+		return err
+	}
+
+	if program, ok := components["ActiveProgram"]; ok {
+		vars, err := childrenByName(b, program.NodeID)
+		if err != nil {
+			return err
+		}
+		d.add(vars, "Name", "ActiveProgram.Name", roleProgramName, 0)
+		d.add(vars, "NumberInList", "ActiveProgram.NumberInList", roleProgramNumber, 0)
+		d.add(vars, "JobIdentifier", "ActiveProgram.JobIdentifier", roleActiveJob, 0)
+		if state, ok := vars["State"]; ok {
+			stateVars, err := childrenByName(b, state.NodeID)
+			if err != nil {
+				return err
+			}
+			d.add(stateVars, "CurrentState", "ActiveProgram.State", roleProgramState, 0)
+		}
+	}
+
+	if plan, ok := components["ProductionPlan"]; ok {
+		jobs, err := b.Children(plan.NodeID)
+		if err != nil {
+			return fmt.Errorf("browse ProductionPlan: %w", err)
+		}
+		for _, job := range jobs {
+			if job.NodeClass != ua.NodeClassObject || d.jobs >= maxMachineToolJobs {
+				continue
+			}
+			vars, err := childrenByName(b, job.NodeID)
+			if err != nil {
+				return err
+			}
+			if !hasChild(vars, "Identifier") || !hasChild(vars, "RunsCompleted") {
+				continue
+			}
+			d.add(vars, "Identifier", "ProductionPlan."+job.BrowseName+".Identifier", roleJobIdentifier, d.jobs)
+			d.add(vars, "RunsCompleted", "ProductionPlan."+job.BrowseName+".RunsCompleted", roleJobRunsCompleted, d.jobs)
+			d.jobs++
+		}
+	}
+	return nil
+}
+
+// childrenByName возвращает дочерние узлы по имени BrowseName
+func childrenByName(b NodeBrowser, node ua.ExpandedNodeID) (map[string]BrowsedNode, error) {
+	children, err := b.Children(node)
+	if err != nil {
+		return nil, fmt.Errorf("browse %s: %w", node, err)
+	}
+	byName := make(map[string]BrowsedNode, len(children))
+	for _, child := range children {
+		if _, ok := byName[child.BrowseName]; !ok {
+			byName[child.BrowseName] = child
+		}
+	}
+	return byName, nil
+}
+
+// hasChild сообщает, есть ли у узла дочерний узел с именем name
+func hasChild(children map[string]BrowsedNode, name string) bool {
+	_, ok := children[name]
+	return ok
+}
+
+// machineToolJob - задание ProductionPlan
+type machineToolJob struct {
+	identifier    string
+	runsCompleted float64
+}
+
+// MachineToolData - модель станка OPC 40501. Узлы находятся обходом адресного пространства
+// (DiscoverNodes) один раз на сессию и передаются каждому новому объекту через UseDiscoveredNodes
+type MachineToolData struct {
+	nodes     *DiscoveredNodes
+	resp      models.MachineDataResponse
+	activeJob string
+	jobs      []machineToolJob
+
+	SampleQuality
+}
+
+// NewMachineToolData создаёт модель станка OPC 40501 без найденных узлов
+func NewMachineToolData() *MachineToolData {
+	return &MachineToolData{}
+}
+
+func (m *MachineToolData) DiscoverNodes(b NodeBrowser) (*DiscoveredNodes, error) {
+	return DiscoverMachineTool(b)
+}
+
+func (m *MachineToolData) UseDiscoveredNodes(nodes *DiscoveredNodes) {
+	if m.nodes == nodes {
+		return
+	}
+	m.nodes = nodes
+	m.resp = models.MachineDataResponse{
+		SpindleInfos: make([]models.SpindleInfosResponse, len(nodes.spindles)),
+		ChannelInfos: make([]models.ChannelInfosResponse, len(nodes.channels)),
+	}
+	for i, name := range nodes.spindles {
+		m.resp.SpindleInfos[i].Name = name
+	}
+	for i, name := range nodes.channels {
+		m.resp.ChannelInfos[i].Name = name
+	}
+	m.activeJob = ""
+	m.jobs = make([]machineToolJob, nodes.jobs)
+}
+
+func (m *MachineToolData) GetRelevantNodeIDs() []ua.ExpandedNodeID {
+	if m.nodes == nil {
+		return nil
+	}
+	return m.nodes.nodeIDs
+}
+
+func (m *MachineToolData) ConvertNodeToMachineData(nodeID string, v any) error {
+	if m.nodes == nil {
+		return errors.New("MachineTool nodes are not discovered")
+	}
+	roles, ok := m.nodes.roles[nodeID]
+	if !ok {
+		return fmt.Errorf("unsupported NodeID: %s", nodeID)
+	}
+	if v == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, node := range roles {
+		if err := m.convert(nodeID, node, v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", m.nodes.names[nodeID], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// convert записывает значение узла в ответ по назначению узла
+func (m *MachineToolData) convert(nodeID string, node machineToolNode, v any) error {
+	switch node.role {
+	case roleSerialNumber:
+		m.resp.MachineId = scalarString(v)
+	case roleProgramName:
+		m.resp.CurrentProgram.ProgramName = scalarString(v)
+	case roleProgramState:
+		m.resp.MachineState = scalarString(v)
+	case roleActiveJob:
+		m.activeJob = scalarString(v)
+	case roleJobIdentifier:
+		m.jobs[node.index].identifier = scalarString(v)
+	case roleOperationMode:
+		m.resp.ProgramMode = m.enumName(nodeID, v)
+	case roleChannelState:
+		m.resp.ChannelInfos[node.index].State = m.enumName(nodeID, v)
+	case roleChannelMode:
+		m.resp.ChannelInfos[node.index].Mode = m.enumName(nodeID, v)
+	case roleSpindleIsRotating, roleSpindleIsUsedAsAxis:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T, expected Boolean", v)
+		}
+		if node.role == roleSpindleIsRotating {
+			m.resp.SpindleInfos[node.index].IsRotating = b
+		} else {
+			m.resp.SpindleInfos[node.index].IsUsedAsAxis = b
+		}
+	default:
+		number, ok := toFloat(v)
+		if !ok {
+			return fmt.Errorf("unexpected type %T, expected number", v)
+		}
+		switch node.role {
+		case rolePowerOnDuration:
+			ms := number * 60 * 60 * 1000 // PowerOnDuration - часы
+			m.resp.PowerOnTime = formatTime(&ms)
+		case roleChannelFeedOverride:
+			m.resp.ChannelInfos[node.index].FeedOverride = number
+		case roleChannelRapidOverride:
+			m.resp.ChannelInfos[node.index].RapidOverride = number
+		case roleSpindleOverride:
+			m.resp.SpindleInfos[node.index].Override = number
+		case roleProgramNumber:
+			m.resp.CurrentProgram.ProgramNumber = int(number)
+		case roleJobRunsCompleted:
+			m.jobs[node.index].runsCompleted = number
+		}
+	}
+	return nil
+}
+
+// enumName возвращает имя значения перечисления, без известных имён - число
+func (m *MachineToolData) enumName(nodeID string, v any) string {
+	if number, ok := toFloat(v); ok {
+		if name, ok := m.nodes.enums[nodeID][int64(number)]; ok {
+			return name
+		}
+	}
+	return scalarString(v)
+}
+
+func (m *MachineToolData) GetExecutionStack() ([]opc_custom.ProgramPositionDataType, error) {
+	return nil, errors.New("execution stack is not defined by OPC 40501")
+}
+
+func (m *MachineToolData) GetMachineID() (*string, error) {
+	if m.resp.MachineId == "" {
+		return nil, nil
+	}
+	id := m.resp.MachineId
+	return &id, nil
+}
+
+func (m *MachineToolData) ToResponse() models.MachineDataResponse {
+	resp := m.resp
+	resp.Timestamp = m.SampleTimestamp()
+	resp.SpindleInfos = append([]models.SpindleInfosResponse(nil), m.resp.SpindleInfos...)
+	resp.ChannelInfos = append([]models.ChannelInfosResponse(nil), m.resp.ChannelInfos...)
+
+	// Коррекция подачи станка - коррекция первого канала
+	if len(resp.ChannelInfos) > 0 {
+		resp.FeedOverride = uint32(math.Round(math.Max(resp.ChannelInfos[0].FeedOverride, 0)))
+	}
+	// Счётчик деталей - выполненные прогоны активного задания; без идентификатора - единственного задания
+	for _, job := range m.jobs {
+		if (m.activeJob != "" && job.identifier == m.activeJob) || (m.activeJob == "" && len(m.jobs) == 1) {
+			resp.PartsCount = job.runsCompleted
+		}
+	}
+	if m.nodes != nil {
+		resp.Quality = m.QualityByName(m.nodes.names)
+	}
+	return resp
+}
+
+func (m *MachineToolData) ToJSON() string {
+	bytes, err := json.MarshalIndent(m.ToResponse(), "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal MachineToolData to JSON: %v", err)
+		return ""
+	}
+	return string(bytes)
+}
@@ -0,0 +1,352 @@
+package machine_models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"gopkg.in/yaml.v3"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/opc_custom"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// MachineProfile - декларативное описание модели станка: какие узлы читать и в какие поля
+// MachineDataResponse их записывать. Профили загружаются из YAML или JSON файлов и интерпретируются
+// ProfileMachineData, поэтому новая модель ЧПУ не требует Go-кода.
+type MachineProfile struct {
+	Name         string        `yaml:"name,omitempty" json:"name,omitempty"` // Имя профиля в реестре, для файлов необязательно
+	Manufacturer string        `yaml:"manufacturer" json:"manufacturer"`
+	Models       []string      `yaml:"models" json:"models"`
+	Description  string        `yaml:"description,omitempty" json:"description,omitempty"`
+	Nodes        []ProfileNode `yaml:"nodes" json:"nodes"`
+
+	Source  string `yaml:"-" json:"-"` // Файл, из которого загружен профиль
+	Version int    `yaml:"-" json:"-"` // Версия профиля в реестре, 0 - профиль из файла
+
+	compiled []compiledNode
+	keys     map[string][]int  // ключ узла nsu=... -> индексы в compiled
+	names    map[string]string // ключ узла -> имя значения в quality
+	nodeIDs  []ua.ExpandedNodeID
+}
+
+// ProfileNode - узел профиля и правило его преобразования в поле ответа
+type ProfileNode struct {
+	Name     string            `yaml:"name" json:"name"`                               // Имя значения в поле quality
+	NodeID   string            `yaml:"node_id" json:"node_id"`                         // nsu=<URI>;<идентификатор> или узел пространства имён 0
+	DataType string            `yaml:"data_type,omitempty" json:"data_type,omitempty"` // Ожидаемый тип: Boolean, Int32, Double, String, LocalizedText, Structure, ...
+	Path     string            `yaml:"path,omitempty" json:"path,omitempty"`           // Поле структуры через точку, например Position.X
+	Field    string            `yaml:"field" json:"field"`                             // Поле MachineDataResponse: feed_override, current_program.program_name, axis_infos.X.position
+	Scale    *float64          `yaml:"scale,omitempty" json:"scale,omitempty"`         // Числовое значение умножается на scale
+	Offset   float64           `yaml:"offset,omitempty" json:"offset,omitempty"`       // и к нему прибавляется offset
+	Duration string            `yaml:"duration,omitempty" json:"duration,omitempty"`   // Единица длительности (ms, s, min, h) для полей времени ЧЧ:ММ:СС
+	Enum     map[string]string `yaml:"enum,omitempty" json:"enum,omitempty"`           // Значение узла -> значение поля
+}
+
+// compiledNode - узел профиля с разобранным NodeID и найденным полем ответа
+type compiledNode struct {
+	ProfileNode
+	nodeID   ua.ExpandedNodeID
+	dataType reflect.Type // nil - тип не проверяется
+	path     []string
+	field    profileField
+	duration float64 // миллисекунд в единице Duration
+}
+
+// durationUnits - единицы длительности значений узлов в миллисекундах
+var durationUnits = map[string]float64{"ms": 1, "s": 1000, "min": 60 * 1000, "h": 60 * 60 * 1000}
+
+// profileExcludedFields - поля ответа, которые заполняет сервис, а не профиль
+var profileExcludedFields = map[string]bool{"timestamp": true, "has_alarms": true, "alarm_status": true, "backfilled": true}
+
+// structureDataType - data_type узла со структурой, декодированной в map[string]any
+const structureDataType = "Structure"
+
+// Validate проверяет профиль и подготавливает его к использованию: разбирает NodeID, находит поля ответа и
+// проверяет преобразования. Возвращает все найденные ошибки сразу
+func (p *MachineProfile) Validate() error {
+	var errs []error
+	if p.Manufacturer == "" {
+		errs = append(errs, errors.New("manufacturer is required"))
+	}
+	if len(p.Models) == 0 {
+		errs = append(errs, errors.New("at least one model is required"))
+	}
+	for _, model := range p.Models {
+		if model == "" {
+			errs = append(errs, errors.New("model name must not be empty"))
+		}
+	}
+	if len(p.Nodes) == 0 {
+		errs = append(errs, errors.New("at least one node is required"))
+	}
+
+	compiled := make([]compiledNode, 0, len(p.Nodes))
+	keys := make(map[string][]int)
+	names := make(map[string]string)
+	fields := make(map[string]string)
+	var nodeIDs []ua.ExpandedNodeID
+	for i, node := range p.Nodes {
+		c, err := compileNode(node)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("node %d (%s): %w", i, node.Name, err))
+			continue
+		}
+		if other, ok := fields[node.Field]; ok {
+			errs = append(errs, fmt.Errorf("node %d (%s): field %s is already set by %s", i, node.Name, node.Field, other))
+			continue
+		}
+		fields[node.Field] = node.Name
+
+		key := c.nodeID.String()
+		if _, ok := keys[key]; !ok {
+			// Один узел может заполнять несколько полей (например, поля одной структуры), но читается один раз
+			nodeIDs = append(nodeIDs, c.nodeID)
+			names[key] = node.Name
+		}
+		keys[key] = append(keys[key], len(compiled))
+		compiled = append(compiled, c)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	p.compiled, p.keys, p.names, p.nodeIDs = compiled, keys, names, nodeIDs
+	return nil
+}
+
+// compileNode разбирает узел профиля
+func compileNode(node ProfileNode) (compiledNode, error) {
+	c := compiledNode{ProfileNode: node}
+	if node.Name == "" {
+		return c, errors.New("name is required")
+	}
+
+	c.nodeID = ua.ParseExpandedNodeID(node.NodeID)
+	if c.nodeID.NodeID == nil {
+		return c, fmt.Errorf("invalid node_id %q", node.NodeID)
+	}
+	if c.nodeID.NamespaceURI == "" && namespaceIndex(c.nodeID.NodeID) != 0 {
+		// Индекс пространства имён зависит от сервера, поэтому узлы производителя задаются по URI
+		return c, fmt.Errorf("node_id %q must use nsu=<namespace URI> instead of ns=<index>", node.NodeID)
+	}
+
+	switch node.DataType {
+	case "", structureDataType:
+	default:
+		typ, ok := opc_custom.BuiltinTypeByName(node.DataType)
+		if !ok {
+			return c, fmt.Errorf("unknown data_type %s", node.DataType)
+		}
+		c.dataType = typ
+	}
+	if node.Path != "" {
+		if node.DataType != "" && node.DataType != structureDataType {
+			return c, fmt.Errorf("path requires data_type %s", structureDataType)
+		}
+		c.path = strings.Split(node.Path, ".")
+	}
+
+	field, err := resolveProfileField(node.Field)
+	if err != nil {
+		return c, err
+	}
+	c.field = field
+
+	if node.Duration != "" {
+		unit, ok := durationUnits[node.Duration]
+		if !ok {
+			return c, fmt.Errorf("unknown duration unit %s, expected ms, s, min or h", node.Duration)
+		}
+		if field.kind != reflect.String {
+			return c, fmt.Errorf("duration requires a string field, %s is %s", node.Field, field.kind)
+		}
+		c.duration = unit
+	}
+	for from, to := range node.Enum {
+		if _, err := field.convert(to); err != nil {
+			return c, fmt.Errorf("enum value %s -> %q: %w", from, to, err)
+		}
+	}
+	return c, nil
+}
+
+// namespaceIndex возвращает индекс пространства имён NodeID
+func namespaceIndex(nodeID ua.NodeID) uint16 {
+	switch id := nodeID.(type) {
+	case ua.NodeIDNumeric:
+		return id.NamespaceIndex
+	case ua.NodeIDString:
+		return id.NamespaceIndex
+	case ua.NodeIDGUID:
+		return id.NamespaceIndex
+	case ua.NodeIDOpaque:
+		return id.NamespaceIndex
+	}
+	return 0
+}
+
+// ParseMachineProfile разбирает профиль в формате YAML или JSON (по расширению name) и проверяет его.
+// Неизвестные ключи считаются ошибкой, чтобы опечатка в имени ключа не превращалась в молча пропущенное правило
+func ParseMachineProfile(name string, data []byte) (*MachineProfile, error) {
+	profile := &MachineProfile{Source: name}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(profile); err != nil {
+			return nil, fmt.Errorf("parse profile %s: %w", name, err)
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(profile); err != nil {
+			return nil, fmt.Errorf("parse profile %s: %w", name, err)
+		}
+	default:
+		return nil, fmt.Errorf("profile %s: unsupported format, expected .yaml, .yml or .json", name)
+	}
+
+	if err := profile.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", name, err)
+	}
+	return profile, nil
+}
+
+// LoadMachineProfiles загружает профили из файлов .yaml, .yml и .json каталога dir. Ошибки отдельных файлов
+// объединяются в возвращаемую ошибку, остальные профили возвращаются. Отсутствующий каталог - не ошибка
+func LoadMachineProfiles(dir string) ([]*MachineProfile, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read profiles directory: %w", err)
+	}
+
+	var profiles []*MachineProfile
+	var errs []error
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read profile %s: %w", path, err))
+			continue
+		}
+		profile, err := ParseMachineProfile(path, data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, errors.Join(errs...)
+}
+
+// profileField - поле MachineDataResponse, которое заполняет узел профиля
+type profileField struct {
+	kind reflect.Kind
+	set  func(resp *models.MachineDataResponse, value reflect.Value)
+}
+
+// resolveProfileField находит поле ответа по пути из json-имён: feed_override, current_program.program_name.
+// Для осей путь содержит имя оси: axis_infos.<имя>.position
+func resolveProfileField(path string) (profileField, error) {
+	if path == "" {
+		return profileField{}, errors.New("field is required")
+	}
+	parts := strings.Split(path, ".")
+	if profileExcludedFields[parts[0]] {
+		return profileField{}, fmt.Errorf("field %s is set by the service", path)
+	}
+
+	respType := reflect.TypeOf(models.MachineDataResponse{})
+	if parts[0] == "axis_infos" {
+		if len(parts) != 3 || parts[1] == "" {
+			return profileField{}, fmt.Errorf("axis field %s must be axis_infos.<axis name>.<field>", path)
+		}
+		axisField, ok := structField(reflect.TypeOf(models.AxisInfosResponse{}), parts[2])
+		if !ok || axisField.Name == "Name" || !isScalarKind(axisField.Type.Kind()) {
+			return profileField{}, fmt.Errorf("unknown axis field %s", parts[2])
+		}
+		axis := parts[1]
+		return profileField{
+			kind: axisField.Type.Kind(),
+			set: func(resp *models.MachineDataResponse, value reflect.Value) {
+				i := axisIndex(resp.AxisInfos, axis)
+				if i < 0 {
+					resp.AxisInfos = append(resp.AxisInfos, models.AxisInfosResponse{Name: axis})
+					i = len(resp.AxisInfos) - 1
+				}
+				reflect.ValueOf(&resp.AxisInfos[i]).Elem().FieldByIndex(axisField.Index).Set(value)
+			},
+		}, nil
+	}
+
+	var index []int
+	typ := respType
+	for i, part := range parts {
+		field, ok := structField(typ, part)
+		if !ok {
+			return profileField{}, fmt.Errorf("unknown field %s", path)
+		}
+		index = append(index, field.Index...)
+		typ = field.Type
+		if typ.Kind() == reflect.Struct && i < len(parts)-1 {
+			continue
+		}
+		if i < len(parts)-1 || !isScalarKind(typ.Kind()) {
+			return profileField{}, fmt.Errorf("field %s is not a scalar field", path)
+		}
+	}
+	return profileField{
+		kind: typ.Kind(),
+		set: func(resp *models.MachineDataResponse, value reflect.Value) {
+			reflect.ValueOf(resp).Elem().FieldByIndex(index).Set(value)
+		},
+	}, nil
+}
+
+// structField ищет поле структуры по json-имени
+func structField(typ reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// isScalarKind - поддерживаемые профилями типы полей ответа
+func isScalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool, reflect.Float64, reflect.Uint32, reflect.Int:
+		return true
+	}
+	return false
+}
+
+// axisIndex возвращает индекс оси с именем name или -1
+func axisIndex(axes []models.AxisInfosResponse, name string) int {
+	for i := range axes {
+		if axes[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,210 @@
+package machine_models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"log"
+	"math"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/opc_custom"
+	"reflect"
+	"strconv"
+)
+
+// ProfileMachineData - модель станка, описанная профилем: значения узлов преобразуются по правилам
+// профиля и записываются в поля MachineDataResponse
+type ProfileMachineData struct {
+	profile *MachineProfile
+	values  []reflect.Value // преобразованные значения по узлам профиля, нулевое - значения нет
+
+	SampleQuality
+}
+
+// NewProfileMachineData создаёт модель станка по проверенному профилю
+func NewProfileMachineData(profile *MachineProfile) *ProfileMachineData {
+	return &ProfileMachineData{
+		profile: profile,
+		values:  make([]reflect.Value, len(profile.compiled)),
+	}
+}
+
+func (m *ProfileMachineData) GetRelevantNodeIDs() []ua.ExpandedNodeID {
+	return m.profile.nodeIDs
+}
+
+func (m *ProfileMachineData) ConvertNodeToMachineData(nodeID string, v any) error {
+	indexes, ok := m.profile.keys[nodeID]
+	if !ok {
+		return fmt.Errorf("unsupported NodeID: %s", nodeID)
+	}
+
+	var errs []error
+	for _, i := range indexes {
+		node := &m.profile.compiled[i]
+		if v == nil {
+			m.values[i] = reflect.Value{}
+			continue
+		}
+		value, err := node.convert(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", node.Name, err))
+			continue
+		}
+		m.values[i] = value
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("node %s: %w", nodeID, errors.Join(errs...))
+	}
+	return nil
+}
+
+func (m *ProfileMachineData) GetExecutionStack() ([]opc_custom.ProgramPositionDataType, error) {
+	return nil, fmt.Errorf("execution stack is not supported by profile of %s", m.profile.Manufacturer)
+}
+
+func (m *ProfileMachineData) GetMachineID() (*string, error) {
+	resp := m.ToResponse()
+	if resp.MachineId == "" {
+		return nil, nil
+	}
+	return &resp.MachineId, nil
+}
+
+func (m *ProfileMachineData) ToResponse() models.MachineDataResponse {
+	resp := models.MachineDataResponse{Timestamp: m.SampleTimestamp()}
+	for i, value := range m.values {
+		if value.IsValid() {
+			m.profile.compiled[i].field.set(&resp, value)
+		}
+	}
+	resp.Quality = m.QualityByName(m.profile.names)
+	return resp
+}
+
+func (m *ProfileMachineData) ToJSON() string {
+	bytes, err := json.MarshalIndent(m.ToResponse(), "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal %s profile data to JSON: %v", m.profile.Manufacturer, err)
+		return ""
+	}
+	return string(bytes)
+}
+
+// convert преобразует значение узла по правилам профиля: проверка типа, поле структуры, перечисление,
+// масштаб и длительность, затем приведение к типу поля ответа
+func (c *compiledNode) convert(v any) (reflect.Value, error) {
+	if c.dataType != nil && reflect.TypeOf(v) != c.dataType {
+		return reflect.Value{}, fmt.Errorf("type mismatch: expected %s, got %T", c.DataType, v)
+	}
+	if c.DataType == structureDataType {
+		if _, ok := v.(map[string]any); !ok {
+			return reflect.Value{}, fmt.Errorf("type mismatch: expected decoded structure, got %T", v)
+		}
+	}
+
+	for _, name := range c.path {
+		structure, ok := v.(map[string]any)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("path %s: %T is not a structure", c.Path, v)
+		}
+		if v, ok = structure[name]; !ok {
+			return reflect.Value{}, fmt.Errorf("path %s: no field %s", c.Path, name)
+		}
+	}
+
+	if c.Enum != nil {
+		mapped, ok := c.Enum[scalarString(v)]
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("no enum mapping for value %v", scalarString(v))
+		}
+		return c.field.convert(mapped)
+	}
+
+	if c.Scale != nil || c.Offset != 0 || c.duration != 0 {
+		number, ok := toFloat(v)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("value of type %T is not a number", v)
+		}
+		if c.Scale != nil {
+			number *= *c.Scale
+		}
+		number += c.Offset
+		if c.duration != 0 {
+			ms := number * c.duration
+			return reflect.ValueOf(formatTime(&ms)), nil
+		}
+		v = number
+	}
+	return c.field.convert(v)
+}
+
+// convert приводит значение к типу поля ответа
+func (f profileField) convert(v any) (reflect.Value, error) {
+	switch f.kind {
+	case reflect.String:
+		return reflect.ValueOf(scalarString(v)), nil
+	case reflect.Bool:
+		switch val := v.(type) {
+		case bool:
+			return reflect.ValueOf(val), nil
+		case string:
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%q is not a boolean", val)
+			}
+			return reflect.ValueOf(b), nil
+		}
+		if number, ok := toFloat(v); ok {
+			return reflect.ValueOf(number != 0), nil
+		}
+	case reflect.Float64, reflect.Uint32, reflect.Int:
+		number, ok := toFloat(v)
+		if s, isString := v.(string); isString {
+			parsed, err := strconv.ParseFloat(s, 64)
+			number, ok = parsed, err == nil
+		}
+		if !ok {
+			break
+		}
+		switch f.kind {
+		case reflect.Uint32:
+			if number < 0 || number > math.MaxUint32 {
+				return reflect.Value{}, fmt.Errorf("%v is out of uint32 range", number)
+			}
+			return reflect.ValueOf(uint32(math.Round(number))), nil
+		case reflect.Int:
+			return reflect.ValueOf(int(math.Round(number))), nil
+		}
+		return reflect.ValueOf(number), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %T to %s", v, f.kind)
+}
+
+// toFloat возвращает числовое значение узла
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// scalarString - строковое представление значения узла: текст LocalizedText, остальные через fmt
+func scalarString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case ua.LocalizedText:
+		return val.Text
+	case ua.QualifiedName:
+		return val.Name
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,135 @@
+package machine_models
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Реестр профилей моделей станков. Профили из каталога (RegisterMachineProfile) задаются при старте, версии
+// профилей из БД (RegisterStoredProfile) добавляются и удаляются во время работы через REST API. Для станка
+// выбирается закреплённая за ним версия, иначе последняя версия профиля из БД, иначе профиль из каталога.
+var (
+	profilesMu     sync.RWMutex
+	fileProfiles   = make(map[string]*MachineProfile)         // профили каталога по производителю и модели
+	storedProfiles = make(map[string]map[int]*MachineProfile) // версии профилей из БД по имени профиля
+	latestProfiles = make(map[string]*MachineProfile)         // последние версии профилей из БД по производителю и модели
+	pinnedProfiles = make(map[string]*MachineProfile)         // закреплённые версии по UUID станка
+)
+
+// profileKey - ключ профиля в реестре
+func profileKey(manufacturer, model string) string {
+	return manufacturer + "/" + model
+}
+
+// RegisterMachineProfile добавляет профиль из каталога для всех его моделей. Профиль той же модели заменяется
+func RegisterMachineProfile(profile *MachineProfile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	for _, model := range profile.Models {
+		fileProfiles[profileKey(profile.Manufacturer, model)] = profile
+	}
+}
+
+// RegisterStoredProfile добавляет версию профиля из БД. Новые опросы моделей профиля используют его
+// последнюю версию, уже запущенные продолжают работать с прежней
+func RegisterStoredProfile(profile *MachineProfile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	versions, ok := storedProfiles[profile.Name]
+	if !ok {
+		versions = make(map[int]*MachineProfile)
+		storedProfiles[profile.Name] = versions
+	}
+	versions[profile.Version] = profile
+	rebuildLatestProfiles()
+}
+
+// RemoveStoredProfile удаляет версию профиля из БД, version 0 - все версии профиля
+func RemoveStoredProfile(name string, version int) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	if version == 0 {
+		delete(storedProfiles, name)
+	} else if versions, ok := storedProfiles[name]; ok {
+		delete(versions, version)
+		if len(versions) == 0 {
+			delete(storedProfiles, name)
+		}
+	}
+	rebuildLatestProfiles()
+}
+
+// rebuildLatestProfiles пересобирает последние версии по моделям: модели, которые исключены из новой версии
+// профиля, перестают ему соответствовать. Вызывается под profilesMu
+func rebuildLatestProfiles() {
+	latestProfiles = make(map[string]*MachineProfile)
+	for _, versions := range storedProfiles {
+		var latest *MachineProfile
+		for _, profile := range versions {
+			if latest == nil || profile.Version > latest.Version {
+				latest = profile
+			}
+		}
+		for _, model := range latest.Models {
+			latestProfiles[profileKey(latest.Manufacturer, model)] = latest
+		}
+	}
+}
+
+// StoredProfile возвращает версию профиля из БД
+func StoredProfile(name string, version int) (*MachineProfile, bool) {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	profile, ok := storedProfiles[name][version]
+	return profile, ok
+}
+
+// LatestStoredProfile возвращает последнюю версию профиля из БД для модели станка
+func LatestStoredProfile(manufacturer, model string) (*MachineProfile, bool) {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	profile, ok := latestProfiles[profileKey(manufacturer, model)]
+	return profile, ok
+}
+
+// PinMachineProfile закрепляет за станком версию профиля из БД
+func PinMachineProfile(machineID, name string, version int) error {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profile, ok := storedProfiles[name][version]
+	if !ok {
+		return fmt.Errorf("profile %s version %d is not registered", name, version)
+	}
+	pinnedProfiles[machineID] = profile
+	return nil
+}
+
+// UnpinMachineProfile снимает закрепление версии профиля со станка
+func UnpinMachineProfile(machineID string) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	delete(pinnedProfiles, machineID)
+}
+
+// FindMachineProfile возвращает профиль модели станка: последнюю версию из БД, иначе профиль из каталога
+func FindMachineProfile(manufacturer, model string) (*MachineProfile, bool) {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	key := profileKey(manufacturer, model)
+	if profile, ok := latestProfiles[key]; ok {
+		return profile, true
+	}
+	profile, ok := fileProfiles[key]
+	return profile, ok
+}
+
+// FindMachineProfileFor возвращает профиль для конкретного станка с учётом закреплённой версии
+func FindMachineProfileFor(machineID, manufacturer, model string) (*MachineProfile, bool) {
+	profilesMu.RLock()
+	profile, ok := pinnedProfiles[machineID]
+	profilesMu.RUnlock()
+	if ok {
+		return profile, true
+	}
+	return FindMachineProfile(manufacturer, model)
+}
@@ -0,0 +1,94 @@
+package machine_models
+
+import (
+	"strings"
+	"testing"
+)
+
+const testProfileYAML = `
+manufacturer: Fanuc
+models: [30i-B]
+nodes:
+  - name: FeedOverride
+    node_id: nsu=http://fanuc.com/UA/CNC/;s=Feed.Override
+    data_type: Double
+    field: feed_override
+  - name: ProgramName
+    node_id: nsu=http://fanuc.com/UA/CNC/;s=Program.Name
+    data_type: String
+    field: current_program.program_name
+  - name: AxisX
+    node_id: nsu=http://fanuc.com/UA/CNC/;s=Axis.Position
+    data_type: Structure
+    path: Position.X
+    field: axis_infos.X.position
+  - name: AxisY
+    node_id: nsu=http://fanuc.com/UA/CNC/;s=Axis.Position
+    data_type: Structure
+    path: Position.Y
+    field: axis_infos.Y.position
+`
+
+func TestParseMachineProfile(t *testing.T) {
+	profile, err := ParseMachineProfile("fanuc.yaml", []byte(testProfileYAML))
+	if err != nil {
+		t.Fatalf("ParseMachineProfile() error = %v", err)
+	}
+	if profile.Manufacturer != "Fanuc" || len(profile.Models) != 1 || profile.Source != "fanuc.yaml" {
+		t.Fatalf("неверно разобран профиль: %+v", profile)
+	}
+	// Узел, заполняющий несколько полей, читается один раз
+	if len(profile.nodeIDs) != 3 {
+		t.Fatalf("узлов для чтения %d, ожидалось 3", len(profile.nodeIDs))
+	}
+}
+
+func TestParseMachineProfileErrors(t *testing.T) {
+	node := func(fields string) string {
+		return "manufacturer: Fanuc\nmodels: [30i-B]\nnodes:\n  - " + strings.ReplaceAll(fields, "\n", "\n    ")
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		wantErr string
+	}{
+		{name: "неизвестный формат", file: "fanuc.xml", data: testProfileYAML, wantErr: "unsupported format"},
+		{name: "неизвестный ключ YAML", file: "fanuc.yaml", data: testProfileYAML + "vendor: x\n", wantErr: "vendor"},
+		{name: "неизвестный ключ JSON", file: "fanuc.json",
+			data: `{"manufacturer":"Fanuc","models":["30i-B"],"nodes":[],"vendor":"x"}`, wantErr: "vendor"},
+		{name: "без производителя и моделей", file: "fanuc.json", data: `{"nodes":[]}`, wantErr: "manufacturer is required"},
+		{name: "без узлов", file: "fanuc.json", data: `{"manufacturer":"Fanuc","models":["30i-B"]}`, wantErr: "at least one node is required"},
+		{name: "пустое имя модели", file: "fanuc.yaml",
+			data: strings.Replace(testProfileYAML, "[30i-B]", `["30i-B", ""]`, 1), wantErr: "model name must not be empty"},
+		{name: "индекс пространства имён", file: "fanuc.yaml",
+			data: node("name: Feed\nnode_id: ns=2;s=Feed\nfield: feed_override"), wantErr: "must use nsu="},
+		{name: "неверный NodeID", file: "fanuc.yaml",
+			data: node("name: Feed\nnode_id: '???'\nfield: feed_override"), wantErr: "invalid node_id"},
+		{name: "неизвестный тип", file: "fanuc.yaml",
+			data: node("name: Feed\nnode_id: nsu=urn:cnc;s=Feed\ndata_type: Decimal128\nfield: feed_override"), wantErr: "unknown data_type"},
+		{name: "path без структуры", file: "fanuc.yaml",
+			data: node("name: Feed\nnode_id: nsu=urn:cnc;s=Feed\ndata_type: Double\npath: A.B\nfield: feed_override"), wantErr: "path requires data_type"},
+		{name: "неизвестное поле", file: "fanuc.yaml",
+			data: node("name: Feed\nnode_id: nsu=urn:cnc;s=Feed\nfield: feed_speed"), wantErr: "feed_speed"},
+		{name: "поле заполняет сервис", file: "fanuc.yaml",
+			data: node("name: Alarm\nnode_id: nsu=urn:cnc;s=Alarm\nfield: has_alarms"), wantErr: "has_alarms"},
+		{name: "неизвестная единица длительности", file: "fanuc.yaml",
+			data: node("name: Up\nnode_id: nsu=urn:cnc;s=Up\nduration: days\nfield: power_on_time"), wantErr: "unknown duration unit"},
+		{name: "длительность в числовое поле", file: "fanuc.yaml",
+			data: node("name: Feed\nnode_id: nsu=urn:cnc;s=Feed\nduration: s\nfield: feed_rate"), wantErr: "duration requires a string field"},
+		{name: "поле задано дважды", file: "fanuc.yaml",
+			data: node("name: Feed\nnode_id: nsu=urn:cnc;s=Feed\nfield: feed_rate") + "\n  - " +
+				"name: Feed2\n    node_id: nsu=urn:cnc;s=Feed2\n    field: feed_rate", wantErr: "already set by Feed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMachineProfile(tt.file, []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseMachineProfile() error = %v, ожидалась ошибка с %q", err, tt.wantErr)
+			}
+		})
+	}
+}
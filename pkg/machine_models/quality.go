@@ -0,0 +1,91 @@
+package machine_models
+
+import (
+	"github.com/awcullen/opcua/ua"
+	"opc_ua_service/internal/domain/models"
+	"opc_ua_service/pkg/opc_custom"
+	"time"
+)
+
+// SampleQuality хранит качество и метки времени последних прочитанных значений узлов.
+// Встраивается в модели станков, чтобы ToResponse мог передать их в payload.
+type SampleQuality struct {
+	samples map[string]models.ValueQuality
+	latest  time.Time
+}
+
+// RecordSample запоминает StatusCode и метки времени значения узла, в том числе плохого
+func (q *SampleQuality) RecordSample(nodeID string, value ua.DataValue) {
+	if q.samples == nil {
+		q.samples = make(map[string]models.ValueQuality)
+	}
+
+	q.samples[nodeID] = NewValueQuality(nodeID, value)
+
+	// Время снимка - самая поздняя метка времени: источника, сервера или момента получения
+	ts := value.SourceTimestamp
+	if ts.IsZero() {
+		ts = value.ServerTimestamp
+	}
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	if ts.After(q.latest) {
+		q.latest = ts
+	}
+}
+
+// SampleTimestamp возвращает время снимка в Unix мс. Если значений ещё не было, возвращается текущее время
+func (q *SampleQuality) SampleTimestamp() int64 {
+	if q.latest.IsZero() {
+		return time.Now().UnixMilli()
+	}
+	return q.latest.UnixMilli()
+}
+
+// QualityByName возвращает качество значений с ключами по именам узлов из names.
+// Узлы без имени в names попадают в результат под своим NodeID.
+func (q *SampleQuality) QualityByName(names map[string]string) map[string]models.ValueQuality {
+	if len(q.samples) == 0 {
+		return nil
+	}
+	result := make(map[string]models.ValueQuality, len(q.samples))
+	for nodeID, sample := range q.samples {
+		name, ok := names[nodeID]
+		if !ok {
+			name = nodeID
+		}
+		result[name] = sample
+	}
+	return result
+}
+
+// NewValueQuality возвращает качество, имя StatusCode и метки времени прочитанного значения узла
+func NewValueQuality(nodeID string, value ua.DataValue) models.ValueQuality {
+	sample := models.ValueQuality{
+		NodeID:     nodeID,
+		Quality:    qualityOf(value.StatusCode),
+		StatusCode: opc_custom.StatusCodeName(value.StatusCode),
+	}
+	if !value.SourceTimestamp.IsZero() {
+		ts := value.SourceTimestamp
+		sample.SourceTimestamp = &ts
+	}
+	if !value.ServerTimestamp.IsZero() {
+		ts := value.ServerTimestamp
+		sample.ServerTimestamp = &ts
+	}
+	return sample
+}
+
+// qualityOf сводит StatusCode к качеству значения
+func qualityOf(code ua.StatusCode) models.ValueQualityEnum {
+	switch {
+	case code.IsGood():
+		return models.QualityGood
+	case code.IsUncertain():
+		return models.QualityUncertain
+	default:
+		return models.QualityBad
+	}
+}
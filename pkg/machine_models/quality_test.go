@@ -0,0 +1,97 @@
+package machine_models
+
+import (
+	"github.com/awcullen/opcua/ua"
+	"opc_ua_service/internal/domain/models"
+	"testing"
+	"time"
+)
+
+func TestQualityOf(t *testing.T) {
+	tests := []struct {
+		name string
+		code ua.StatusCode
+		want models.ValueQualityEnum
+	}{
+		{name: "Good", code: ua.Good, want: models.QualityGood},
+		{name: "GoodLocalOverride", code: ua.GoodLocalOverride, want: models.QualityGood},
+		{name: "UncertainLastUsableValue", code: ua.UncertainLastUsableValue, want: models.QualityUncertain},
+		{name: "UncertainSensorNotAccurate", code: ua.UncertainSensorNotAccurate, want: models.QualityUncertain},
+		{name: "BadNodeIDUnknown", code: ua.BadNodeIDUnknown, want: models.QualityBad},
+		{name: "BadCommunicationError", code: ua.BadCommunicationError, want: models.QualityBad},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := qualityOf(tt.code); got != tt.want {
+				t.Fatalf("qualityOf(%s) = %s, want %s", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampleQuality(t *testing.T) {
+	source := time.Date(2025, 8, 22, 12, 0, 0, 0, time.UTC)
+	server := source.Add(time.Minute)
+
+	tests := []struct {
+		name          string
+		samples       map[string]ua.DataValue
+		names         map[string]string
+		wantQuality   map[string]models.ValueQualityEnum
+		wantTimestamp time.Time
+	}{
+		{
+			name: "время снимка по самой поздней метке источника",
+			samples: map[string]ua.DataValue{
+				"nsu=urn:cnc;s=Feed":  {StatusCode: ua.Good, SourceTimestamp: source, ServerTimestamp: server},
+				"nsu=urn:cnc;s=Speed": {StatusCode: ua.UncertainLastUsableValue, SourceTimestamp: source.Add(-time.Hour)},
+			},
+			names:         map[string]string{"nsu=urn:cnc;s=Feed": "FeedOverride"},
+			wantQuality:   map[string]models.ValueQualityEnum{"FeedOverride": models.QualityGood, "nsu=urn:cnc;s=Speed": models.QualityUncertain},
+			wantTimestamp: source,
+		},
+		{
+			name: "метка сервера, если нет метки источника",
+			samples: map[string]ua.DataValue{
+				"nsu=urn:cnc;s=Mode": {StatusCode: ua.BadNodeIDUnknown, ServerTimestamp: server},
+			},
+			names:         map[string]string{"nsu=urn:cnc;s=Mode": "OperatingMode"},
+			wantQuality:   map[string]models.ValueQualityEnum{"OperatingMode": models.QualityBad},
+			wantTimestamp: server,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q SampleQuality
+			for nodeID, value := range tt.samples {
+				q.RecordSample(nodeID, value)
+			}
+
+			got := q.QualityByName(tt.names)
+			if len(got) != len(tt.wantQuality) {
+				t.Fatalf("QualityByName() = %v, want %v", got, tt.wantQuality)
+			}
+			for name, want := range tt.wantQuality {
+				if got[name].Quality != want {
+					t.Fatalf("качество %s = %s, want %s", name, got[name].Quality, want)
+				}
+			}
+			if ts := q.SampleTimestamp(); ts != tt.wantTimestamp.UnixMilli() {
+				t.Fatalf("SampleTimestamp() = %d, want %d", ts, tt.wantTimestamp.UnixMilli())
+			}
+		})
+	}
+}
+
+func TestSampleQualityEmpty(t *testing.T) {
+	var q SampleQuality
+	if got := q.QualityByName(nil); got != nil {
+		t.Fatalf("QualityByName() без значений = %v, want nil", got)
+	}
+	before := time.Now().UnixMilli()
+	if ts := q.SampleTimestamp(); ts < before {
+		t.Fatalf("SampleTimestamp() без значений = %d, ожидалось текущее время", ts)
+	}
+}
@@ -0,0 +1,158 @@
+package opc_custom
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"reflect"
+	"strings"
+)
+
+// Пространства имён словаря типов OPC Binary
+const (
+	binarySchemaNamespace = "http://opcfoundation.org/BinarySchema/"
+	uaNamespace           = "http://opcfoundation.org/UA/"
+)
+
+// binarySchemaTypes - Go-типы типов opc: и стандартных типов ua: словаря OPC Binary
+var binarySchemaTypes = map[string]uint32{
+	"Boolean": 1, "SByte": 2, "Byte": 3, "Int16": 4, "UInt16": 5, "Int32": 6, "UInt32": 7, "Int64": 8, "UInt64": 9,
+	"Float": 10, "Double": 11, "String": 12, "CharArray": 12, "DateTime": 13, "Guid": 14, "ByteString": 15,
+	"XmlElement": 16, "NodeId": 17, "ExpandedNodeId": 18, "StatusCode": 19, "QualifiedName": 20, "LocalizedText": 21,
+	"ExtensionObject": 22, "DataValue": 23, "Variant": 24, "DiagnosticInfo": 25,
+}
+
+// uaStructureTypes - стандартные структуры ua:, которые встречаются в полях структур производителей ЧПУ
+var uaStructureTypes = map[string]reflect.Type{
+	"EUInformation":    reflect.TypeOf(ua.EUInformation{}),
+	"Range":            reflect.TypeOf(ua.Range{}),
+	"Argument":         reflect.TypeOf(ua.Argument{}),
+	"EnumValueType":    reflect.TypeOf(ua.EnumValueType{}),
+	"TimeZoneDataType": reflect.TypeOf(ua.TimeZoneDataType{}),
+}
+
+// TypeDictionary - словарь типов OPC Binary (opc:TypeDictionary), которым серверы OPC UA 1.03 описывают
+// свои структуры вместо атрибута DataTypeDefinition
+type TypeDictionary struct {
+	TargetNamespace string
+	structures      map[string]dictionaryStructure
+	enumerations    map[string]bool
+	prefixes        map[string]string // префикс пространства имён -> URI
+}
+
+type dictionaryStructure struct {
+	Name   string            `xml:"Name,attr"`
+	Fields []dictionaryField `xml:"Field"`
+}
+
+type dictionaryField struct {
+	Name        string `xml:"Name,attr"`
+	TypeName    string `xml:"TypeName,attr"`
+	LengthField string `xml:"LengthField,attr"`
+	SwitchField string `xml:"SwitchField,attr"`
+}
+
+// ParseTypeDictionary разбирает XML словаря типов OPC Binary
+func ParseTypeDictionary(data []byte) (*TypeDictionary, error) {
+	var doc struct {
+		TargetNamespace string                `xml:"TargetNamespace,attr"`
+		Attrs           []xml.Attr            `xml:",any,attr"`
+		Structures      []dictionaryStructure `xml:"StructuredType"`
+		Enumerations    []struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"EnumeratedType"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse type dictionary: %w", err)
+	}
+
+	dict := &TypeDictionary{
+		TargetNamespace: doc.TargetNamespace,
+		structures:      make(map[string]dictionaryStructure, len(doc.Structures)),
+		enumerations:    make(map[string]bool, len(doc.Enumerations)),
+		prefixes:        make(map[string]string),
+	}
+	for _, attr := range doc.Attrs {
+		if attr.Name.Space == "xmlns" {
+			dict.prefixes[attr.Name.Local] = attr.Value
+		}
+	}
+	for _, structure := range doc.Structures {
+		dict.structures[structure.Name] = structure
+	}
+	for _, enum := range doc.Enumerations {
+		dict.enumerations[enum.Name] = true
+	}
+	return dict, nil
+}
+
+// StructureType строит Go-тип структуры словаря. Поле с LengthField становится массивом, а поле длины
+// пропускается: массив кодируется так же, как длина и элементы подряд. Необязательные поля (SwitchField)
+// не поддерживаются
+func (d *TypeDictionary) StructureType(name string) (reflect.Type, error) {
+	return d.structureType(name, make(map[string]bool))
+}
+
+func (d *TypeDictionary) structureType(name string, resolving map[string]bool) (reflect.Type, error) {
+	structure, ok := d.structures[name]
+	if !ok {
+		return nil, fmt.Errorf("structure %s is not in type dictionary %s", name, d.TargetNamespace)
+	}
+	if resolving[name] {
+		return nil, fmt.Errorf("recursive structure %s", name)
+	}
+	resolving[name] = true
+	defer delete(resolving, name)
+
+	lengthFields := make(map[string]bool)
+	for _, field := range structure.Fields {
+		if field.LengthField != "" {
+			lengthFields[field.LengthField] = true
+		}
+	}
+
+	fields := make([]StructureField, 0, len(structure.Fields))
+	for _, field := range structure.Fields {
+		if field.SwitchField != "" {
+			return nil, fmt.Errorf("structure %s: optional field %s is not supported", name, field.Name)
+		}
+		if lengthFields[field.Name] {
+			continue
+		}
+		typ, err := d.fieldType(field.TypeName, resolving)
+		if err != nil {
+			return nil, fmt.Errorf("structure %s, field %s: %w", name, field.Name, err)
+		}
+		if field.LengthField != "" {
+			typ = reflect.SliceOf(typ)
+		}
+		fields = append(fields, StructureField{Name: field.Name, Type: typ})
+	}
+	return NewStructureType(d.TargetNamespace+"#"+name, fields), nil
+}
+
+// fieldType возвращает Go-тип поля по его TypeName вида prefix:Name
+func (d *TypeDictionary) fieldType(typeName string, resolving map[string]bool) (reflect.Type, error) {
+	prefix, name, ok := strings.Cut(typeName, ":")
+	if !ok {
+		prefix, name = "", typeName
+	}
+
+	switch d.prefixes[prefix] {
+	case binarySchemaNamespace, uaNamespace:
+		if id, ok := binarySchemaTypes[name]; ok {
+			return builtinTypes[id], nil
+		}
+		if typ, ok := uaStructureTypes[name]; ok {
+			return typ, nil
+		}
+	case d.TargetNamespace:
+		if d.enumerations[name] {
+			return builtinTypes[6], nil // перечисления кодируются как Int32
+		}
+		if _, ok := d.structures[name]; ok {
+			return d.structureType(name, resolving)
+		}
+	}
+	return nil, fmt.Errorf("unsupported type %s", typeName)
+}
@@ -0,0 +1,265 @@
+package opc_custom
+
+import (
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+)
+
+// StatusCodeName возвращает символьное имя StatusCode (например, BadNodeIdUnknown).
+// Для кодов, отсутствующих в таблице, возвращается шестнадцатеричное значение.
+func StatusCodeName(code ua.StatusCode) string {
+	// Биты InfoBits не входят в имя кода
+	if name, ok := statusCodeNames[code&0xFFFF0000]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%08X", uint32(code))
+}
+
+// statusCodeNames - имена StatusCode из спецификации OPC UA (Part 6, StatusCode.csv)
+var statusCodeNames = map[ua.StatusCode]string{
+	ua.Good:                                                   "Good",
+	ua.BadUnexpectedError:                                     "BadUnexpectedError",
+	ua.BadInternalError:                                       "BadInternalError",
+	ua.BadOutOfMemory:                                         "BadOutOfMemory",
+	ua.BadResourceUnavailable:                                 "BadResourceUnavailable",
+	ua.BadCommunicationError:                                  "BadCommunicationError",
+	ua.BadEncodingError:                                       "BadEncodingError",
+	ua.BadDecodingError:                                       "BadDecodingError",
+	ua.BadEncodingLimitsExceeded:                              "BadEncodingLimitsExceeded",
+	ua.BadRequestTooLarge:                                     "BadRequestTooLarge",
+	ua.BadResponseTooLarge:                                    "BadResponseTooLarge",
+	ua.BadUnknownResponse:                                     "BadUnknownResponse",
+	ua.BadTimeout:                                             "BadTimeout",
+	ua.BadServiceUnsupported:                                  "BadServiceUnsupported",
+	ua.BadShutdown:                                            "BadShutdown",
+	ua.BadServerNotConnected:                                  "BadServerNotConnected",
+	ua.BadServerHalted:                                        "BadServerHalted",
+	ua.BadNothingToDo:                                         "BadNothingToDo",
+	ua.BadTooManyOperations:                                   "BadTooManyOperations",
+	ua.BadTooManyMonitoredItems:                               "BadTooManyMonitoredItems",
+	ua.BadDataTypeIDUnknown:                                   "BadDataTypeIDUnknown",
+	ua.BadCertificateInvalid:                                  "BadCertificateInvalid",
+	ua.BadSecurityChecksFailed:                                "BadSecurityChecksFailed",
+	ua.BadCertificatePolicyCheckFailed:                        "BadCertificatePolicyCheckFailed",
+	ua.BadCertificateTimeInvalid:                              "BadCertificateTimeInvalid",
+	ua.BadCertificateIssuerTimeInvalid:                        "BadCertificateIssuerTimeInvalid",
+	ua.BadCertificateHostNameInvalid:                          "BadCertificateHostNameInvalid",
+	ua.BadCertificateURIInvalid:                               "BadCertificateURIInvalid",
+	ua.BadCertificateUseNotAllowed:                            "BadCertificateUseNotAllowed",
+	ua.BadCertificateIssuerUseNotAllowed:                      "BadCertificateIssuerUseNotAllowed",
+	ua.BadCertificateUntrusted:                                "BadCertificateUntrusted",
+	ua.BadCertificateRevocationUnknown:                        "BadCertificateRevocationUnknown",
+	ua.BadCertificateIssuerRevocationUnknown:                  "BadCertificateIssuerRevocationUnknown",
+	ua.BadCertificateRevoked:                                  "BadCertificateRevoked",
+	ua.BadCertificateIssuerRevoked:                            "BadCertificateIssuerRevoked",
+	ua.BadCertificateChainIncomplete:                          "BadCertificateChainIncomplete",
+	ua.BadUserAccessDenied:                                    "BadUserAccessDenied",
+	ua.BadIdentityTokenInvalid:                                "BadIdentityTokenInvalid",
+	ua.BadIdentityTokenRejected:                               "BadIdentityTokenRejected",
+	ua.BadSecureChannelIDInvalid:                              "BadSecureChannelIDInvalid",
+	ua.BadInvalidTimestamp:                                    "BadInvalidTimestamp",
+	ua.BadNonceInvalid:                                        "BadNonceInvalid",
+	ua.BadSessionIDInvalid:                                    "BadSessionIDInvalid",
+	ua.BadSessionClosed:                                       "BadSessionClosed",
+	ua.BadSessionNotActivated:                                 "BadSessionNotActivated",
+	ua.BadSubscriptionIDInvalid:                               "BadSubscriptionIDInvalid",
+	ua.BadRequestHeaderInvalid:                                "BadRequestHeaderInvalid",
+	ua.BadTimestampsToReturnInvalid:                           "BadTimestampsToReturnInvalid",
+	ua.BadRequestCancelledByClient:                            "BadRequestCancelledByClient",
+	ua.BadTooManyArguments:                                    "BadTooManyArguments",
+	ua.BadLicenseExpired:                                      "BadLicenseExpired",
+	ua.BadLicenseLimitsExceeded:                               "BadLicenseLimitsExceeded",
+	ua.BadLicenseNotAvailable:                                 "BadLicenseNotAvailable",
+	ua.GoodSubscriptionTransferred:                            "GoodSubscriptionTransferred",
+	ua.GoodCompletesAsynchronously:                            "GoodCompletesAsynchronously",
+	ua.GoodOverload:                                           "GoodOverload",
+	ua.GoodClamped:                                            "GoodClamped",
+	ua.BadNoCommunication:                                     "BadNoCommunication",
+	ua.BadWaitingForInitialData:                               "BadWaitingForInitialData",
+	ua.BadNodeIDInvalid:                                       "BadNodeIDInvalid",
+	ua.BadNodeIDUnknown:                                       "BadNodeIDUnknown",
+	ua.BadAttributeIDInvalid:                                  "BadAttributeIDInvalid",
+	ua.BadIndexRangeInvalid:                                   "BadIndexRangeInvalid",
+	ua.BadIndexRangeNoData:                                    "BadIndexRangeNoData",
+	ua.BadDataEncodingInvalid:                                 "BadDataEncodingInvalid",
+	ua.BadDataEncodingUnsupported:                             "BadDataEncodingUnsupported",
+	ua.BadNotReadable:                                         "BadNotReadable",
+	ua.BadNotWritable:                                         "BadNotWritable",
+	ua.BadOutOfRange:                                          "BadOutOfRange",
+	ua.BadNotSupported:                                        "BadNotSupported",
+	ua.BadNotFound:                                            "BadNotFound",
+	ua.BadObjectDeleted:                                       "BadObjectDeleted",
+	ua.BadNotImplemented:                                      "BadNotImplemented",
+	ua.BadMonitoringModeInvalid:                               "BadMonitoringModeInvalid",
+	ua.BadMonitoredItemIDInvalid:                              "BadMonitoredItemIDInvalid",
+	ua.BadMonitoredItemFilterInvalid:                          "BadMonitoredItemFilterInvalid",
+	ua.BadMonitoredItemFilterUnsupported:                      "BadMonitoredItemFilterUnsupported",
+	ua.BadFilterNotAllowed:                                    "BadFilterNotAllowed",
+	ua.BadStructureMissing:                                    "BadStructureMissing",
+	ua.BadEventFilterInvalid:                                  "BadEventFilterInvalid",
+	ua.BadContentFilterInvalid:                                "BadContentFilterInvalid",
+	ua.BadFilterOperatorInvalid:                               "BadFilterOperatorInvalid",
+	ua.BadFilterOperatorUnsupported:                           "BadFilterOperatorUnsupported",
+	ua.BadFilterOperandCountMismatch:                          "BadFilterOperandCountMismatch",
+	ua.BadFilterOperandInvalid:                                "BadFilterOperandInvalid",
+	ua.BadFilterElementInvalid:                                "BadFilterElementInvalid",
+	ua.BadFilterLiteralInvalid:                                "BadFilterLiteralInvalid",
+	ua.BadContinuationPointInvalid:                            "BadContinuationPointInvalid",
+	ua.BadNoContinuationPoints:                                "BadNoContinuationPoints",
+	ua.BadReferenceTypeIDInvalid:                              "BadReferenceTypeIDInvalid",
+	ua.BadBrowseDirectionInvalid:                              "BadBrowseDirectionInvalid",
+	ua.BadNodeNotInView:                                       "BadNodeNotInView",
+	ua.BadNumericOverflow:                                     "BadNumericOverflow",
+	ua.BadServerURIInvalid:                                    "BadServerURIInvalid",
+	ua.BadServerNameMissing:                                   "BadServerNameMissing",
+	ua.BadDiscoveryURLMissing:                                 "BadDiscoveryURLMissing",
+	ua.BadSempahoreFileMissing:                                "BadSempahoreFileMissing",
+	ua.BadRequestTypeInvalid:                                  "BadRequestTypeInvalid",
+	ua.BadSecurityModeRejected:                                "BadSecurityModeRejected",
+	ua.BadSecurityPolicyRejected:                              "BadSecurityPolicyRejected",
+	ua.BadTooManySessions:                                     "BadTooManySessions",
+	ua.BadUserSignatureInvalid:                                "BadUserSignatureInvalid",
+	ua.BadApplicationSignatureInvalid:                         "BadApplicationSignatureInvalid",
+	ua.BadNoValidCertificates:                                 "BadNoValidCertificates",
+	ua.BadIdentityChangeNotSupported:                          "BadIdentityChangeNotSupported",
+	ua.BadRequestCancelledByRequest:                           "BadRequestCancelledByRequest",
+	ua.BadParentNodeIDInvalid:                                 "BadParentNodeIDInvalid",
+	ua.BadReferenceNotAllowed:                                 "BadReferenceNotAllowed",
+	ua.BadNodeIDRejected:                                      "BadNodeIDRejected",
+	ua.BadNodeIDExists:                                        "BadNodeIDExists",
+	ua.BadNodeClassInvalid:                                    "BadNodeClassInvalid",
+	ua.BadBrowseNameInvalid:                                   "BadBrowseNameInvalid",
+	ua.BadBrowseNameDuplicated:                                "BadBrowseNameDuplicated",
+	ua.BadNodeAttributesInvalid:                               "BadNodeAttributesInvalid",
+	ua.BadTypeDefinitionInvalid:                               "BadTypeDefinitionInvalid",
+	ua.BadSourceNodeIDInvalid:                                 "BadSourceNodeIDInvalid",
+	ua.BadTargetNodeIDInvalid:                                 "BadTargetNodeIDInvalid",
+	ua.BadDuplicateReferenceNotAllowed:                        "BadDuplicateReferenceNotAllowed",
+	ua.BadInvalidSelfReference:                                "BadInvalidSelfReference",
+	ua.BadReferenceLocalOnly:                                  "BadReferenceLocalOnly",
+	ua.BadNoDeleteRights:                                      "BadNoDeleteRights",
+	ua.UncertainReferenceNotDeleted:                           "UncertainReferenceNotDeleted",
+	ua.BadServerIndexInvalid:                                  "BadServerIndexInvalid",
+	ua.BadViewIDUnknown:                                       "BadViewIDUnknown",
+	ua.BadViewTimestampInvalid:                                "BadViewTimestampInvalid",
+	ua.BadViewParameterMismatch:                               "BadViewParameterMismatch",
+	ua.BadViewVersionInvalid:                                  "BadViewVersionInvalid",
+	ua.UncertainNotAllNodesAvailable:                          "UncertainNotAllNodesAvailable",
+	ua.GoodResultsMayBeIncomplete:                             "GoodResultsMayBeIncomplete",
+	ua.BadNotTypeDefinition:                                   "BadNotTypeDefinition",
+	ua.UncertainReferenceOutOfServer:                          "UncertainReferenceOutOfServer",
+	ua.BadTooManyMatches:                                      "BadTooManyMatches",
+	ua.BadQueryTooComplex:                                     "BadQueryTooComplex",
+	ua.BadNoMatch:                                             "BadNoMatch",
+	ua.BadMaxAgeInvalid:                                       "BadMaxAgeInvalid",
+	ua.BadSecurityModeInsufficient:                            "BadSecurityModeInsufficient",
+	ua.BadHistoryOperationInvalid:                             "BadHistoryOperationInvalid",
+	ua.BadHistoryOperationUnsupported:                         "BadHistoryOperationUnsupported",
+	ua.BadInvalidTimestampArgument:                            "BadInvalidTimestampArgument",
+	ua.BadWriteNotSupported:                                   "BadWriteNotSupported",
+	ua.BadTypeMismatch:                                        "BadTypeMismatch",
+	ua.BadMethodInvalid:                                       "BadMethodInvalid",
+	ua.BadArgumentsMissing:                                    "BadArgumentsMissing",
+	ua.BadNotExecutable:                                       "BadNotExecutable",
+	ua.BadTooManySubscriptions:                                "BadTooManySubscriptions",
+	ua.BadTooManyPublishRequests:                              "BadTooManyPublishRequests",
+	ua.BadNoSubscription:                                      "BadNoSubscription",
+	ua.BadSequenceNumberUnknown:                               "BadSequenceNumberUnknown",
+	ua.BadMessageNotAvailable:                                 "BadMessageNotAvailable",
+	ua.BadInsufficientClientProfile:                           "BadInsufficientClientProfile",
+	ua.BadStateNotActive:                                      "BadStateNotActive",
+	ua.BadAlreadyExists:                                       "BadAlreadyExists",
+	ua.BadTCPServerTooBusy:                                    "BadTCPServerTooBusy",
+	ua.BadTCPMessageTypeInvalid:                               "BadTCPMessageTypeInvalid",
+	ua.BadTCPSecureChannelUnknown:                             "BadTCPSecureChannelUnknown",
+	ua.BadTCPMessageTooLarge:                                  "BadTCPMessageTooLarge",
+	ua.BadTCPNotEnoughResources:                               "BadTCPNotEnoughResources",
+	ua.BadTCPInternalError:                                    "BadTCPInternalError",
+	ua.BadTCPEndpointURLInvalid:                               "BadTCPEndpointURLInvalid",
+	ua.BadRequestInterrupted:                                  "BadRequestInterrupted",
+	ua.BadRequestTimeout:                                      "BadRequestTimeout",
+	ua.BadSecureChannelClosed:                                 "BadSecureChannelClosed",
+	ua.BadSecureChannelTokenUnknown:                           "BadSecureChannelTokenUnknown",
+	ua.BadSequenceNumberInvalid:                               "BadSequenceNumberInvalid",
+	ua.BadProtocolVersionUnsupported:                          "BadProtocolVersionUnsupported",
+	ua.BadConfigurationError:                                  "BadConfigurationError",
+	ua.BadNotConnected:                                        "BadNotConnected",
+	ua.BadDeviceFailure:                                       "BadDeviceFailure",
+	ua.BadSensorFailure:                                       "BadSensorFailure",
+	ua.BadOutOfService:                                        "BadOutOfService",
+	ua.BadDeadbandFilterInvalid:                               "BadDeadbandFilterInvalid",
+	ua.UncertainNoCommunicationLastUsableValue:                "UncertainNoCommunicationLastUsableValue",
+	ua.UncertainLastUsableValue:                               "UncertainLastUsableValue",
+	ua.UncertainSubstituteValue:                               "UncertainSubstituteValue",
+	ua.UncertainInitialValue:                                  "UncertainInitialValue",
+	ua.UncertainSensorNotAccurate:                             "UncertainSensorNotAccurate",
+	ua.UncertainEngineeringUnitsExceeded:                      "UncertainEngineeringUnitsExceeded",
+	ua.UncertainSubNormal:                                     "UncertainSubNormal",
+	ua.GoodLocalOverride:                                      "GoodLocalOverride",
+	ua.BadRefreshInProgress:                                   "BadRefreshInProgress",
+	ua.BadConditionAlreadyDisabled:                            "BadConditionAlreadyDisabled",
+	ua.BadConditionAlreadyEnabled:                             "BadConditionAlreadyEnabled",
+	ua.BadConditionDisabled:                                   "BadConditionDisabled",
+	ua.BadEventIDUnknown:                                      "BadEventIDUnknown",
+	ua.BadEventNotAcknowledgeable:                             "BadEventNotAcknowledgeable",
+	ua.BadDialogNotActive:                                     "BadDialogNotActive",
+	ua.BadDialogResponseInvalid:                               "BadDialogResponseInvalid",
+	ua.BadConditionBranchAlreadyAcked:                         "BadConditionBranchAlreadyAcked",
+	ua.BadConditionBranchAlreadyConfirmed:                     "BadConditionBranchAlreadyConfirmed",
+	ua.BadConditionAlreadyShelved:                             "BadConditionAlreadyShelved",
+	ua.BadConditionNotShelved:                                 "BadConditionNotShelved",
+	ua.BadShelvingTimeOutOfRange:                              "BadShelvingTimeOutOfRange",
+	ua.BadNoData:                                              "BadNoData",
+	ua.BadBoundNotFound:                                       "BadBoundNotFound",
+	ua.BadBoundNotSupported:                                   "BadBoundNotSupported",
+	ua.BadDataLost:                                            "BadDataLost",
+	ua.BadDataUnavailable:                                     "BadDataUnavailable",
+	ua.BadEntryExists:                                         "BadEntryExists",
+	ua.BadNoEntryExists:                                       "BadNoEntryExists",
+	ua.BadTimestampNotSupported:                               "BadTimestampNotSupported",
+	ua.GoodEntryInserted:                                      "GoodEntryInserted",
+	ua.GoodEntryReplaced:                                      "GoodEntryReplaced",
+	ua.UncertainDataSubNormal:                                 "UncertainDataSubNormal",
+	ua.GoodNoData:                                             "GoodNoData",
+	ua.GoodMoreData:                                           "GoodMoreData",
+	ua.BadAggregateListMismatch:                               "BadAggregateListMismatch",
+	ua.BadAggregateNotSupported:                               "BadAggregateNotSupported",
+	ua.BadAggregateInvalidInputs:                              "BadAggregateInvalidInputs",
+	ua.BadAggregateConfigurationRejected:                      "BadAggregateConfigurationRejected",
+	ua.GoodDataIgnored:                                        "GoodDataIgnored",
+	ua.BadRequestNotAllowed:                                   "BadRequestNotAllowed",
+	ua.BadRequestNotComplete:                                  "BadRequestNotComplete",
+	ua.GoodEdited:                                             "GoodEdited",
+	ua.GoodPostActionFailed:                                   "GoodPostActionFailed",
+	ua.UncertainDominantValueChanged:                          "UncertainDominantValueChanged",
+	ua.GoodDependentValueChanged:                              "GoodDependentValueChanged",
+	ua.BadDominantValueChanged:                                "BadDominantValueChanged",
+	ua.UncertainDependentValueChanged:                         "UncertainDependentValueChanged",
+	ua.BadDependentValueChanged:                               "BadDependentValueChanged",
+	ua.GoodEditedDependentValueChanged:                        "GoodEditedDependentValueChanged",
+	ua.GoodEditedDominantValueChanged:                         "GoodEditedDominantValueChanged",
+	ua.GoodEditedDominantValueChangedDependentValueChanged:    "GoodEditedDominantValueChangedDependentValueChanged",
+	ua.BadEditedOutOfRange:                                    "BadEditedOutOfRange",
+	ua.BadInitialValueOutOfRange:                              "BadInitialValueOutOfRange",
+	ua.BadOutOfRangeDominantValueChanged:                      "BadOutOfRangeDominantValueChanged",
+	ua.BadEditedOutOfRangeDominantValueChanged:                "BadEditedOutOfRangeDominantValueChanged",
+	ua.BadOutOfRangeDominantValueChangedDependentValueChanged: "BadOutOfRangeDominantValueChangedDependentValueChanged",
+	ua.BadEditedOutOfRangeDominantValueChangedDependentValueChanged: "BadEditedOutOfRangeDominantValueChangedDependentValueChanged",
+	ua.GoodCommunicationEvent:   "GoodCommunicationEvent",
+	ua.GoodShutdownEvent:        "GoodShutdownEvent",
+	ua.GoodCallAgain:            "GoodCallAgain",
+	ua.GoodNonCriticalTimeout:   "GoodNonCriticalTimeout",
+	ua.BadInvalidArgument:       "BadInvalidArgument",
+	ua.BadConnectionRejected:    "BadConnectionRejected",
+	ua.BadDisconnect:            "BadDisconnect",
+	ua.BadConnectionClosed:      "BadConnectionClosed",
+	ua.BadInvalidState:          "BadInvalidState",
+	ua.BadEndOfStream:           "BadEndOfStream",
+	ua.BadNoDataAvailable:       "BadNoDataAvailable",
+	ua.BadWaitingForResponse:    "BadWaitingForResponse",
+	ua.BadOperationAbandoned:    "BadOperationAbandoned",
+	ua.BadExpectedStreamToBlock: "BadExpectedStreamToBlock",
+	ua.BadWouldBlock:            "BadWouldBlock",
+	ua.BadSyntaxError:           "BadSyntaxError",
+	ua.BadMaxConnectionsReached: "BadMaxConnectionsReached",
+}
@@ -0,0 +1,305 @@
+package opc_custom
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Динамические структуры. Go-тип структуры строится по описанию типа данных с сервера (DataTypeDefinition
+// или словарь типов) и регистрируется для её кодировки в awcullen/opcua, поэтому ExtensionObject типа, для
+// которого нет Go-кода, декодируется, а DecodeStructures превращает его в map[string]any.
+
+// StructureField - поле структуры: имя на сервере и Go-тип значения
+type StructureField struct {
+	Name string
+	Type reflect.Type
+}
+
+var (
+	structuresMu    sync.RWMutex
+	structureFields = make(map[reflect.Type][]string) // имена полей динамических структур в порядке полей Go-типа
+	dataTypes       = make(map[string]reflect.Type)   // Go-типы по ExpandedNodeID типа данных (nsu=<URI>;<id>)
+)
+
+// builtinTypes - Go-типы, в которые awcullen/opcua декодирует стандартные типы данных пространства имён 0
+var builtinTypes = map[uint32]reflect.Type{
+	1:  reflect.TypeOf(false),
+	2:  reflect.TypeOf(int8(0)),
+	3:  reflect.TypeOf(uint8(0)),
+	4:  reflect.TypeOf(int16(0)),
+	5:  reflect.TypeOf(uint16(0)),
+	6:  reflect.TypeOf(int32(0)),
+	7:  reflect.TypeOf(uint32(0)),
+	8:  reflect.TypeOf(int64(0)),
+	9:  reflect.TypeOf(uint64(0)),
+	10: reflect.TypeOf(float32(0)),
+	11: reflect.TypeOf(float64(0)),
+	12: reflect.TypeOf(""),
+	13: reflect.TypeOf(time.Time{}),
+	14: reflect.TypeOf(uuid.UUID{}),
+	15: reflect.TypeOf(ua.ByteString("")),
+	16: reflect.TypeOf(ua.XMLElement("")),
+	17: reflect.TypeOf((*ua.NodeID)(nil)).Elem(),
+	18: reflect.TypeOf(ua.ExpandedNodeID{}),
+	19: reflect.TypeOf(ua.StatusCode(0)),
+	20: reflect.TypeOf(ua.QualifiedName{}),
+	21: reflect.TypeOf(ua.LocalizedText{}),
+	22: reflect.TypeOf((*ua.ExtensionObject)(nil)).Elem(), // абстрактная Structure кодируется как ExtensionObject
+	23: reflect.TypeOf(ua.DataValue{}),
+	24: reflect.TypeOf((*ua.Variant)(nil)).Elem(),
+	25: reflect.TypeOf(ua.DiagnosticInfo{}),
+	26: reflect.TypeOf((*ua.Variant)(nil)).Elem(), // Number
+	27: reflect.TypeOf((*ua.Variant)(nil)).Elem(), // Integer
+	28: reflect.TypeOf((*ua.Variant)(nil)).Elem(), // UInteger
+	29: reflect.TypeOf(int32(0)),                  // Enumeration
+}
+
+// Guid - поле типа Guid в структуре. Декодер awcullen/opcua читает uuid.UUID только как значение Variant,
+// а поле структуры с массивом [16]byte не декодирует. Guid кодируется теми же 16 байтами, что и Guid OPC UA
+// (Data1-Data3 в little-endian, Data4 как есть), и в DecodeStructures заменяется на uuid.UUID
+type Guid struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 uint64 // 8 байт Data4 в порядке передачи
+}
+
+// UUID возвращает Guid в виде uuid.UUID, как awcullen/opcua декодирует значения Guid
+func (g Guid) UUID() uuid.UUID {
+	var id uuid.UUID
+	binary.BigEndian.PutUint32(id[0:4], g.Data1)
+	binary.BigEndian.PutUint16(id[4:6], g.Data2)
+	binary.BigEndian.PutUint16(id[6:8], g.Data3)
+	binary.LittleEndian.PutUint64(id[8:16], g.Data4)
+	return id
+}
+
+// NewGuid возвращает Guid для uuid.UUID
+func NewGuid(id uuid.UUID) Guid {
+	return Guid{
+		Data1: binary.BigEndian.Uint32(id[0:4]),
+		Data2: binary.BigEndian.Uint16(id[4:6]),
+		Data3: binary.BigEndian.Uint16(id[6:8]),
+		Data4: binary.LittleEndian.Uint64(id[8:16]),
+	}
+}
+
+var (
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	guidType = reflect.TypeOf(Guid{})
+)
+
+// BuiltinType возвращает Go-тип стандартного типа данных с числовым идентификатором в пространстве имён 0
+func BuiltinType(id uint32) (reflect.Type, bool) {
+	typ, ok := builtinTypes[id]
+	return typ, ok
+}
+
+// BuiltinTypeByName возвращает Go-тип стандартного типа данных по его имени (Boolean, Int32, Double, LocalizedText, ...)
+func BuiltinTypeByName(name string) (reflect.Type, bool) {
+	id, ok := binarySchemaTypes[name]
+	if !ok {
+		return nil, false
+	}
+	return builtinTypes[id], true
+}
+
+// FindDataType возвращает Go-тип, ранее построенный для типа данных сервера
+func FindDataType(key string) (reflect.Type, bool) {
+	structuresMu.RLock()
+	defer structuresMu.RUnlock()
+	typ, ok := dataTypes[key]
+	return typ, ok
+}
+
+// StoreDataType запоминает Go-тип типа данных сервера. Ключ - ExpandedNodeID типа с URI пространства имён,
+// поэтому тип переиспользуется всеми сессиями и серверами с тем же пространством имён
+func StoreDataType(key string, typ reflect.Type) {
+	structuresMu.Lock()
+	defer structuresMu.Unlock()
+	dataTypes[key] = typ
+}
+
+// NewStructureType строит Go-тип структуры с полями в порядке кодирования. key попадает в тег служебного
+// поля, чтобы структуры с одинаковыми полями получили разные типы: awcullen/opcua не допускает регистрацию
+// одного типа для двух кодировок
+func NewStructureType(key string, fields []StructureField) reflect.Type {
+	goFields := make([]reflect.StructField, 0, len(fields)+1)
+	names := make([]string, 0, len(fields))
+	for i, field := range fields {
+		goFields = append(goFields, reflect.StructField{Name: fmt.Sprintf("F%d_%s", i, identifier(field.Name)), Type: structureFieldType(field.Type)})
+		names = append(names, field.Name)
+	}
+	goFields = append(goFields, reflect.StructField{
+		Name: "XDataType",
+		Type: reflect.TypeOf(struct{}{}),
+		Tag:  reflect.StructTag(fmt.Sprintf("opc:%q", key)),
+	})
+
+	typ := reflect.StructOf(goFields)
+	structuresMu.Lock()
+	structureFields[typ] = names
+	structuresMu.Unlock()
+	return typ
+}
+
+// structureFieldType возвращает Go-тип поля структуры, который умеет декодировать awcullen/opcua: Guid вместо uuid.UUID
+func structureFieldType(typ reflect.Type) reflect.Type {
+	switch {
+	case typ == uuidType:
+		return guidType
+	case typ.Kind() == reflect.Slice && typ.Elem() == uuidType:
+		return reflect.SliceOf(guidType)
+	}
+	return typ
+}
+
+// RegisterStructure регистрирует Go-тип для кодировки структуры. Если для кодировки уже есть тип
+// (стандартный или объявленный в opc_custom), возвращается он. Регистрация общая для всех сессий, поэтому
+// структура с полем, которое awcullen/opcua не декодирует, не регистрируется: иначе любой ответ с таким
+// ExtensionObject завершался бы BadDecodingError. Без регистрации значение структуры остаётся пустым
+func RegisterStructure(encodingID ua.ExpandedNodeID, typ reflect.Type) (reflect.Type, error) {
+	if existing, ok := ua.FindTypeForBinaryEncodingID(encodingID); ok {
+		return existing, nil
+	}
+	if err := checkDecodable(typ); err != nil {
+		return nil, fmt.Errorf("structure %s is not registered: %w", encodingID, err)
+	}
+	ua.RegisterBinaryEncodingID(typ, encodingID)
+	return typ, nil
+}
+
+// checkDecodable проверяет, что рефлексивный декодер awcullen/opcua поддерживает тип: структуры, срезы,
+// скалярные типы и интерфейсы NodeID, ExtensionObject и Variant. Массивы и словари не поддерживаются
+func checkDecodable(typ reflect.Type) error {
+	switch typ.Kind() {
+	case reflect.Struct:
+		switch typ {
+		case reflect.TypeOf(time.Time{}), reflect.TypeOf(ua.ExpandedNodeID{}), reflect.TypeOf(ua.QualifiedName{}),
+			reflect.TypeOf(ua.LocalizedText{}), reflect.TypeOf(ua.DataValue{}), reflect.TypeOf(ua.DiagnosticInfo{}):
+			return nil
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			if err := checkDecodable(typ.Field(i).Type); err != nil {
+				return fmt.Errorf("field %s: %w", typ.Field(i).Name, err)
+			}
+		}
+		return nil
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		return checkDecodable(typ.Elem())
+	case reflect.Ptr:
+		if typ.Elem().Kind() == reflect.Struct {
+			return checkDecodable(typ.Elem())
+		}
+	case reflect.Interface:
+		switch typ {
+		case builtinTypes[17], builtinTypes[22], builtinTypes[24]:
+			return nil
+		}
+	case reflect.Bool, reflect.Int8, reflect.Uint8, reflect.Int16, reflect.Uint16, reflect.Int32, reflect.Uint32,
+		reflect.Int64, reflect.Uint64, reflect.Float32, reflect.Float64, reflect.String:
+		return nil
+	}
+	return fmt.Errorf("unsupported type %s", typ)
+}
+
+// DecodeStructures заменяет значения динамических структур на map[string]any с именами полей сервера,
+// в том числе внутри массивов ExtensionObject и Variant. Остальные значения возвращаются без изменений
+func DecodeStructures(value any) any {
+	if value == nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case []ua.ExtensionObject:
+		return decodeSlice(v)
+	case []ua.Variant:
+		return decodeSlice(v)
+	}
+	rv := reflect.ValueOf(value)
+	if _, ok := structureNames(rv.Type()); !ok {
+		return value
+	}
+	return structureValue(rv)
+}
+
+// decodeSlice заменяет динамические структуры в массиве, сохраняя тип массива
+func decodeSlice[T any](values []T) []T {
+	var result []T
+	for i, item := range values {
+		decoded := DecodeStructures(any(item))
+		if reflect.ValueOf(decoded).Kind() != reflect.Map {
+			continue
+		}
+		if result == nil {
+			result = append([]T(nil), values...)
+		}
+		result[i] = decoded.(T)
+	}
+	if result == nil {
+		return values
+	}
+	return result
+}
+
+// structureNames возвращает имена полей динамической структуры
+func structureNames(typ reflect.Type) ([]string, bool) {
+	structuresMu.RLock()
+	defer structuresMu.RUnlock()
+	names, ok := structureFields[typ]
+	return names, ok
+}
+
+// structureValue преобразует значение поля: динамические структуры в map[string]any, массивы в []any
+func structureValue(rv reflect.Value) any {
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return structureValue(rv.Elem())
+	case reflect.Struct:
+		if guid, ok := rv.Interface().(Guid); ok {
+			return guid.UUID()
+		}
+		names, ok := structureNames(rv.Type())
+		if !ok {
+			return rv.Interface()
+		}
+		result := make(map[string]any, len(names))
+		for i, name := range names {
+			result[name] = structureValue(rv.Field(i))
+		}
+		return result
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 || rv.IsNil() {
+			return rv.Interface()
+		}
+		result := make([]any, rv.Len())
+		for i := range result {
+			result[i] = structureValue(rv.Index(i))
+		}
+		return result
+	default:
+		return rv.Interface()
+	}
+}
+
+// identifier оставляет в имени поля только символы, допустимые в идентификаторе Go
+func identifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
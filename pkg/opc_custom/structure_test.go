@@ -0,0 +1,110 @@
+package opc_custom
+
+import (
+	"bytes"
+	"github.com/awcullen/opcua/ua"
+	"github.com/google/uuid"
+	"reflect"
+	"testing"
+)
+
+type testEncodingContext []string
+
+func (c testEncodingContext) NamespaceURIs() []string { return c }
+
+func TestGuidEncoding(t *testing.T) {
+	id := uuid.MustParse("72962b91-fa75-4ae6-8d28-b404dc7daf63")
+	if got := NewGuid(id).UUID(); got != id {
+		t.Fatalf("NewGuid(%s).UUID() = %s", id, got)
+	}
+
+	// Guid в поле структуры кодируется теми же байтами, что и значение Guid
+	ctx := testEncodingContext{"http://opcfoundation.org/UA/"}
+	var want, got bytes.Buffer
+	if err := ua.NewBinaryEncoder(&want, ctx).WriteGUID(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := ua.NewBinaryEncoder(&got, ctx).Encode(NewGuid(id)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Fatalf("Guid закодирован как % x, ожидалось % x", got.Bytes(), want.Bytes())
+	}
+}
+
+func TestStructureWithGuidRoundTrip(t *testing.T) {
+	ctx := testEncodingContext{"http://opcfoundation.org/UA/", "urn:test:guid"}
+	encodingID := ua.ToExpandedNodeID(ua.NewNodeIDNumeric(1, 5001), ctx)
+	typ := NewStructureType("nsu=urn:test:guid;i=3001", []StructureField{
+		{Name: "ToolId", Type: builtinTypes[14]},
+		{Name: "Name", Type: builtinTypes[12]},
+		{Name: "Replacements", Type: reflect.SliceOf(builtinTypes[14])},
+	})
+	registered, err := RegisterStructure(encodingID, typ)
+	if err != nil {
+		t.Fatalf("RegisterStructure() error = %v", err)
+	}
+
+	toolID, replacementID := uuid.New(), uuid.New()
+	value := reflect.New(registered).Elem()
+	value.Field(0).Set(reflect.ValueOf(NewGuid(toolID)))
+	value.Field(1).SetString("T12")
+	value.Field(2).Set(reflect.ValueOf([]Guid{NewGuid(replacementID)}))
+
+	var buf bytes.Buffer
+	if err := ua.NewBinaryEncoder(&buf, ctx).WriteExtensionObject(value.Interface()); err != nil {
+		t.Fatalf("WriteExtensionObject() error = %v", err)
+	}
+	var obj ua.ExtensionObject
+	if err := ua.NewBinaryDecoder(&buf, ctx).ReadExtensionObject(&obj); err != nil {
+		t.Fatalf("ReadExtensionObject() error = %v", err)
+	}
+
+	want := map[string]any{"ToolId": toolID, "Name": "T12", "Replacements": []any{replacementID}}
+	if got := DecodeStructures(obj); !reflect.DeepEqual(got, want) {
+		t.Fatalf("DecodeStructures() = %#v, want %#v", got, want)
+	}
+}
+
+func TestRegisterStructureUnsupportedField(t *testing.T) {
+	encodingID := ua.ExpandedNodeID{NamespaceURI: "urn:test:unsupported", NodeID: ua.NewNodeIDNumeric(0, 5002)}
+	typ := NewStructureType("nsu=urn:test:unsupported;i=3002", []StructureField{
+		{Name: "Counter", Type: builtinTypes[7]},
+		{Name: "Raw", Type: reflect.TypeOf([4]byte{})},
+	})
+
+	if _, err := RegisterStructure(encodingID, typ); err == nil {
+		t.Fatal("ожидалась ошибка для поля-массива")
+	}
+	// Незарегистрированная структура не ломает декодирование других ответов с этим ExtensionObject
+	if _, ok := ua.FindTypeForBinaryEncodingID(encodingID); ok {
+		t.Fatal("структура с неподдерживаемым полем зарегистрирована")
+	}
+}
+
+func TestDictionaryGuidField(t *testing.T) {
+	dict, err := ParseTypeDictionary([]byte(`<opc:TypeDictionary xmlns:opc="http://opcfoundation.org/BinarySchema/"
+		xmlns:tns="urn:test:dictionary" TargetNamespace="urn:test:dictionary">
+		<opc:StructuredType Name="ToolLife">
+			<opc:Field Name="ToolId" TypeName="opc:Guid"/>
+			<opc:Field Name="NoOfHistory" TypeName="opc:Int32"/>
+			<opc:Field Name="History" TypeName="opc:Guid" LengthField="NoOfHistory"/>
+		</opc:StructuredType>
+	</opc:TypeDictionary>`))
+	if err != nil {
+		t.Fatalf("ParseTypeDictionary() error = %v", err)
+	}
+	typ, err := dict.StructureType("ToolLife")
+	if err != nil {
+		t.Fatalf("StructureType() error = %v", err)
+	}
+	if got := typ.Field(0).Type; got != guidType {
+		t.Fatalf("поле ToolId имеет тип %s, ожидался %s", got, guidType)
+	}
+	if got := typ.Field(1).Type; got != reflect.SliceOf(guidType) {
+		t.Fatalf("поле History имеет тип %s, ожидался []%s", got, guidType)
+	}
+	if err := checkDecodable(typ); err != nil {
+		t.Fatalf("checkDecodable() error = %v", err)
+	}
+}
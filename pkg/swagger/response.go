@@ -37,3 +37,142 @@ type PollingResponse struct {
 	Type    string                 `json:"type" example:"object"`
 	Data    models.PollingResponse `json:"data"`
 }
+
+type ServerCertificateListResponse struct {
+	Status  string                               `json:"status" example:"ok"`
+	Message string                               `json:"message" example:"Successfully get server certificates"`
+	Type    string                               `json:"type" example:"object"`
+	Data    models.ServerCertificateListResponse `json:"data"`
+}
+
+type ServerCertificateResponse struct {
+	Status  string                       `json:"status" example:"ok"`
+	Message string                       `json:"message" example:"Certificate trusted"`
+	Type    string                       `json:"type" example:"object"`
+	Data    models.ServerCertificateInfo `json:"data"`
+}
+
+type EndpointsResponse struct {
+	Status  string                   `json:"status" example:"ok"`
+	Message string                   `json:"message" example:"Successfully get endpoints"`
+	Type    string                   `json:"type" example:"object"`
+	Data    models.EndpointsResponse `json:"data"`
+}
+
+type ApplicationCertificateResponse struct {
+	Status  string                            `json:"status" example:"ok"`
+	Message string                            `json:"message" example:"Successfully get application certificate"`
+	Type    string                            `json:"type" example:"object"`
+	Data    models.ApplicationCertificateInfo `json:"data"`
+}
+
+type CertificateExpiryResponse struct {
+	Status  string                           `json:"status" example:"ok"`
+	Message string                           `json:"message" example:"Successfully get certificate expiry"`
+	Type    string                           `json:"type" example:"object"`
+	Data    models.CertificateExpiryResponse `json:"data"`
+}
+
+type RotateCertificateResponse struct {
+	Status  string                       `json:"status" example:"ok"`
+	Message string                       `json:"message" example:"Certificate rotated for machine"`
+	Type    string                       `json:"type" example:"object"`
+	Data    models.CertificateExpiryInfo `json:"data"`
+}
+
+type BrowseResponse struct {
+	Status  string                `json:"status" example:"ok"`
+	Message string                `json:"message" example:"Browsed 12 nodes of machine 550e8400-e29b-41d4-a716-446655440000"`
+	Type    string                `json:"type" example:"object"`
+	Data    models.BrowseResponse `json:"data"`
+}
+
+type NodeReadResponse struct {
+	Status  string                  `json:"status" example:"ok"`
+	Message string                  `json:"message" example:"Read 1 nodes of machine 12840be9-36b2-4ecb-8243-b9d9e0952a03"`
+	Type    string                  `json:"type" example:"object"`
+	Data    models.NodeReadResponse `json:"data"`
+}
+
+type NodeWriteResponse struct {
+	Status  string                   `json:"status" example:"ok"`
+	Message string                   `json:"message" example:"Write processed for machine 12840be9-36b2-4ecb-8243-b9d9e0952a03"`
+	Type    string                   `json:"type" example:"object"`
+	Data    models.NodeWriteResponse `json:"data"`
+}
+
+type WritableNodesResponse struct {
+	Status  string                       `json:"status" example:"ok"`
+	Message string                       `json:"message" example:"Successfully get writable nodes"`
+	Type    string                       `json:"type" example:"object"`
+	Data    models.WritableNodesResponse `json:"data"`
+}
+
+type NodeWriteAuditResponse struct {
+	Status  string                        `json:"status" example:"ok"`
+	Message string                        `json:"message" example:"Successfully get write audit"`
+	Type    string                        `json:"type" example:"object"`
+	Data    models.NodeWriteAuditResponse `json:"data"`
+}
+
+type MethodCallResponse struct {
+	Status  string                    `json:"status" example:"ok"`
+	Message string                    `json:"message" example:"Method ns=2;s=/Methods/Select called on machine 12840be9-36b2-4ecb-8243-b9d9e0952a03"`
+	Type    string                    `json:"type" example:"object"`
+	Data    models.MethodCallResponse `json:"data"`
+}
+
+type CallableMethodsResponse struct {
+	Status  string                         `json:"status" example:"ok"`
+	Message string                         `json:"message" example:"Successfully get callable methods"`
+	Type    string                         `json:"type" example:"object"`
+	Data    models.CallableMethodsResponse `json:"data"`
+}
+
+type HistoryReadResponse struct {
+	Status  string                     `json:"status" example:"ok"`
+	Message string                     `json:"message" example:"Read history of 1 nodes of machine 12840be9-36b2-4ecb-8243-b9d9e0952a03"`
+	Type    string                     `json:"type" example:"object"`
+	Data    models.HistoryReadResponse `json:"data"`
+}
+
+type BackfillJobResponse struct {
+	Status  string             `json:"status" example:"ok"`
+	Message string             `json:"message" example:"Backfill started for machine 12840be9-36b2-4ecb-8243-b9d9e0952a03"`
+	Type    string             `json:"type" example:"object"`
+	Data    models.BackfillJob `json:"data"`
+}
+
+type ProfileListResponse struct {
+	Status  string                     `json:"status" example:"ok"`
+	Message string                     `json:"message" example:"Successfully get 2 machine profiles"`
+	Type    string                     `json:"type" example:"object"`
+	Data    models.ProfileListResponse `json:"data"`
+}
+
+type ProfileResponse struct {
+	Status  string                 `json:"status" example:"ok"`
+	Message string                 `json:"message" example:"Profile example-ex500 version 2 saved"`
+	Type    string                 `json:"type" example:"object"`
+	Data    models.ProfileResponse `json:"data"`
+}
+
+type ProfileValidationResponse struct {
+	Status  string                           `json:"status" example:"ok"`
+	Message string                           `json:"message" example:"Profile is invalid"`
+	Type    string                           `json:"type" example:"object"`
+	Data    models.ProfileValidationResponse `json:"data"`
+}
+
+type ProfileDeleteResponse struct {
+	Status  string `json:"status" example:"ok"`
+	Message string `json:"message" example:"Profile example-ex500 version 1 deleted"`
+	Type    string `json:"type" example:"empty"`
+}
+
+type MachineProfileResponse struct {
+	Status  string                        `json:"status" example:"ok"`
+	Message string                        `json:"message" example:"Successfully get profile of machine 12840be9-36b2-4ecb-8243-b9d9e0952a03"`
+	Type    string                        `json:"type" example:"object"`
+	Data    models.MachineProfileResponse `json:"data"`
+}